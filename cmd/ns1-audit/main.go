@@ -0,0 +1,66 @@
+// Command ns1-audit reports the users, API keys and teams of an NS1 account
+// needing review: missing two-factor authentication, unused API keys, loose
+// IP whitelists and account management privileges.
+//
+// The API key is read from the NS1_APIKEY environment variable:
+//
+//	NS1_APIKEY=... ns1-audit -format csv -unused 2160h -output audit.csv
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"gopkg.in/ns1/ns1-go.v2/rest"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dataset"
+)
+
+func main() {
+	format := flag.String("format", string(dataset.ExportTypeJSON), "report format: json or csv")
+	unused := flag.Duration("unused", 90*24*time.Hour, "report API keys not used for this long")
+	output := flag.String("output", "", "file to write the report to; standard output if empty")
+	endpoint := flag.String("endpoint", "", "NS1 API endpoint, if not the default")
+	flag.Parse()
+	exportType := dataset.ExportType(*format)
+	if exportType != dataset.ExportTypeJSON && exportType != dataset.ExportTypeCSV {
+		log.Fatalf("unsupported format %q", *format)
+	}
+
+	k := os.Getenv("NS1_APIKEY")
+	if k == "" {
+		log.Fatal("NS1_APIKEY environment variable is not set, giving up")
+	}
+
+	httpClient := &http.Client{Timeout: time.Second * 30}
+	options := []func(*rest.Client){rest.SetAPIKey(k)}
+	if *endpoint != "" {
+		options = append(options, rest.SetEndpoint(*endpoint))
+	}
+	client := rest.NewClient(httpClient, options...)
+
+	report, _, err := client.AccountAudit.Report(time.Now().Add(-*unused))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *output == "" {
+		if err := report.Write(os.Stdout, exportType); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := report.Write(f, exportType); err != nil {
+		f.Close()
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
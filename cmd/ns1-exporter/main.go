@@ -0,0 +1,73 @@
+// Command ns1-exporter serves NS1 account metrics to Prometheus.
+//
+// The API key is read from the NS1_APIKEY environment variable:
+//
+//	NS1_APIKEY=... ns1-exporter -listen :9913 -interval 1m -zones example.com,example.net
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"gopkg.in/ns1/ns1-go.v2/exporter"
+	"gopkg.in/ns1/ns1-go.v2/rest"
+)
+
+func main() {
+	listen := flag.String("listen", ":9913", "address to serve metrics on")
+	path := flag.String("path", "/metrics", "path to serve metrics on")
+	interval := flag.Duration("interval", time.Minute, "time between scrapes of the NS1 API")
+	zones := flag.String("zones", "", "comma separated zones to export; all zones if empty")
+	endpoint := flag.String("endpoint", "", "NS1 API endpoint, if not the default")
+	flag.Parse()
+
+	k := os.Getenv("NS1_APIKEY")
+	if k == "" {
+		log.Fatal("NS1_APIKEY environment variable is not set, giving up")
+	}
+
+	httpClient := &http.Client{Timeout: time.Second * 30}
+	options := []func(*rest.Client){rest.SetAPIKey(k)}
+	if *endpoint != "" {
+		options = append(options, rest.SetEndpoint(*endpoint))
+	}
+	client := rest.NewClient(httpClient, options...)
+
+	exporterOptions := []func(*exporter.Exporter){
+		exporter.SetInterval(*interval),
+		exporter.SetErrorFunc(func(err error) { log.Println(err) }),
+	}
+	if *zones != "" {
+		exporterOptions = append(exporterOptions, exporter.SetZones(strings.Split(*zones, ",")...))
+	}
+	e, err := exporter.New(client, exporterOptions...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go e.Run(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle(*path, e)
+	srv := &http.Server{Addr: *listen, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Println(err)
+		}
+	}()
+
+	log.Printf("serving NS1 metrics on %s%s", *listen, *path)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,9 @@
+// Package exporter periodically scrapes an NS1 account through the rest
+// client and exposes the results as Prometheus metrics over HTTP: account,
+// zone and network QPS, monitoring job health, secondary zone transfer
+// state and the API rate limit.
+//
+// The metrics are rendered in the Prometheus text exposition format, so no
+// Prometheus client library is required. See the ns1-exporter command, in
+// cmd/ns1-exporter at the root of the module, for a standalone server.
+package exporter
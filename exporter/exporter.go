@@ -0,0 +1,298 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gopkg.in/ns1/ns1-go.v2/rest"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+)
+
+const (
+	defaultInterval = time.Minute
+
+	// contentType is the content type of the Prometheus text format.
+	contentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// Exporter scrapes an NS1 account and serves the results as Prometheus
+// metrics. Scrapes run on Run's schedule rather than per request, so that
+// Prometheus polling does not eat into the API rate limit.
+type Exporter struct {
+	client   *rest.Client
+	interval time.Duration
+	zones    []string
+	errFunc  func(error)
+	now      func() time.Time
+
+	mu   sync.Mutex
+	rate *rest.RateLimit
+	page []byte
+
+	// firstScrape serializes the scrapes ServeHTTP starts while there is no
+	// page yet, so concurrent first requests share a single scrape.
+	firstScrape sync.Mutex
+}
+
+// New returns an Exporter scraping through c, or an error if the options set
+// a non-positive interval.
+//
+// New modifies c: it replaces c.RateLimitFunc with a func recording the API
+// rate limit before calling the previous one. Any rate limit strategy must
+// therefore be set on c beforehand, and c should not be shared with code
+// that sets RateLimitFunc afterwards.
+func New(c *rest.Client, options ...func(*Exporter)) (*Exporter, error) {
+	e := &Exporter{
+		client:   c,
+		interval: defaultInterval,
+		errFunc:  func(error) {},
+		now:      time.Now,
+	}
+	for _, option := range options {
+		option(e)
+	}
+	if e.interval <= 0 {
+		return nil, fmt.Errorf("scrape interval must be positive, got %s", e.interval)
+	}
+
+	next := c.RateLimitFunc
+	c.RateLimitFunc = func(rl rest.RateLimit) {
+		e.mu.Lock()
+		e.rate = &rl
+		e.mu.Unlock()
+		if next != nil {
+			next(rl)
+		}
+	}
+
+	return e, nil
+}
+
+// SetInterval sets the time between scrapes started by Run.
+func SetInterval(d time.Duration) func(*Exporter) {
+	return func(e *Exporter) { e.interval = d }
+}
+
+// SetZones limits the zone metrics to the given zones, and adds their QPS.
+// By default every zone of the account is checked for secondary transfer
+// state, and zone QPS is not exported.
+func SetZones(zones ...string) func(*Exporter) {
+	return func(e *Exporter) { e.zones = zones }
+}
+
+// SetErrorFunc sets a function called with the error of each failed scrape
+// started by Run or ServeHTTP.
+func SetErrorFunc(f func(error)) func(*Exporter) {
+	return func(e *Exporter) { e.errFunc = f }
+}
+
+// Run scrapes immediately and then once per interval, until ctx is done.
+func (e *Exporter) Run(ctx context.Context) error {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := e.Scrape(); err != nil {
+			e.errFunc(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scrape collects every metric and replaces the page served by ServeHTTP.
+// A failing collector does not prevent the others from being exported; its
+// failure is reported by the ns1_scrape_success metric and the returned
+// error, which is the first one encountered.
+func (e *Exporter) Scrape() error {
+	start := e.now()
+	m := newMetrics()
+
+	var first error
+	collectors := []struct {
+		name    string
+		collect func(*metrics) error
+	}{
+		{"qps", e.collectQPS},
+		{"monitors", e.collectMonitors},
+		{"zones", e.collectZones},
+	}
+	for _, c := range collectors {
+		err := c.collect(m)
+		if err != nil && first == nil {
+			first = fmt.Errorf("scraping %s: %w", c.name, err)
+		}
+		m.add("ns1_scrape_success", "Whether the last scrape of a collector succeeded.",
+			gauge, boolValue(err == nil), "collector", c.name)
+	}
+
+	e.collectRateLimit(m)
+
+	end := e.now()
+	m.add("ns1_scrape_duration_seconds", "Duration of the last scrape.",
+		gauge, end.Sub(start).Seconds())
+	m.add("ns1_scrape_timestamp_seconds", "Unix time of the last scrape.",
+		gauge, float64(end.Unix()))
+
+	var buf bytes.Buffer
+	if err := m.write(&buf); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.page = buf.Bytes()
+	e.mu.Unlock()
+
+	return first
+}
+
+// ServeHTTP serves the metrics of the last scrape, scraping first if there
+// has not been one yet.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	page := e.lastPage()
+	if page == nil {
+		e.firstScrape.Lock()
+		if page = e.lastPage(); page == nil {
+			if err := e.Scrape(); err != nil {
+				e.errFunc(err)
+			}
+			page = e.lastPage()
+		}
+		e.firstScrape.Unlock()
+	}
+	if page == nil {
+		http.Error(w, "no metrics scraped yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(page) // nolint: errcheck
+}
+
+func (e *Exporter) lastPage() []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.page
+}
+
+func (e *Exporter) collectQPS(m *metrics) error {
+	qps, _, err := e.client.Stats.GetQPSByNetwork()
+	if err != nil {
+		return err
+	}
+
+	m.add("ns1_qps", "Queries per second served for the account.",
+		gauge, float64(qps.QPS))
+	for _, n := range qps.Networks {
+		m.add("ns1_network_qps", "Queries per second served for the account, per network.",
+			gauge, float64(n.QPS), "network", strconv.Itoa(n.Network))
+	}
+
+	for _, zone := range e.zones {
+		zqps, _, err := e.client.Stats.GetZoneQPS(zone)
+		if err != nil {
+			return fmt.Errorf("zone %s: %w", zone, err)
+		}
+		m.add("ns1_zone_qps", "Queries per second served for a zone.",
+			gauge, float64(zqps), "zone", zone)
+	}
+
+	return nil
+}
+
+func (e *Exporter) collectMonitors(m *metrics) error {
+	jobs, _, err := e.client.Jobs.List()
+	if err != nil {
+		return err
+	}
+
+	for _, j := range jobs {
+		m.add("ns1_monitor_active", "Whether a monitoring job is active.",
+			gauge, boolValue(j.Active), "job_id", j.ID, "name", j.Name)
+
+		for region, s := range j.Status {
+			if s == nil {
+				continue
+			}
+			m.add("ns1_monitor_up", "Whether a monitoring job reports up, per region (\"global\" for the job's overall status).",
+				gauge, boolValue(s.Status == "up"), "job_id", j.ID, "name", j.Name, "region", region)
+			m.add("ns1_monitor_status_since_timestamp_seconds", "Unix time of the last status change of a monitoring job, per region.",
+				gauge, float64(s.Since), "job_id", j.ID, "name", j.Name, "region", region)
+		}
+	}
+
+	return nil
+}
+
+func (e *Exporter) collectZones(m *metrics) error {
+	var zones []*dns.Zone
+	if len(e.zones) == 0 {
+		zl, _, err := e.client.Zones.List()
+		if err != nil {
+			return err
+		}
+		zones = zl
+	} else {
+		for _, name := range e.zones {
+			z, _, err := e.client.Zones.Get(name, false)
+			if err != nil {
+				return fmt.Errorf("zone %s: %w", name, err)
+			}
+			zones = append(zones, z)
+		}
+	}
+
+	now := e.now()
+	for _, z := range zones {
+		sec := z.Secondary
+		if sec == nil || !sec.Enabled {
+			continue
+		}
+
+		m.add("ns1_zone_secondary_expired", "Whether a secondary zone has expired.",
+			gauge, boolValue(sec.Expired), "zone", z.Zone)
+		m.add("ns1_zone_secondary_error", "Whether the last transfer of a secondary zone failed.",
+			gauge, boolValue(sec.Error != nil && *sec.Error != ""), "zone", z.Zone)
+		if sec.Status != "" {
+			m.add("ns1_zone_secondary_status", "Transfer status of a secondary zone, always 1.",
+				gauge, 1, "zone", z.Zone, "status", sec.Status)
+		}
+		if sec.LastXfr > 0 {
+			last := time.Unix(int64(sec.LastXfr), 0)
+			m.add("ns1_zone_secondary_last_transfer_timestamp_seconds", "Unix time of the last transfer of a secondary zone.",
+				gauge, float64(sec.LastXfr), "zone", z.Zone)
+			m.add("ns1_zone_secondary_transfer_age_seconds", "Time since the last transfer of a secondary zone.",
+				gauge, now.Sub(last).Seconds(), "zone", z.Zone)
+		}
+	}
+
+	return nil
+}
+
+// collectRateLimit exports the rate limit headers of the most recent API
+// response, whether sent by a scrape or by other users of the client.
+func (e *Exporter) collectRateLimit(m *metrics) {
+	e.mu.Lock()
+	rate := e.rate
+	e.mu.Unlock()
+
+	if rate == nil {
+		return
+	}
+
+	m.add("ns1_api_rate_limit", "Requests allowed per rate limit period.",
+		gauge, float64(rate.Limit))
+	m.add("ns1_api_rate_limit_remaining", "Requests remaining in the current rate limit period.",
+		gauge, float64(rate.Remaining))
+	m.add("ns1_api_rate_limit_period_seconds", "Length of the rate limit period.",
+		gauge, float64(rate.Period))
+}
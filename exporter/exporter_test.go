@@ -0,0 +1,204 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gopkg.in/ns1/ns1-go.v2/rest"
+)
+
+func newTestServer(t *testing.T, routes map[string]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Ratelimit-Limit", "100")
+		w.Header().Set("X-Ratelimit-Remaining", "42")
+		w.Header().Set("X-Ratelimit-Period", "10")
+
+		body, ok := routes[r.URL.RequestURI()]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message": "zone not found"}`)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestExporter(t *testing.T, srv *httptest.Server, options ...func(*Exporter)) *Exporter {
+	c := rest.NewClient(srv.Client(), rest.SetEndpoint(srv.URL+"/v1/"), rest.SetAPIKey("key"))
+	c.FollowPagination = false
+	e, err := New(c, options...)
+	require.NoError(t, err)
+	e.now = func() time.Time { return time.Unix(1700000600, 0) }
+	return e
+}
+
+func TestExporter(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"/v1/stats/qps": `{"qps": 12.5, "networks": [{"network": 0, "qps": 10}, {"network": 1, "qps": 2.5}]}`,
+		"/v1/monitoring/jobs": `[{"id": "j1", "name": "web \"a\"", "active": true,
+			"status": {"global": {"since": 1700000000, "status": "up"}, "lga": {"since": 1700000100, "status": "down"}}}]`,
+		"/v1/zones": `[
+			{"zone": "primary.com"},
+			{"zone": "secondary.com", "secondary": {"enabled": true, "last_xfr": 1700000000, "status": "ok", "error": null}},
+			{"zone": "broken.com", "secondary": {"enabled": true, "expired": true, "error": "refused"}}
+		]`,
+	})
+	e := newTestExporter(t, srv)
+	require.NoError(t, e.Scrape())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, contentType, rec.Header().Get("Content-Type"))
+	page := rec.Body.String()
+
+	for _, line := range []string{
+		"# HELP ns1_qps Queries per second served for the account.",
+		"# TYPE ns1_qps gauge",
+		"ns1_qps 12.5",
+		`ns1_network_qps{network="0"} 10`,
+		`ns1_network_qps{network="1"} 2.5`,
+		`ns1_monitor_active{job_id="j1",name="web \"a\""} 1`,
+		`ns1_monitor_up{job_id="j1",name="web \"a\"",region="global"} 1`,
+		`ns1_monitor_up{job_id="j1",name="web \"a\"",region="lga"} 0`,
+		`ns1_monitor_status_since_timestamp_seconds{job_id="j1",name="web \"a\"",region="lga"} 1.7000001e+09`,
+		`ns1_zone_secondary_expired{zone="secondary.com"} 0`,
+		`ns1_zone_secondary_expired{zone="broken.com"} 1`,
+		`ns1_zone_secondary_error{zone="secondary.com"} 0`,
+		`ns1_zone_secondary_error{zone="broken.com"} 1`,
+		`ns1_zone_secondary_status{status="ok",zone="secondary.com"} 1`,
+		`ns1_zone_secondary_transfer_age_seconds{zone="secondary.com"} 600`,
+		"ns1_api_rate_limit 100",
+		"ns1_api_rate_limit_remaining 42",
+		"ns1_api_rate_limit_period_seconds 10",
+		`ns1_scrape_success{collector="qps"} 1`,
+		`ns1_scrape_success{collector="zones"} 1`,
+	} {
+		assert.Contains(t, page, line+"\n")
+	}
+	assert.NotContains(t, page, "primary.com")
+	assert.NotContains(t, page, "ns1_zone_qps")
+	assert.Equal(t, 1, strings.Count(page, "# TYPE ns1_network_qps gauge"))
+}
+
+func TestExporterZones(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"/v1/stats/qps":                       `{"qps": 3}`,
+		"/v1/stats/qps/example.com":           `{"qps": 1.5}`,
+		"/v1/monitoring/jobs":                 `[]`,
+		"/v1/zones/example.com?records=false": `{"zone": "example.com", "secondary": {"enabled": true, "last_xfr": 1700000500}}`,
+	})
+	e := newTestExporter(t, srv, SetZones("example.com"))
+	require.NoError(t, e.Scrape())
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	page := rec.Body.String()
+
+	assert.Contains(t, page, `ns1_zone_qps{zone="example.com"} 1.5`+"\n")
+	assert.Contains(t, page, `ns1_zone_secondary_transfer_age_seconds{zone="example.com"} 100`+"\n")
+	assert.NotContains(t, page, "ns1_zone_secondary_status")
+}
+
+func TestExporterPartialFailure(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"/v1/stats/qps":       `{"qps": 3}`,
+		"/v1/monitoring/jobs": `[]`,
+	})
+	e := newTestExporter(t, srv, SetZones("missing.com"))
+
+	err := e.Scrape()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "missing.com")
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	page := rec.Body.String()
+
+	assert.Contains(t, page, `ns1_scrape_success{collector="monitors"} 1`+"\n")
+	assert.Contains(t, page, `ns1_scrape_success{collector="zones"} 0`+"\n")
+}
+
+func TestServeHTTPScrapesOnFirstRequest(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"/v1/stats/qps":       `{"qps": 7}`,
+		"/v1/monitoring/jobs": `[]`,
+		"/v1/zones":           `[]`,
+	})
+	e := newTestExporter(t, srv)
+
+	resp := httptest.NewRecorder()
+	e.ServeHTTP(resp, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(resp.Result().Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "ns1_qps 7\n")
+}
+
+func TestServeHTTPReportsScrapeErrors(t *testing.T) {
+	srv := newTestServer(t, map[string]string{
+		"/v1/monitoring/jobs": `[]`,
+		"/v1/zones":           `[]`,
+	})
+	var errs []error
+	e := newTestExporter(t, srv, SetErrorFunc(func(err error) { errs = append(errs, err) }))
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `ns1_scrape_success{collector="qps"} 0`+"\n")
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "scraping qps")
+}
+
+func TestServeHTTPScrapesOnceConcurrently(t *testing.T) {
+	var scrapes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/stats/qps":
+			atomic.AddInt32(&scrapes, 1)
+			time.Sleep(10 * time.Millisecond)
+			fmt.Fprint(w, `{"qps": 7}`)
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	e := newTestExporter(t, srv)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+			assert.Contains(t, rec.Body.String(), "ns1_qps 7\n")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&scrapes))
+}
+
+func TestNewRejectsInterval(t *testing.T) {
+	_, err := New(rest.NewClient(nil), SetInterval(0))
+	assert.EqualError(t, err, "scrape interval must be positive, got 0s")
+
+	_, err = New(rest.NewClient(nil), SetInterval(-time.Second))
+	assert.EqualError(t, err, "scrape interval must be positive, got -1s")
+}
+
+func TestFormatValue(t *testing.T) {
+	assert.Equal(t, "0.25", formatValue(0.25))
+	assert.Equal(t, "1.7e+09", formatValue(1.7e9))
+	assert.Equal(t, `a\\b\n\"c\"`, escapeLabel("a\\b\n\"c\""))
+}
@@ -0,0 +1,120 @@
+package exporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// metricType is the Prometheus type of a metric family.
+type metricType string
+
+const gauge = metricType("gauge")
+
+// sample is a single labelled value of a metric family.
+type sample struct {
+	labels map[string]string
+	value  float64
+}
+
+// family is a Prometheus metric family.
+type family struct {
+	name    string
+	help    string
+	typ     metricType
+	samples []sample
+}
+
+// metrics collects metric families in the order they were first added.
+type metrics struct {
+	families []*family
+	byName   map[string]*family
+}
+
+func newMetrics() *metrics {
+	return &metrics{byName: map[string]*family{}}
+}
+
+// add records a sample, creating its family on first use.
+func (m *metrics) add(name, help string, typ metricType, value float64, labels ...string) {
+	f, ok := m.byName[name]
+	if !ok {
+		f = &family{name: name, help: help, typ: typ}
+		m.byName[name] = f
+		m.families = append(m.families, f)
+	}
+
+	l := make(map[string]string, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		l[labels[i]] = labels[i+1]
+	}
+	f.samples = append(f.samples, sample{labels: l, value: value})
+}
+
+// write renders the families in the Prometheus text exposition format.
+func (m *metrics) write(w io.Writer) error {
+	for _, f := range m.families {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, escapeHelp(f.help), f.name, f.typ); err != nil {
+			return err
+		}
+		for _, s := range f.samples {
+			if _, err := fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(s.labels), formatValue(s.value)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, escapeLabel(labels[name]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,27 @@
+package mockns1
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/pulsar"
+)
+
+// AddPulsarDataTestCase sets up a test case for the api.Client.PulsarData
+// Performance(), Availability() and Decisions() functions. metric is one of
+// "performance", "availability" or "decisions", and query the encoded query
+// string the client is expected to send.
+func (s *Service) AddPulsarDataTestCase(
+	metric, query string,
+	requestHeaders, responseHeaders http.Header,
+	response *pulsar.DataResult,
+) error {
+	uri := fmt.Sprintf("/pulsar/query/%s/time-series", metric)
+	if query != "" {
+		uri = fmt.Sprintf("%s?%s", uri, query)
+	}
+	return s.AddTestCase(
+		http.MethodGet, uri, http.StatusOK, requestHeaders,
+		responseHeaders, "", response,
+	)
+}
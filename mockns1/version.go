@@ -57,3 +57,17 @@ func (s *Service) AddActivateVersionTestCase(
 		responseHeaders, "", nil,
 	)
 }
+
+// AddVersionExportTestCase sets up a test case for the api.Client.Versions.Export()
+// function
+func (s *Service) AddVersionExportTestCase(
+	zoneName string,
+	versionID int64,
+	requestHeaders, responseHeaders http.Header,
+	response *dns.Zone,
+) error {
+	return s.AddTestCase(
+		http.MethodGet, fmt.Sprintf("/zones/%s/versions/%d", zoneName, versionID), http.StatusOK, requestHeaders,
+		responseHeaders, "", response,
+	)
+}
@@ -0,0 +1,35 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/account"
+)
+
+// AccountAuditService audits the users, API keys and teams of the account.
+type AccountAuditService service
+
+// Report walks the users, API keys, teams and global IP whitelists of the
+// account and returns their audit. API keys not used since cutoff are
+// reported as unused.
+func (s *AccountAuditService) Report(cutoff time.Time) (*account.AuditReport, *http.Response, error) {
+	users, resp, err := (*UsersService)(s).List()
+	if err != nil {
+		return nil, resp, err
+	}
+	keys, resp, err := (*APIKeysService)(s).List()
+	if err != nil {
+		return nil, resp, err
+	}
+	teams, resp, err := (*TeamsService)(s).List()
+	if err != nil {
+		return nil, resp, err
+	}
+	whitelists, resp, err := (*GlobalIPWhitelistService)(s).List()
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return account.Audit(users, keys, teams, whitelists, cutoff, time.Now()), resp, nil
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/account"
+)
+
+func TestAccountAudit(t *testing.T) {
+	failPath := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == failPath {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"message": "failed"}`)) // nolint: errcheck
+			return
+		}
+		switch r.URL.Path {
+		case "/account/users":
+			json.NewEncoder(w).Encode([]*account.User{{Username: "bob"}}) // nolint: errcheck
+		case "/account/apikeys":
+			json.NewEncoder(w).Encode([]*account.APIKey{{ID: "k1", TeamIDs: []string{"t1"}}}) // nolint: errcheck
+		case "/account/teams":
+			json.NewEncoder(w).Encode([]*account.Team{{ID: "t1"}}) // nolint: errcheck
+		case "/account/whitelist":
+			json.NewEncoder(w).Encode([]*account.IPWhitelist{{Values: []string{"192.0.2.0/24"}}}) // nolint: errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "not found"}`)) // nolint: errcheck
+		}
+	}))
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+	c.FollowPagination = false
+
+	r, _, err := c.AccountAudit.Report(time.Now().AddDate(0, -3, 0))
+	require.NoError(t, err)
+	assert.Equal(t, 1, r.Users)
+	assert.Equal(t, 1, r.APIKeys)
+	assert.Equal(t, 1, r.Teams)
+	assert.Equal(t, []string{"192.0.2.0/24"}, r.GlobalIPWhitelist)
+	kinds := []account.FindingKind{}
+	for _, f := range r.Findings {
+		kinds = append(kinds, f.Kind)
+	}
+	assert.Equal(t, []account.FindingKind{account.FindingNo2FA, account.FindingNoIPWhitelist, account.FindingUnusedKey}, kinds)
+
+	failPath = "/account/whitelist"
+	_, _, err = c.AccountAudit.Report(time.Now())
+	assert.Error(t, err)
+}
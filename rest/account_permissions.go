@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"net/http"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/account"
+)
+
+// Effective returns the effective permissions of a user: its own and
+// those of its teams.
+func (s *UsersService) Effective(username string) (*account.Effective, *http.Response, error) {
+	u, resp, err := s.Get(username)
+	if err != nil {
+		return nil, resp, err
+	}
+	return effectivePermissions((*TeamsService)(s), u.Permissions, u.TeamIDs, resp)
+}
+
+// Effective returns the effective permissions of an API key: its own and
+// those of its teams.
+func (s *APIKeysService) Effective(keyID string) (*account.Effective, *http.Response, error) {
+	k, resp, err := s.Get(keyID)
+	if err != nil {
+		return nil, resp, err
+	}
+	return effectivePermissions((*TeamsService)(s), k.Permissions, k.TeamIDs, resp)
+}
+
+func effectivePermissions(s *TeamsService, own account.PermissionsMap, teamIDs []string, resp *http.Response) (*account.Effective, *http.Response, error) {
+	var teams []*account.Team
+	if len(teamIDs) > 0 {
+		var err error
+		teams, resp, err = s.List()
+		if err != nil {
+			return nil, resp, err
+		}
+	}
+	e, err := account.NewEffective(own, teamIDs, teams)
+	return e, resp, err
+}
@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/account"
+)
+
+func TestEffectivePermissions(t *testing.T) {
+	teams := []*account.Team{{ID: "t1", Name: "dns", Permissions: account.PermissionsMap{
+		DNS: account.PermissionsDNS{ManageZones: true, ZonesAllowByDefault: true},
+	}}}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/account/apikeys/k1":
+			json.NewEncoder(w).Encode(&account.APIKey{ID: "k1", TeamIDs: []string{"t1"}}) // nolint: errcheck
+		case "/account/users/alice":
+			json.NewEncoder(w).Encode(&account.User{Username: "alice"}) // nolint: errcheck
+		case "/account/teams":
+			json.NewEncoder(w).Encode(teams) // nolint: errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "not found"}`)) // nolint: errcheck
+		}
+	}))
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+	c.FollowPagination = false
+
+	e, _, err := c.APIKeys.Effective("k1")
+	require.NoError(t, err)
+	require.Len(t, e.Sources, 2)
+	assert.True(t, e.CanModifyRecord("example.com", "www.example.com", "A").Allowed)
+
+	e, _, err = c.Users.Effective("alice")
+	require.NoError(t, err)
+	require.Len(t, e.Sources, 1)
+	assert.False(t, e.CanModifyRecord("example.com", "www.example.com", "A").Allowed)
+
+	_, _, err = c.Users.Effective("bob")
+	assert.Error(t, err)
+}
@@ -63,11 +63,13 @@ type Client struct {
 
 	// Services used for communicating with different components of the NS1 API.
 	APIKeys           *APIKeysService
+	AccountAudit      *AccountAuditService
 	DataFeeds         *DataFeedsService
 	DataSources       *DataSourcesService
 	Jobs              *JobsService
 	MonitorRegions    *MonitorRegionsService
 	PulsarJobs        *PulsarJobsService
+	PulsarData        *PulsarDataService
 	Notifications     *NotificationsService
 	Records           *RecordsService
 	Applications      *ApplicationsService
@@ -111,11 +113,13 @@ func NewClient(httpClient Doer, options ...func(*Client)) *Client {
 
 	c.common.client = c
 	c.APIKeys = (*APIKeysService)(&c.common)
+	c.AccountAudit = (*AccountAuditService)(&c.common)
 	c.DataFeeds = (*DataFeedsService)(&c.common)
 	c.DataSources = (*DataSourcesService)(&c.common)
 	c.Jobs = (*JobsService)(&c.common)
 	c.MonitorRegions = (*MonitorRegionsService)(&c.common)
 	c.PulsarJobs = (*PulsarJobsService)(&c.common)
+	c.PulsarData = (*PulsarDataService)(&c.common)
 	c.Notifications = (*NotificationsService)(&c.common)
 	c.Records = (*RecordsService)(&c.common)
 	c.Applications = (*ApplicationsService)(&c.common)
@@ -0,0 +1,276 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/data"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+)
+
+// feedDestinations decodes the read-only destinations the API returns along
+// with a feed. They are kept out of data.Feed so that they are never sent back
+// in Create or Update bodies.
+type feedDestinations struct {
+	data.Feed
+	Destinations []*data.Destination `json:"destinations"`
+}
+
+// Destinations takes a data source ID and a data feed ID and returns the
+// metadata tables the feed is connected to.
+//
+// NS1 API docs: https://ns1.com/api/#feeds-feed-get
+func (s *DataFeedsService) Destinations(sourceID string, feedID string) ([]*data.Destination, *http.Response, error) {
+	path := fmt.Sprintf("data/feeds/%s/%s", sourceID, feedID)
+
+	req, err := s.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var df feedDestinations
+	resp, err := s.client.Do(req, &df)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	for _, d := range df.Destinations {
+		d.SourceID = sourceID
+	}
+	return df.Destinations, resp, nil
+}
+
+// listWithDestinations returns the feeds of a data source along with their
+// destinations.
+func (s *DataFeedsService) listWithDestinations(sourceID string) ([]*feedDestinations, *http.Response, error) {
+	path := fmt.Sprintf("data/feeds/%s", sourceID)
+
+	req, err := s.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dfl := []*feedDestinations{}
+	resp, err := s.client.Do(req, &dfl)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return dfl, resp, nil
+}
+
+// FeedDependency is a metadata field of a record, region or answer whose
+// value comes from a data feed.
+type FeedDependency struct {
+	Zone     string
+	Domain   string
+	Type     string
+	RecordID string
+
+	*dns.MetaFeedPtr
+}
+
+// FeedNode is a data feed in a FeedGraph.
+type FeedNode struct {
+	Source *data.Source
+	Feed   *data.Feed
+
+	// MonitorJobID is the monitoring job driving the feed, for feeds of
+	// NS1 monitoring sources.
+	MonitorJobID string
+
+	// Destinations holds the metadata tables the feed is connected to.
+	Destinations []*data.Destination
+
+	// Dependents holds every metadata field pointing at the feed.
+	Dependents []*FeedDependency
+}
+
+// FeedGraph is the dependency graph from monitoring jobs, through data
+// sources and feeds, to the record metadata they drive.
+type FeedGraph struct {
+	// Sources and Feeds are keyed by ID.
+	Sources map[string]*data.Source
+	Feeds   map[string]*FeedNode
+
+	// Records holds every record connected to a feed, keyed by
+	// "zone/domain/type".
+	Records map[string]*dns.Record
+}
+
+// Graph builds the FeedGraph of the account. Records are looked up in the
+// given zones, or in every zone if none are given; only records that are the
+// destination of at least one feed are fetched.
+func (s *DataFeedsService) Graph(zones ...string) (*FeedGraph, *http.Response, error) {
+	g := &FeedGraph{
+		Sources: map[string]*data.Source{},
+		Feeds:   map[string]*FeedNode{},
+		Records: map[string]*dns.Record{},
+	}
+
+	sources, resp, err := s.client.DataSources.List()
+	if err != nil {
+		return nil, resp, err
+	}
+
+	destRecords := map[string]bool{}
+	for _, src := range sources {
+		g.Sources[src.ID] = src
+
+		var feeds []*feedDestinations
+		feeds, resp, err = s.listWithDestinations(src.ID)
+		if err != nil {
+			return nil, resp, err
+		}
+		src.Feeds = make([]*data.Feed, 0, len(feeds))
+
+		for _, fd := range feeds {
+			f := &fd.Feed
+			f.SourceID = src.ID
+			src.Feeds = append(src.Feeds, f)
+			node := &FeedNode{Source: src, Feed: f, Destinations: fd.Destinations}
+			if src.Type == string(data.SourceTypeNS1Monitoring) {
+				if cfg, err := f.TypedConfig(data.SourceTypeNS1Monitoring); err == nil {
+					node.MonitorJobID = cfg.(*data.NS1MonitoringFeedConfig).JobID
+				}
+			}
+			g.Feeds[f.ID] = node
+
+			for _, d := range fd.Destinations {
+				d.SourceID = src.ID
+				destRecords[d.RecordID] = true
+			}
+		}
+	}
+
+	if len(zones) == 0 {
+		var zl []*dns.Zone
+		zl, resp, err = s.client.Zones.List()
+		if err != nil {
+			return nil, resp, err
+		}
+		for _, z := range zl {
+			zones = append(zones, z.Zone)
+		}
+	}
+
+	for _, zone := range zones {
+		var z *dns.Zone
+		z, resp, err = s.client.Zones.Get(zone, true)
+		if err != nil {
+			return nil, resp, err
+		}
+
+		for _, zr := range z.Records {
+			if !destRecords[zr.ID] {
+				continue
+			}
+
+			var r *dns.Record
+			r, resp, err = s.client.Records.Get(zone, zr.Domain, zr.Type)
+			if err != nil {
+				return nil, resp, err
+			}
+			g.addRecord(r)
+		}
+	}
+
+	for _, node := range g.Feeds {
+		sortFeedDependencies(node.Dependents)
+	}
+
+	return g, resp, nil
+}
+
+// addRecord links every feed pointer of the record to its feed. Pointers at
+// feeds missing from the graph are ignored.
+func (g *FeedGraph) addRecord(r *dns.Record) {
+	g.Records[recordKey(r.Zone, r.Domain, r.Type)] = r
+
+	for _, ptr := range r.FeedPtrs() {
+		node, ok := g.Feeds[ptr.FeedID]
+		if !ok {
+			continue
+		}
+		node.Dependents = append(node.Dependents, &FeedDependency{
+			Zone:        r.Zone,
+			Domain:      r.Domain,
+			Type:        r.Type,
+			RecordID:    r.ID,
+			MetaFeedPtr: ptr,
+		})
+	}
+}
+
+// FeedDependents returns every metadata field pointing at the feed.
+func (g *FeedGraph) FeedDependents(feedID string) []*FeedDependency {
+	node, ok := g.Feeds[feedID]
+	if !ok {
+		return nil
+	}
+	return node.Dependents
+}
+
+// SourceDependents returns every metadata field pointing at a feed of the
+// data source.
+func (g *FeedGraph) SourceDependents(sourceID string) []*FeedDependency {
+	return g.collectDependents(func(n *FeedNode) bool { return n.Source.ID == sourceID })
+}
+
+// MonitorDependents returns every metadata field pointing at a feed driven
+// by the monitoring job.
+func (g *FeedGraph) MonitorDependents(jobID string) []*FeedDependency {
+	return g.collectDependents(func(n *FeedNode) bool { return n.MonitorJobID == jobID })
+}
+
+// RecordFeeds returns the feeds the record's metadata depends on.
+func (g *FeedGraph) RecordFeeds(zone, domain, t string) []*FeedNode {
+	r, ok := g.Records[recordKey(zone, domain, t)]
+	if !ok {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	nodes := []*FeedNode{}
+	for _, ptr := range r.FeedPtrs() {
+		node, ok := g.Feeds[ptr.FeedID]
+		if !ok || seen[ptr.FeedID] {
+			continue
+		}
+		seen[ptr.FeedID] = true
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+func (g *FeedGraph) collectDependents(match func(*FeedNode) bool) []*FeedDependency {
+	deps := []*FeedDependency{}
+	for _, node := range g.Feeds {
+		if match(node) {
+			deps = append(deps, node.Dependents...)
+		}
+	}
+	sortFeedDependencies(deps)
+	return deps
+}
+
+func sortFeedDependencies(deps []*FeedDependency) {
+	sort.SliceStable(deps, func(i, j int) bool {
+		a, b := deps[i], deps[j]
+		if a.Zone != b.Zone {
+			return a.Zone < b.Zone
+		}
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.FeedID < b.FeedID
+	})
+}
+
+func recordKey(zone, domain, t string) string {
+	return zone + "/" + domain + "/" + t
+}
@@ -0,0 +1,124 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/data"
+)
+
+func TestDataFeeds_Graph(t *testing.T) {
+	responses := map[string]string{
+		"/data/sources": `[
+			{"id": "src-mon", "name": "monitoring", "sourcetype": "nsone_monitoring"},
+			{"id": "src-api", "name": "api", "sourcetype": "nsone_v1"}
+		]`,
+		"/data/feeds/src-mon": `[
+			{"id": "feed-mon", "name": "lhr monitor", "config": {"jobid": "job-1"},
+			 "destinations": [{"destid": "a1", "desttype": "answer", "record": "rec-1"}]}
+		]`,
+		"/data/feeds/src-api": `[
+			{"id": "feed-api", "name": "lhr load", "config": {"label": "lhr"},
+			 "destinations": [
+				{"destid": "rec-1", "desttype": "record", "record": "rec-1"},
+				{"destid": "r1", "desttype": "region", "record": "rec-2"}
+			 ]}
+		]`,
+		"/data/feeds/src-mon/feed-mon": `{"id": "feed-mon", "name": "lhr monitor",
+			"destinations": [{"destid": "a1", "desttype": "answer", "record": "rec-1"}]}`,
+		"/zones": `[{"zone": "example.com"}]`,
+		"/zones/example.com": `{"zone": "example.com", "records": [
+			{"id": "rec-1", "domain": "www.example.com", "type": "A"},
+			{"id": "rec-2", "domain": "api.example.com", "type": "A"},
+			{"id": "rec-3", "domain": "static.example.com", "type": "A"}
+		]}`,
+		"/zones/example.com/www.example.com/A": `{
+			"id": "rec-1", "zone": "example.com", "domain": "www.example.com", "type": "A",
+			"meta": {"loadavg": {"feed": "feed-api"}},
+			"answers": [
+				{"id": "a1", "answer": ["1.1.1.1"], "meta": {"up": {"feed": "feed-mon"}}},
+				{"id": "a2", "answer": ["2.2.2.2"], "meta": {"up": true}}
+			]
+		}`,
+		"/zones/example.com/api.example.com/A": `{
+			"id": "rec-2", "zone": "example.com", "domain": "api.example.com", "type": "A",
+			"regions": {"lhr": {"meta": {"connections": {"feed": "feed-api"}}}},
+			"answers": []
+		}`,
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "not found"}`)) // nolint: errcheck
+			return
+		}
+		w.Write([]byte(body)) // nolint: errcheck
+	}))
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+	t.Run("Destinations", func(t *testing.T) {
+		dests, _, err := c.DataFeeds.Destinations("src-mon", "feed-mon")
+		require.NoError(t, err)
+		require.Len(t, dests, 1)
+		assert.Equal(t, "rec-1", dests[0].RecordID)
+		assert.Equal(t, data.DestinationTypeAnswer, dests[0].Type)
+		assert.Equal(t, "src-mon", dests[0].SourceID)
+	})
+
+	g, resp, err := c.DataFeeds.Graph()
+	require.NoError(t, err)
+	assert.Equal(t, "/zones/example.com/api.example.com/A", resp.Request.URL.Path)
+
+	assert.Len(t, g.Sources, 2)
+	assert.Len(t, g.Feeds, 2)
+	assert.Len(t, g.Records, 2)
+	assert.Equal(t, "job-1", g.Feeds["feed-mon"].MonitorJobID)
+	require.Len(t, g.Feeds["feed-api"].Destinations, 2)
+	assert.Equal(t, "src-api", g.Feeds["feed-api"].Destinations[1].SourceID)
+
+	// Destinations are read-only and never sent back on update.
+	b, err := json.Marshal(g.Feeds["feed-api"].Feed)
+	require.NoError(t, err)
+	assert.NotContains(t, string(b), "destinations")
+
+	t.Run("MonitorDependents", func(t *testing.T) {
+		deps := g.MonitorDependents("job-1")
+		require.Len(t, deps, 1)
+		assert.Equal(t, "www.example.com", deps[0].Domain)
+		assert.Equal(t, data.DestinationTypeAnswer, deps[0].Level)
+		assert.Equal(t, "a1", deps[0].AnswerID)
+		assert.Equal(t, "1.1.1.1", deps[0].Answer)
+		assert.Equal(t, "up", deps[0].Field)
+	})
+
+	t.Run("SourceDependents", func(t *testing.T) {
+		deps := g.SourceDependents("src-api")
+		require.Len(t, deps, 2)
+		assert.Equal(t, "api.example.com", deps[0].Domain)
+		assert.Equal(t, data.DestinationTypeRegion, deps[0].Level)
+		assert.Equal(t, "lhr", deps[0].Region)
+		assert.Equal(t, "connections", deps[0].Field)
+		assert.Equal(t, "www.example.com", deps[1].Domain)
+		assert.Equal(t, data.DestinationTypeRecord, deps[1].Level)
+		assert.Equal(t, "loadavg", deps[1].Field)
+
+		assert.Equal(t, deps, g.FeedDependents("feed-api"))
+		assert.Nil(t, g.FeedDependents("bogus"))
+	})
+
+	t.Run("RecordFeeds", func(t *testing.T) {
+		nodes := g.RecordFeeds("example.com", "www.example.com", "A")
+		require.Len(t, nodes, 2)
+		assert.Equal(t, "feed-mon", nodes[0].Feed.ID)
+		assert.Equal(t, "feed-api", nodes[1].Feed.ID)
+
+		assert.Nil(t, g.RecordFeeds("example.com", "static.example.com", "A"))
+	})
+}
@@ -0,0 +1,290 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/data"
+)
+
+const (
+	defaultPublishInterval   = time.Second * 5
+	defaultPublishBatchSize  = 100
+	defaultPublishMaxRetries = 3
+	defaultPublishMinBackoff = time.Millisecond * 250
+	defaultPublishMaxBackoff = time.Second * 10
+)
+
+var (
+	// ErrPublisherClosed is returned when updating a closed Publisher.
+	ErrPublisherClosed = errors.New("publisher is closed")
+)
+
+// Publisher batches metadata updates for the feeds of a single data source
+// and sends them through DataSourcesService.Publish. Repeated updates to the
+// same feed label are coalesced until the next flush, which happens every
+// interval or once the batch size is reached. Failed publishes are retried
+// with exponential backoff; updates still failing after the last retry are
+// dropped and reported.
+//
+// A Publisher must be created with DataSourcesService.NewPublisher and is
+// safe for concurrent use.
+type Publisher struct {
+	service  *DataSourcesService
+	sourceID string
+
+	interval   time.Duration
+	batchSize  int
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	dropFunc   func(label string, meta *data.Meta, err error)
+
+	mu      sync.Mutex
+	pending map[string]*data.Meta
+	closed  bool
+
+	published uint64
+	dropped   uint64
+
+	full    chan struct{}
+	flushes chan chan error
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewPublisher returns a running Publisher for the data source with the given
+// ID. Callers must Close it to flush pending updates and stop its goroutine.
+// An error is returned if the options set a non-positive interval or batch
+// size.
+func (s *DataSourcesService) NewPublisher(sourceID string, options ...func(*Publisher)) (*Publisher, error) {
+	p := &Publisher{
+		service:    s,
+		sourceID:   sourceID,
+		interval:   defaultPublishInterval,
+		batchSize:  defaultPublishBatchSize,
+		maxRetries: defaultPublishMaxRetries,
+		minBackoff: defaultPublishMinBackoff,
+		maxBackoff: defaultPublishMaxBackoff,
+		pending:    map[string]*data.Meta{},
+		full:       make(chan struct{}, 1),
+		flushes:    make(chan chan error),
+		done:       make(chan struct{}),
+	}
+
+	for _, option := range options {
+		option(p)
+	}
+	if p.interval <= 0 {
+		return nil, fmt.Errorf("publish interval must be positive, got %s", p.interval)
+	}
+	if p.batchSize <= 0 {
+		return nil, fmt.Errorf("publish batch size must be positive, got %d", p.batchSize)
+	}
+
+	p.wg.Add(1)
+	go p.run()
+	return p, nil
+}
+
+// SetPublishInterval sets how often a Publisher flushes pending updates.
+func SetPublishInterval(d time.Duration) func(*Publisher) {
+	return func(p *Publisher) { p.interval = d }
+}
+
+// SetPublishBatchSize sets the number of distinct feed labels that triggers
+// an early flush.
+func SetPublishBatchSize(n int) func(*Publisher) {
+	return func(p *Publisher) { p.batchSize = n }
+}
+
+// SetPublishRetry sets how many times a failed batch is retried, and the
+// bounds of the exponential backoff between attempts.
+func SetPublishRetry(maxRetries int, minBackoff, maxBackoff time.Duration) func(*Publisher) {
+	return func(p *Publisher) {
+		p.maxRetries = maxRetries
+		p.minBackoff = minBackoff
+		p.maxBackoff = maxBackoff
+	}
+}
+
+// SetPublishDropFunc sets a func called for every update dropped after
+// exhausting retries, along with the last error seen.
+func SetPublishDropFunc(f func(label string, meta *data.Meta, err error)) func(*Publisher) {
+	return func(p *Publisher) { p.dropFunc = f }
+}
+
+// Update validates meta and queues it for the feed with the given label.
+// If the label already has a pending update, the non-nil fields of meta
+// replace the pending ones.
+func (p *Publisher) Update(label string, meta *data.Meta) error {
+	if meta == nil {
+		return fmt.Errorf("nil meta for feed %q", label)
+	}
+	if errs := meta.Validate(); len(errs) > 0 {
+		return fmt.Errorf("invalid meta for feed %q: %v", label, errs)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrPublisherClosed
+	}
+
+	if pending, ok := p.pending[label]; ok {
+		mergeMeta(pending, meta)
+	} else {
+		m := *meta
+		p.pending[label] = &m
+	}
+
+	if len(p.pending) >= p.batchSize {
+		select {
+		case p.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Flush publishes all pending updates immediately, and returns the error of
+// the last attempt if the batch was dropped.
+func (p *Publisher) Flush() error {
+	errc := make(chan error, 1)
+	select {
+	case p.flushes <- errc:
+		return <-errc
+	case <-p.done:
+		return ErrPublisherClosed
+	}
+}
+
+// Close flushes pending updates and stops the Publisher. Updates made after
+// Close return ErrPublisherClosed. Once closing, failed publishes are no
+// longer retried.
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return ErrPublisherClosed
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	close(p.done)
+	p.wg.Wait()
+	return p.flush()
+}
+
+// Published returns the number of feed updates successfully published.
+func (p *Publisher) Published() uint64 {
+	return atomic.LoadUint64(&p.published)
+}
+
+// Dropped returns the number of feed updates dropped after failing to publish.
+func (p *Publisher) Dropped() uint64 {
+	return atomic.LoadUint64(&p.dropped)
+}
+
+func (p *Publisher) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.flush() // nolint: errcheck
+		case <-p.full:
+			p.flush() // nolint: errcheck
+		case errc := <-p.flushes:
+			errc <- p.flush()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// flush swaps out the pending updates and publishes them as a single batch.
+func (p *Publisher) flush() error {
+	p.mu.Lock()
+	batch := p.pending
+	p.pending = map[string]*data.Meta{}
+	p.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := p.publish(batch)
+	if err != nil {
+		atomic.AddUint64(&p.dropped, uint64(len(batch)))
+		if p.dropFunc != nil {
+			for label, meta := range batch {
+				p.dropFunc(label, meta, err)
+			}
+		}
+		return err
+	}
+
+	atomic.AddUint64(&p.published, uint64(len(batch)))
+	return nil
+}
+
+func (p *Publisher) publish(batch map[string]*data.Meta) error {
+	backoff := p.minBackoff
+
+	var err error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			// Stop retrying once closed, so Close does not wait out the
+			// backoff.
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-p.done:
+				timer.Stop()
+				return err
+			}
+			backoff *= 2
+			if backoff > p.maxBackoff {
+				backoff = p.maxBackoff
+			}
+		}
+
+		var resp *http.Response
+		resp, err = p.service.Publish(p.sourceID, batch)
+		if err == nil || !retryablePublish(resp) {
+			return err
+		}
+	}
+	return err
+}
+
+// retryablePublish reports whether a failed publish is worth retrying.
+// Client errors other than rate limiting will fail the same way again.
+func retryablePublish(resp *http.Response) bool {
+	if resp == nil {
+		return true
+	}
+	c := resp.StatusCode
+	return c == http.StatusTooManyRequests || c < 400 || c > 499
+}
+
+// mergeMeta copies the non-nil fields of src over dst.
+func mergeMeta(dst, src *data.Meta) {
+	dv := reflect.ValueOf(dst).Elem()
+	sv := reflect.ValueOf(src).Elem()
+	for i := 0; i < sv.NumField(); i++ {
+		if !sv.Field(i).IsNil() {
+			dv.Field(i).Set(sv.Field(i))
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/data"
+)
+
+type publishRecorder struct {
+	mu       sync.Mutex
+	batches  []map[string]map[string]interface{}
+	statuses []int
+}
+
+func (r *publishRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"message": "publish failed"}`)) // nolint: errcheck
+			return
+		}
+	}
+
+	var batch map[string]map[string]interface{}
+	if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.batches = append(r.batches, batch)
+	w.Write([]byte(`{}`)) // nolint: errcheck
+}
+
+func (r *publishRecorder) Batches() []map[string]map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.batches
+}
+
+func TestPublisher_Coalesce(t *testing.T) {
+	rec := &publishRecorder{}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+	p, err := c.DataSources.NewPublisher("src-1", SetPublishInterval(time.Hour))
+	require.NoError(t, err)
+
+	require.NoError(t, p.Update("lhr", &data.Meta{Up: true, Connections: 10}))
+	require.NoError(t, p.Update("lhr", &data.Meta{Connections: 20}))
+	require.NoError(t, p.Update("jfk", &data.Meta{Up: false}))
+	require.NoError(t, p.Flush())
+
+	batches := rec.Batches()
+	require.Len(t, batches, 1)
+	assert.Equal(t, map[string]map[string]interface{}{
+		"lhr": {"up": true, "connections": float64(20)},
+		"jfk": {"up": false},
+	}, batches[0])
+	assert.Equal(t, uint64(2), p.Published())
+
+	// Nothing pending, nothing sent.
+	require.NoError(t, p.Flush())
+	assert.Len(t, rec.Batches(), 1)
+
+	require.NoError(t, p.Close())
+	assert.Equal(t, ErrPublisherClosed, p.Update("lhr", &data.Meta{Up: true}))
+}
+
+func TestPublisher_BatchSize(t *testing.T) {
+	rec := &publishRecorder{}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+	p, err := c.DataSources.NewPublisher("src-1", SetPublishInterval(time.Hour), SetPublishBatchSize(2))
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, p.Update("lhr", &data.Meta{Up: true}))
+	require.NoError(t, p.Update("jfk", &data.Meta{Up: true}))
+
+	assert.Eventually(t, func() bool { return len(rec.Batches()) == 1 }, time.Second, time.Millisecond*10)
+}
+
+func TestPublisher_Close(t *testing.T) {
+	rec := &publishRecorder{}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+	p, err := c.DataSources.NewPublisher("src-1", SetPublishInterval(time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, p.Update("lhr", &data.Meta{Up: true}))
+	require.NoError(t, p.Close())
+
+	assert.Len(t, rec.Batches(), 1)
+	assert.Equal(t, ErrPublisherClosed, p.Close())
+	assert.Equal(t, ErrPublisherClosed, p.Flush())
+}
+
+func TestPublisher_Retry(t *testing.T) {
+	rec := &publishRecorder{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+	p, err := c.DataSources.NewPublisher("src-1",
+		SetPublishInterval(time.Hour),
+		SetPublishRetry(2, time.Millisecond, time.Millisecond),
+	)
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, p.Update("lhr", &data.Meta{Up: true}))
+	require.NoError(t, p.Flush())
+	assert.Len(t, rec.Batches(), 1)
+	assert.Equal(t, uint64(0), p.Dropped())
+}
+
+func TestPublisher_Drop(t *testing.T) {
+	rec := &publishRecorder{statuses: []int{http.StatusBadRequest}}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+	var dropped []string
+	p, err := c.DataSources.NewPublisher("src-1",
+		SetPublishInterval(time.Hour),
+		SetPublishRetry(5, time.Millisecond, time.Millisecond),
+		SetPublishDropFunc(func(label string, meta *data.Meta, err error) {
+			dropped = append(dropped, label)
+		}),
+	)
+	require.NoError(t, err)
+	defer p.Close()
+
+	require.NoError(t, p.Update("lhr", &data.Meta{Up: true}))
+	err = p.Flush()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "publish failed")
+
+	// A client error is not retried.
+	assert.Empty(t, rec.statuses)
+	assert.Empty(t, rec.Batches())
+	assert.Equal(t, []string{"lhr"}, dropped)
+	assert.Equal(t, uint64(1), p.Dropped())
+}
+
+func TestPublisher_Validate(t *testing.T) {
+	c := NewClient(nil)
+	p, err := c.DataSources.NewPublisher("src-1", SetPublishInterval(time.Hour))
+	require.NoError(t, err)
+	defer p.Close()
+
+	err = p.Update("lhr", &data.Meta{Connections: -1})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid meta for feed "lhr"`)
+}
+
+func TestPublisher_Options(t *testing.T) {
+	c := NewClient(nil)
+	_, err := c.DataSources.NewPublisher("src-1", SetPublishInterval(0))
+	assert.EqualError(t, err, "publish interval must be positive, got 0s")
+
+	_, err = c.DataSources.NewPublisher("src-1", SetPublishBatchSize(-1))
+	assert.EqualError(t, err, "publish batch size must be positive, got -1")
+}
+
+func TestPublisher_CloseDuringBackoff(t *testing.T) {
+	rec := &publishRecorder{statuses: []int{
+		http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable,
+	}}
+	ts := httptest.NewServer(rec)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+	p, err := c.DataSources.NewPublisher("src-1",
+		SetPublishInterval(time.Hour),
+		SetPublishBatchSize(1),
+		SetPublishRetry(2, time.Hour, time.Hour),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, p.Update("lhr", &data.Meta{Up: true}))
+	start := time.Now()
+	p.Close() // nolint: errcheck
+	assert.True(t, time.Since(start) < time.Minute)
+	assert.Equal(t, uint64(1), p.Dropped())
+}
+
+func TestPublisher_UpdateNil(t *testing.T) {
+	c := NewClient(nil)
+	p, err := c.DataSources.NewPublisher("src-1", SetPublishInterval(time.Hour))
+	require.NoError(t, err)
+	defer p.Close()
+
+	assert.EqualError(t, p.Update("lhr", nil), `nil meta for feed "lhr"`)
+}
@@ -127,3 +127,24 @@ func (s *DatasetsService) GetReport(dtID string, reportID string) (*bytes.Buffer
 
 	return &buf, resp, nil
 }
+
+// GetReportData takes a dataset and a report id and returns the report
+// parsed into rows, according to the export type and datatype of the
+// dataset. Only csv and json exports can be parsed.
+func (s *DatasetsService) GetReportData(dt *dataset.Dataset, reportID string) (*dataset.ReportData, *http.Response, error) {
+	if dt.ExportType != dataset.ExportTypeCSV && dt.ExportType != dataset.ExportTypeJSON {
+		return nil, nil, fmt.Errorf("%w: %q", dataset.ErrUnsupportedExportType, dt.ExportType)
+	}
+
+	buf, resp, err := s.GetReport(dt.ID, reportID)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	data, err := dataset.ParseReport(buf, dt.ExportType, dt.Datatype)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return data, resp, nil
+}
@@ -0,0 +1,294 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dataset"
+)
+
+const (
+	defaultReportMinPoll = time.Second * 2
+	defaultReportMaxPoll = time.Minute
+	defaultReportTimeout = time.Minute * 30
+)
+
+var (
+	// ErrReportFailed is returned when the awaited report has failed to generate.
+	ErrReportFailed = errors.New("dataset report failed")
+
+	// ErrReportTimeout is returned when the awaited report is still not
+	// available once the wait timeout has passed.
+	ErrReportTimeout = errors.New("timed out waiting for dataset report")
+)
+
+// ReportWait configures how DatasetsService waits for reports.
+type ReportWait struct {
+	minPoll     time.Duration
+	maxPoll     time.Duration
+	timeout     time.Duration
+	deleteAfter bool
+	sleep       func(time.Duration)
+}
+
+func newReportWait(options []func(*ReportWait)) *ReportWait {
+	w := &ReportWait{
+		minPoll: defaultReportMinPoll,
+		maxPoll: defaultReportMaxPoll,
+		timeout: defaultReportTimeout,
+		sleep:   time.Sleep,
+	}
+	for _, option := range options {
+		option(w)
+	}
+	return w
+}
+
+// SetReportPollInterval sets the bounds of the exponential backoff between
+// polls of a dataset. Non-positive bounds keep their defaults, and a max
+// below min is raised to min.
+func SetReportPollInterval(min, max time.Duration) func(*ReportWait) {
+	return func(w *ReportWait) {
+		if min <= 0 {
+			min = defaultReportMinPoll
+		}
+		if max <= 0 {
+			max = defaultReportMaxPoll
+		}
+		if max < min {
+			max = min
+		}
+		w.minPoll = min
+		w.maxPoll = max
+	}
+}
+
+// SetReportTimeout sets how long to wait for a report before giving up with
+// ErrReportTimeout.
+func SetReportTimeout(d time.Duration) func(*ReportWait) {
+	return func(w *ReportWait) { w.timeout = d }
+}
+
+// SetReportDeleteAfter sets whether CreateAndFetch deletes the dataset once
+// done with it, whether or not its report could be fetched.
+func SetReportDeleteAfter(deleteAfter bool) func(*ReportWait) {
+	return func(w *ReportWait) { w.deleteAfter = deleteAfter }
+}
+
+// ReportFile is a downloaded dataset report.
+type ReportFile struct {
+	Dataset *dataset.Dataset
+	Report  *dataset.Report
+
+	// Filename is taken from the Content-Disposition header, if any.
+	Filename string
+
+	// Contents is nil for failed reports returned by ReportFetcher.Fetch.
+	Contents *bytes.Buffer
+}
+
+// WaitForReport polls a dataset until the report with the given id is
+// available or failed, backing off between polls. An empty reportID waits
+// for the most recent report, which lets callers wait on a dataset that has
+// just been created and has no report yet. ErrReportFailed is returned along
+// with the report if it failed.
+func (s *DatasetsService) WaitForReport(dtID, reportID string, options ...func(*ReportWait)) (*dataset.Report, *http.Response, error) {
+	w := newReportWait(options)
+	_, r, resp, err := s.waitForReport(dtID, reportID, w)
+	return r, resp, err
+}
+
+func (s *DatasetsService) waitForReport(dtID, reportID string, w *ReportWait) (*dataset.Dataset, *dataset.Report, *http.Response, error) {
+	deadline := time.Now().Add(w.timeout)
+	backoff := w.minPoll
+
+	for {
+		dt, resp, err := s.Get(dtID)
+		if err != nil {
+			return nil, nil, resp, err
+		}
+
+		if r := findReport(dt, reportID); r != nil {
+			switch r.Status {
+			case dataset.ReportStatusAvailable:
+				return dt, r, resp, nil
+			case dataset.ReportStatusFailed:
+				return dt, r, resp, ErrReportFailed
+			}
+		}
+
+		if !time.Now().Add(backoff).Before(deadline) {
+			return dt, nil, resp, ErrReportTimeout
+		}
+		w.sleep(backoff)
+
+		backoff *= 2
+		if backoff > w.maxPoll {
+			backoff = w.maxPoll
+		}
+	}
+}
+
+// CreateAndFetch creates a dataset, waits for its first report and
+// downloads it. With SetReportDeleteAfter(true), the dataset is deleted
+// afterwards; a failure to delete is only returned if everything else
+// succeeded.
+func (s *DatasetsService) CreateAndFetch(dt *dataset.Dataset, options ...func(*ReportWait)) (*ReportFile, *http.Response, error) {
+	w := newReportWait(options)
+
+	dt, resp, err := s.Create(dt)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	file, resp, err := s.fetchFirstReport(dt.ID, w)
+
+	if w.deleteAfter {
+		delResp, delErr := s.Delete(dt.ID)
+		if err == nil && delErr != nil {
+			return file, delResp, delErr
+		}
+	}
+
+	return file, resp, err
+}
+
+func (s *DatasetsService) fetchFirstReport(dtID string, w *ReportWait) (*ReportFile, *http.Response, error) {
+	dt, r, resp, err := s.waitForReport(dtID, "", w)
+	if err != nil {
+		return nil, resp, err
+	}
+	return s.download(dt, r)
+}
+
+func (s *DatasetsService) download(dt *dataset.Dataset, r *dataset.Report) (*ReportFile, *http.Response, error) {
+	buf, resp, err := s.GetReport(dt.ID, r.ID)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return &ReportFile{
+		Dataset:  dt,
+		Report:   r,
+		Filename: reportFilename(resp),
+		Contents: buf,
+	}, resp, nil
+}
+
+// findReport returns the report with the given id, or the most recently
+// created one if id is empty.
+func findReport(dt *dataset.Dataset, id string) *dataset.Report {
+	var found *dataset.Report
+	for _, r := range dt.Reports {
+		if id != "" {
+			if r.ID == id {
+				return r
+			}
+			continue
+		}
+		if found == nil || !time.Time(r.CreatedAt).Before(time.Time(found.CreatedAt)) {
+			found = r
+		}
+	}
+	return found
+}
+
+func reportFilename(resp *http.Response) string {
+	if resp == nil {
+		return ""
+	}
+	_, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition"))
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
+
+// ReportFetcher fetches the reports of a recurring dataset, each exactly
+// once. It is meant to be called from a scheduler (e.g. cron): every call to
+// Fetch downloads the reports that became available since the previous one.
+// The ids of fetched reports can be persisted with Seen and restored with
+// NewReportFetcher between runs.
+//
+// A ReportFetcher must be created with DatasetsService.NewReportFetcher and
+// is safe for concurrent use.
+type ReportFetcher struct {
+	service *DatasetsService
+	dtID    string
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// NewReportFetcher returns a ReportFetcher for the dataset with the given
+// id, which skips the reports with the given ids.
+func (s *DatasetsService) NewReportFetcher(dtID string, seen ...string) *ReportFetcher {
+	f := &ReportFetcher{service: s, dtID: dtID, seen: map[string]bool{}}
+	for _, id := range seen {
+		f.seen[id] = true
+	}
+	return f
+}
+
+// Fetch downloads the available reports that have not been fetched yet,
+// oldest first. Newly failed reports are returned once too, with nil
+// Contents. Reports still queued or generating are left for a later call.
+// If a download fails, the files fetched so far are returned along with the
+// error, and the remaining reports are retried on the next call.
+func (f *ReportFetcher) Fetch() ([]*ReportFile, *http.Response, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	dt, resp, err := f.service.Get(f.dtID)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	reports := append([]*dataset.Report{}, dt.Reports...)
+	sort.SliceStable(reports, func(i, j int) bool {
+		return time.Time(reports[i].CreatedAt).Before(time.Time(reports[j].CreatedAt))
+	})
+
+	files := []*ReportFile{}
+	for _, r := range reports {
+		if f.seen[r.ID] {
+			continue
+		}
+
+		switch r.Status {
+		case dataset.ReportStatusFailed:
+			files = append(files, &ReportFile{Dataset: dt, Report: r})
+		case dataset.ReportStatusAvailable:
+			file, dresp, err := f.service.download(dt, r)
+			if err != nil {
+				return files, dresp, fmt.Errorf("fetching report %s: %w", r.ID, err)
+			}
+			files = append(files, file)
+			resp = dresp
+		default:
+			continue
+		}
+		f.seen[r.ID] = true
+	}
+
+	return files, resp, nil
+}
+
+// Seen returns the ids of the reports fetched so far, sorted.
+func (f *ReportFetcher) Seen() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := make([]string, 0, len(f.seen))
+	for id := range f.seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
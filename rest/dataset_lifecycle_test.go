@@ -0,0 +1,218 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dataset"
+)
+
+// datasetServer serves a single dataset whose reports advance through the
+// given statuses, one step per GET.
+type datasetServer struct {
+	mu       sync.Mutex
+	reports  map[string][]string
+	order    []string
+	gets     int
+	deleted  bool
+	download []string
+}
+
+func (s *datasetServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case req.Method == "PUT" && req.URL.Path == "/datasets":
+		w.Write([]byte(`{"id": "dt-1", "name": "test"}`)) // nolint: errcheck
+	case req.Method == "DELETE" && req.URL.Path == "/datasets/dt-1":
+		s.deleted = true
+		w.Write([]byte(`{}`)) // nolint: errcheck
+	case req.Method == "GET" && strings.HasPrefix(req.URL.Path, "/datasets/dt-1/reports/"):
+		id := strings.TrimPrefix(req.URL.Path, "/datasets/dt-1/reports/")
+		s.download = append(s.download, id)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, id))
+		w.Write([]byte("zone,num_queries\nexample.com," + id + "\n")) // nolint: errcheck
+	case req.Method == "GET" && req.URL.Path == "/datasets/dt-1":
+		reports := []map[string]interface{}{}
+		for i, id := range s.order {
+			statuses := s.reports[id]
+			step := s.gets
+			if step >= len(statuses) {
+				step = len(statuses) - 1
+			}
+			if statuses[step] == "" {
+				continue
+			}
+			reports = append(reports, map[string]interface{}{
+				"id":         id,
+				"status":     statuses[step],
+				"created_at": 1700000000 + i,
+			})
+		}
+		s.gets++
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": "dt-1", "reports": reports}) // nolint: errcheck
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "dataset not found"}`)) // nolint: errcheck
+	}
+}
+
+func noSleep(sleeps *[]time.Duration) func(*ReportWait) {
+	return func(w *ReportWait) {
+		w.sleep = func(d time.Duration) { *sleeps = append(*sleeps, d) }
+	}
+}
+
+func TestDatasetsService_WaitForReport(t *testing.T) {
+	t.Run("available", func(t *testing.T) {
+		srv := &datasetServer{
+			order:   []string{"r1"},
+			reports: map[string][]string{"r1": {"queued", "generating", "generating", "generating", "available"}},
+		}
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+		var sleeps []time.Duration
+		r, _, err := c.Datasets.WaitForReport("dt-1", "r1",
+			SetReportPollInterval(time.Second, 3*time.Second), noSleep(&sleeps))
+		require.NoError(t, err)
+		assert.Equal(t, "r1", r.ID)
+		assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}, sleeps)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		srv := &datasetServer{
+			order:   []string{"r1"},
+			reports: map[string][]string{"r1": {"generating", "failed"}},
+		}
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+		var sleeps []time.Duration
+		r, _, err := c.Datasets.WaitForReport("dt-1", "", noSleep(&sleeps))
+		assert.Equal(t, ErrReportFailed, err)
+		require.NotNil(t, r)
+		assert.Equal(t, "r1", r.ID)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		srv := &datasetServer{
+			order:   []string{"r1"},
+			reports: map[string][]string{"r1": {"queued"}},
+		}
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+		var sleeps []time.Duration
+		_, _, err := c.Datasets.WaitForReport("dt-1", "r1",
+			SetReportPollInterval(time.Second, time.Second), SetReportTimeout(0), noSleep(&sleeps))
+		assert.Equal(t, ErrReportTimeout, err)
+		assert.Empty(t, sleeps)
+	})
+
+	t.Run("non-positive poll interval", func(t *testing.T) {
+		srv := &datasetServer{
+			order:   []string{"r1"},
+			reports: map[string][]string{"r1": {"queued", "queued", "available"}},
+		}
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+		var sleeps []time.Duration
+		_, _, err := c.Datasets.WaitForReport("dt-1", "r1",
+			SetReportPollInterval(0, -time.Second), noSleep(&sleeps))
+		require.NoError(t, err)
+		assert.Equal(t, []time.Duration{defaultReportMinPoll, 2 * defaultReportMinPoll}, sleeps)
+
+		w := newReportWait([]func(*ReportWait){SetReportPollInterval(time.Hour, 0)})
+		assert.Equal(t, time.Hour, w.minPoll)
+		assert.Equal(t, time.Hour, w.maxPoll)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		ts := httptest.NewServer(&datasetServer{})
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+		_, _, err := c.Datasets.WaitForReport("missing", "")
+		assert.Equal(t, ErrDatasetNotFound, err)
+	})
+}
+
+func TestDatasetsService_CreateAndFetch(t *testing.T) {
+	srv := &datasetServer{
+		order:   []string{"r1"},
+		reports: map[string][]string{"r1": {"", "queued", "available"}},
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+	var sleeps []time.Duration
+	file, _, err := c.Datasets.CreateAndFetch(&dataset.Dataset{Name: "test"}, SetReportDeleteAfter(true), noSleep(&sleeps))
+	require.NoError(t, err)
+	assert.Equal(t, "dt-1", file.Dataset.ID)
+	assert.Equal(t, "r1", file.Report.ID)
+	assert.Equal(t, "r1.csv", file.Filename)
+	assert.Equal(t, "zone,num_queries\nexample.com,r1\n", file.Contents.String())
+	assert.Len(t, sleeps, 2)
+	assert.True(t, srv.deleted)
+}
+
+func TestReportFetcher(t *testing.T) {
+	srv := &datasetServer{
+		order: []string{"r1", "r2", "r3", "r4"},
+		reports: map[string][]string{
+			"r1": {"available"},
+			"r2": {"available"},
+			"r3": {"generating", "failed"},
+			"r4": {"", "queued", "available"},
+		},
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+	f := c.Datasets.NewReportFetcher("dt-1", "r1")
+
+	ids := func(files []*ReportFile) []string {
+		out := []string{}
+		for _, file := range files {
+			out = append(out, file.Report.ID)
+		}
+		return out
+	}
+
+	files, _, err := f.Fetch()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r2"}, ids(files))
+
+	files, _, err = f.Fetch()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r3"}, ids(files))
+	assert.Nil(t, files[0].Contents)
+
+	files, _, err = f.Fetch()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"r4"}, ids(files))
+
+	files, _, err = f.Fetch()
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	assert.Equal(t, []string{"r2", "r4"}, srv.download)
+	assert.Equal(t, []string{"r1", "r2", "r3", "r4"}, f.Seen())
+}
@@ -1,6 +1,7 @@
 package rest_test
 
 import (
+	"errors"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/ns1/ns1-go.v2/mockns1"
@@ -221,4 +222,35 @@ func TestDatasetsService(t *testing.T) {
 			require.Equal(t, api.ErrDatasetNotFound, err)
 		})
 	})
+
+	t.Run("Get Report Data", func(t *testing.T) {
+		reportId := "f840be95e13c"
+		dt := &dataset.Dataset{
+			ID:         id,
+			ExportType: dataset.ExportTypeCSV,
+			Datatype:   dataset.NewDatatype(dataset.DatatypeTypeNumQueries, dataset.DatatypeScopeTopNZones, nil),
+		}
+
+		t.Run("Success", func(t *testing.T) {
+			defer mock.ClearTestCases()
+			fileContents := []byte("zone,num_queries\nexample.com,\"1,200\"\nexample.net,300\n")
+			require.Nil(t, mock.AddDatasetGetReportTestCase(id, reportId, nil, nil, fileContents))
+
+			data, _, err := client.Datasets.GetReportData(dt, reportId)
+			require.Nil(t, err)
+			require.Len(t, data.Rows, 2)
+			assert.Equal(t, "example.com", data.Rows[0].Zone)
+			assert.Equal(t, int64(1200), data.Rows[0].Value)
+			assert.Equal(t, 2, data.Rows[1].Rank)
+			assert.Equal(t, int64(1500), data.Total())
+		})
+
+		t.Run("Unsupported", func(t *testing.T) {
+			xlsx := *dt
+			xlsx.ExportType = dataset.ExportTypeXLSX
+
+			_, _, err := client.Datasets.GetReportData(&xlsx, reportId)
+			require.True(t, errors.Is(err, dataset.ErrUnsupportedExportType))
+		})
+	})
 }
@@ -176,6 +176,29 @@ func (s *DNSViewService) UpdatePreferences(m map[string]int) (map[string]int, *h
 	return mapUpdated, resp, nil
 }
 
+// Resolve works out which view answers q and which of its zones is
+// authoritative, using the current views and preferences. The ACLs
+// referenced by the views must be given, as they are not served by this
+// endpoint. See dns.ResolveView.
+func (s *DNSViewService) Resolve(q *dns.ViewQuery, acls []*dns.ACL) (*dns.ViewResolution, *http.Response, error) {
+	vl, resp, err := s.List()
+	if err != nil {
+		return nil, resp, err
+	}
+
+	prefs, resp, err := s.GetPreferences()
+	if err != nil {
+		return nil, resp, err
+	}
+
+	r, err := dns.ResolveView(vl, prefs, acls, q)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return r, resp, nil
+}
+
 var (
 	// ErrViewExists bundles CREATE error.
 	ErrViewExists = errors.New("DNS view already exists")
@@ -2,6 +2,7 @@ package rest_test
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"reflect"
 	"testing"
@@ -233,6 +234,37 @@ func TestDNSView(t *testing.T) {
 		})
 	})
 
+	// Test for api.Client.View.Resolve()
+	t.Run("Resolve", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			defer mock.ClearTestCases()
+
+			views := []*dns.View{
+				{Name: "view1", Zones: []string{"example.com"}},
+				{Name: "view5", ReadACLs: []string{"internal"}, Zones: []string{"example.com"}},
+			}
+			acls := []*dns.ACL{{Name: "internal", IPPrefixes: []string{"10.0.0.0/8"}}}
+			require.Nil(t, mock.AddDNSViewListTestCase(nil, nil, views))
+			require.Nil(t, mock.AddDNSViewGetPreferencesTestCase(nil, nil, myMap))
+
+			r, _, err := client.View.Resolve(&dns.ViewQuery{Name: "www.example.com", ClientIP: net.ParseIP("192.0.2.1")}, acls)
+			require.Nil(t, err)
+			require.Equal(t, "view1", r.View.Name)
+			require.Equal(t, "example.com", r.Zone)
+		})
+
+		t.Run("Error", func(t *testing.T) {
+			defer mock.ClearTestCases()
+			require.Nil(t, mock.AddTestCase(
+				http.MethodGet, "views", http.StatusBadGateway,
+				nil, nil, "", `{"message": "test error"}`,
+			))
+			r, _, err := client.View.Resolve(&dns.ViewQuery{Name: "www.example.com"}, nil)
+			require.Nil(t, r)
+			require.NotNil(t, err)
+		})
+	})
+
 	// Test for api.Client.View.UpdatePreferences()
 	t.Run("UpdatePreferences", func(t *testing.T) {
 		t.Run("Success", func(t *testing.T) {
@@ -46,3 +46,32 @@ var (
 	// account-level DNSSEC permission.
 	ErrDNSECNotEnabled = errors.New("DNSSEC is not enabled on the zone")
 )
+
+// CheckDelegation checks the DS records published at the parent of a zone,
+// given in presentation format (see dns.ParseDS), against the zone's DNSSEC
+// keys and settings.
+func (s *DNSSECService) CheckDelegation(zone string, parentDS string) (*dns.DelegationReport, *http.Response, error) {
+	parent, err := dns.ParseDS(parentDS)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	z, resp, err := (*ZonesService)(s).Get(zone, false)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	var d *dns.ZoneDNSSEC
+	if z.DNSSEC != nil && *z.DNSSEC {
+		d, resp, err = s.Get(zone)
+		if err != nil {
+			return nil, resp, err
+		}
+	}
+
+	report, err := dns.CheckDelegation(z, d, parent)
+	if err != nil {
+		return nil, resp, err
+	}
+	return report, resp, nil
+}
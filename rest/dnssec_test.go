@@ -0,0 +1,58 @@
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/mockns1"
+	api "gopkg.in/ns1/ns1-go.v2/rest"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+)
+
+func TestDNSSECService_CheckDelegation(t *testing.T) {
+	mock, doer, err := mockns1.New(t)
+	require.Nil(t, err)
+	defer mock.Shutdown()
+	client := api.NewClient(doer, api.SetEndpoint("https://"+mock.Address+"/v1/"))
+
+	enabled := true
+	zone := &dns.Zone{Zone: "dskey.example.com", DNSSEC: &enabled}
+	dnssec := `{
+		"zone": "dskey.example.com",
+		"keys": {"dnskey": [["257", "3", "5", "AQOeiiR0GOMYkDshWoSKz9XzfwJr1AYtsmx3TGkJaNXVbfi/2pHm822aJ5iI9BMzNXxeYCmZDRD99WYwYqUSdjMmmAphXdvxegXd/M5+X7OrzKBaMbCVdFLUUh6DhweJBjEVv5f2wwjM9XzcnOf+EPbtG9DMBmADjFDc2w/rljwvFw=="]], "ttl": 3600}
+	}`
+
+	t.Run("missing ds", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddZoneGetTestCase("dskey.example.com", nil, nil, zone, false))
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/zones/dskey.example.com/dnssec", http.StatusOK, nil, nil, "", dnssec,
+		))
+
+		report, _, err := client.DNSSEC.CheckDelegation("dskey.example.com", "")
+		require.Nil(t, err)
+		assert.False(t, report.OK())
+		require.Len(t, report.Issues, 1)
+		assert.Equal(t, dns.IssueNoDS, report.Issues[0].Type)
+	})
+
+	t.Run("invalid ds", func(t *testing.T) {
+		_, _, err := client.DNSSEC.CheckDelegation("dskey.example.com", "not a DS record")
+		require.NotNil(t, err)
+	})
+
+	t.Run("zone not found", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/zones/dskey.example.com?records=false", http.StatusNotFound,
+			nil, nil, "", `{"message": "zone not found"}`,
+		))
+
+		_, _, err := client.DNSSEC.CheckDelegation("dskey.example.com", "")
+		require.Equal(t, api.ErrZoneMissing, err)
+	})
+}
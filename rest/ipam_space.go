@@ -0,0 +1,146 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/ipam"
+)
+
+// maxAllocateAttempts is how many times an allocation is retried when it
+// conflicts with one made concurrently.
+const maxAllocateAttempts = 3
+
+// ErrIPAMConflict is returned when an allocation keeps conflicting with
+// addresses created concurrently.
+var ErrIPAMConflict = errors.New("allocation conflicts with an existing address")
+
+// GetSpace loads the whole address space: the root addresses of all
+// networks and, concurrently like GetTree, their descendants.
+func (s *IPAMService) GetSpace(options ...func(*TreeLoad)) (*ipam.Space, *http.Response, error) {
+	addrs, resp, err := s.loadAddrs(options)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	space, err := ipam.NewSpace(addrs)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return space, resp, nil
+}
+
+// AllocateSubnet creates the lowest free subnet of the given prefix length
+// under the subnet parentID, e.g. the next free /26 under a /16. The other
+// fields of the new subnet are taken from addr, which may be nil.
+//
+// The free space of the parent is fetched right before creating the
+// subnet. If the creation conflicts with a subnet created meanwhile, it is
+// retried with fresh data, and ErrIPAMConflict is returned if it keeps
+// conflicting.
+func (s *IPAMService) AllocateSubnet(parentID, bits int, addr *ipam.Address) (*ipam.Address, *http.Response, error) {
+	return s.allocate(parentID, addr, func(b *ipam.Block) (netip.Prefix, error) {
+		return b.NextFree(bits)
+	})
+}
+
+// AllocateHost creates the lowest free host address under the subnet
+// parentID, skipping the network and broadcast addresses of IPv4 subnets.
+// See AllocateSubnet.
+func (s *IPAMService) AllocateHost(parentID int, addr *ipam.Address) (*ipam.Address, *http.Response, error) {
+	return s.allocate(parentID, addr, func(b *ipam.Block) (netip.Prefix, error) {
+		a, err := b.NextFreeHost()
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		return netip.PrefixFrom(a, a.BitLen()), nil
+	})
+}
+
+func (s *IPAMService) allocate(parentID int, addr *ipam.Address, next func(*ipam.Block) (netip.Prefix, error)) (*ipam.Address, *http.Response, error) {
+	var resp *http.Response
+	for attempt := 0; attempt < maxAllocateAttempts; attempt++ {
+		block, r, err := s.getBlock(parentID)
+		if err != nil {
+			return nil, r, err
+		}
+
+		p, err := next(block)
+		if err != nil {
+			return nil, r, fmt.Errorf("subnet %s: %w", block.Prefix, err)
+		}
+
+		newAddr := &ipam.Address{}
+		if addr != nil {
+			*newAddr = *addr
+		}
+		newAddr.ID = 0
+		newAddr.Prefix = p.String()
+		newAddr.Network = block.Address.Network
+		newAddr.Parent = 0
+
+		created, r, err := s.CreateSubnet(newAddr)
+		resp = r
+		if err != nil {
+			if isIPAMConflict(err) {
+				continue
+			}
+			return nil, resp, err
+		}
+		if created.Parent != 0 && created.Parent != parentID {
+			return created, resp, fmt.Errorf("%w: %s was created under address %d instead of %d",
+				ErrIPAMConflict, created.Prefix, created.Parent, parentID)
+		}
+
+		return created, resp, nil
+	}
+
+	return nil, resp, ErrIPAMConflict
+}
+
+// getBlock fetches a subnet and its children.
+func (s *IPAMService) getBlock(id int) (*ipam.Block, *http.Response, error) {
+	parent, resp, err := s.GetSubnet(id)
+	if err != nil {
+		return nil, resp, err
+	}
+	p, err := parent.ParsePrefix()
+	if err != nil {
+		return nil, resp, err
+	}
+
+	children, resp, err := s.GetChildren(id)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	block := &ipam.Block{Address: parent, Prefix: p}
+	for _, c := range children {
+		cp, err := c.ParsePrefix()
+		if err != nil {
+			return nil, resp, fmt.Errorf("address %d: %w", c.ID, err)
+		}
+		block.Children = append(block.Children, &ipam.Block{Address: c, Prefix: cp})
+	}
+
+	return block, resp, nil
+}
+
+// isIPAMConflict reports whether err is the API refusing an address that
+// overlaps an existing one.
+func isIPAMConflict(err error) bool {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.Resp.StatusCode == http.StatusConflict {
+		return true
+	}
+	msg := strings.ToLower(apiErr.Message)
+	return apiErr.Resp.StatusCode == http.StatusBadRequest &&
+		(strings.Contains(msg, "overlap") || strings.Contains(msg, "exist"))
+}
@@ -0,0 +1,201 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/ipam"
+)
+
+// ipamServer simulates a flat IPAM address space.
+type ipamServer struct {
+	mu     sync.Mutex
+	addrs  []*ipam.Address
+	nextID int
+
+	// steal lists prefixes created by someone else right before the next
+	// create requests, to simulate concurrent allocations.
+	steal []string
+
+	// failChildren makes children requests of that address fail.
+	failChildren int
+	// failCreate makes the creation of that prefix fail.
+	failCreate string
+}
+
+func (s *ipamServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var id int
+	switch {
+	case req.Method == "GET" && req.URL.Path == "/ipam/address":
+		roots := []*ipam.Address{}
+		for _, a := range s.addrs {
+			if a.Parent == 0 {
+				roots = append(roots, a)
+			}
+		}
+		json.NewEncoder(w).Encode(roots) // nolint: errcheck
+	case req.Method == "GET" && scan(req.URL.Path, "/ipam/address/%d/children", &id):
+		if id == s.failChildren {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"message": "internal error"}`)) // nolint: errcheck
+			return
+		}
+		children := []*ipam.Address{}
+		for _, a := range s.addrs {
+			if a.Parent == id {
+				children = append(children, a)
+			}
+		}
+		json.NewEncoder(w).Encode(children) // nolint: errcheck
+	case req.Method == "GET" && scan(req.URL.Path, "/ipam/address/%d", &id):
+		for _, a := range s.addrs {
+			if a.ID == id {
+				json.NewEncoder(w).Encode(a) // nolint: errcheck
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "address not found"}`)) // nolint: errcheck
+	case req.Method == "PUT" && req.URL.Path == "/ipam/address":
+		var a ipam.Address
+		json.NewDecoder(req.Body).Decode(&a) // nolint: errcheck
+		if len(s.steal) > 0 {
+			s.add(s.steal[0])
+			s.steal = s.steal[1:]
+		}
+		if a.Prefix == s.failCreate {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"message": "internal error"}`)) // nolint: errcheck
+			return
+		}
+		for _, b := range s.addrs {
+			if b.Prefix == a.Prefix {
+				w.WriteHeader(http.StatusConflict)
+				w.Write([]byte(`{"message": "address already exists"}`)) // nolint: errcheck
+				return
+			}
+		}
+		json.NewEncoder(w).Encode(s.add(a.Prefix)) // nolint: errcheck
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "not found"}`)) // nolint: errcheck
+	}
+}
+
+// add creates an address under the smallest one containing it.
+func (s *ipamServer) add(prefix string) *ipam.Address {
+	s.nextID++
+	a := &ipam.Address{ID: s.nextID, Prefix: prefix, Network: 1}
+	p, _ := a.ParsePrefix()
+	bits := -1
+	for _, b := range s.addrs {
+		bp, _ := b.ParsePrefix()
+		if bp.Bits() > bits && bp.Bits() < p.Bits() && bp.Contains(p.Addr()) {
+			a.Parent, bits = b.ID, bp.Bits()
+		}
+	}
+	for _, b := range s.addrs {
+		if b.ID == a.Parent {
+			b.Children++
+		}
+	}
+	s.addrs = append(s.addrs, a)
+	return a
+}
+
+func newIPAMServer() *ipamServer {
+	s := &ipamServer{}
+	s.add("10.20.0.0/16")
+	s.add("10.20.0.0/26")
+	s.add("10.20.1.0/24")
+	s.add("10.20.1.1/32")
+	return s
+}
+
+func TestIPAMService_GetSpace(t *testing.T) {
+	ts := httptest.NewServer(newIPAMServer())
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+	c.FollowPagination = false
+
+	space, _, err := c.IPAM.GetSpace()
+	require.NoError(t, err)
+	require.Len(t, space.Roots(1), 1)
+	assert.Len(t, space.Roots(1)[0].Children, 2)
+	require.NotNil(t, space.Find(1, "10.20.1.1"))
+}
+
+func TestIPAMService_Allocate(t *testing.T) {
+	t.Run("subnet", func(t *testing.T) {
+		srv := newIPAMServer()
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		a, _, err := c.IPAM.AllocateSubnet(1, 26, &ipam.Address{Name: "app"})
+		require.NoError(t, err)
+		assert.Equal(t, "10.20.0.64/26", a.Prefix)
+		assert.Equal(t, 1, a.Parent)
+	})
+
+	t.Run("host", func(t *testing.T) {
+		ts := httptest.NewServer(newIPAMServer())
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		a, _, err := c.IPAM.AllocateHost(3, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "10.20.1.2/32", a.Prefix)
+	})
+
+	t.Run("retries conflicts", func(t *testing.T) {
+		srv := newIPAMServer()
+		srv.steal = []string{"10.20.0.64/26"}
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		a, _, err := c.IPAM.AllocateSubnet(1, 26, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "10.20.0.128/26", a.Prefix)
+	})
+
+	t.Run("keeps conflicting", func(t *testing.T) {
+		srv := newIPAMServer()
+		srv.steal = []string{"10.20.0.64/26", "10.20.0.128/26", "10.20.0.192/26"}
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		_, _, err := c.IPAM.AllocateSubnet(1, 26, nil)
+		assert.Equal(t, ErrIPAMConflict, err)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		ts := httptest.NewServer(newIPAMServer())
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		for _, want := range []string{"10.20.0.0/27", "10.20.0.32/27"} {
+			a, _, err := c.IPAM.AllocateSubnet(2, 27, nil)
+			require.NoError(t, err)
+			assert.Equal(t, want, a.Prefix)
+		}
+		_, _, err := c.IPAM.AllocateSubnet(2, 27, nil)
+		assert.True(t, errors.Is(err, ipam.ErrNoFreeSpace))
+	})
+}
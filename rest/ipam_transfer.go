@@ -0,0 +1,123 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/ipam"
+)
+
+// ErrIPAMImportInvalid is returned when addresses to import fail
+// validation; the problems are listed in IPAMImportResult.Invalid.
+var ErrIPAMImportInvalid = errors.New("addresses to import are invalid")
+
+// IPAMImport configures IPAMService.Import.
+type IPAMImport struct {
+	dryRun  bool
+	network int
+}
+
+// SetIPAMImportDryRun makes the import validate the addresses and work out
+// which would be created, without creating any.
+func SetIPAMImportDryRun(dryRun bool) func(*IPAMImport) {
+	return func(i *IPAMImport) { i.dryRun = dryRun }
+}
+
+// SetIPAMImportNetwork sets the network of the addresses that have none.
+func SetIPAMImportNetwork(network int) func(*IPAMImport) {
+	return func(i *IPAMImport) { i.network = network }
+}
+
+// IPAMImportResult describes an import.
+type IPAMImportResult struct {
+	DryRun bool
+
+	// Created holds the addresses created, or to be created on a dry run,
+	// in creation order.
+	Created []*ipam.Address
+	// Skipped holds the addresses that already exist.
+	Skipped []*ipam.Address
+	// Failed is the address whose creation failed, if any.
+	Failed *ipam.Address
+	// Invalid lists the validation problems of the addresses.
+	Invalid []error
+}
+
+// Import creates addresses, e.g. as read by ipam.ReadAddresses, parents
+// before children. The addresses are validated first, and nothing is
+// created if any is invalid.
+//
+// Addresses that already exist are skipped, so an import that failed
+// half-way through resumes where it stopped when run again. On failure,
+// the result tells what was created before the failing address.
+func (s *IPAMService) Import(addrs []*ipam.Address, options ...func(*IPAMImport)) (*IPAMImportResult, *http.Response, error) {
+	imp := &IPAMImport{}
+	for _, option := range options {
+		option(imp)
+	}
+
+	sorted := make([]*ipam.Address, len(addrs))
+	for i, a := range addrs {
+		addr := *a
+		if addr.Network == 0 {
+			addr.Network = imp.network
+		}
+		sorted[i] = &addr
+	}
+	ipam.SortParentsFirst(sorted)
+
+	result := &IPAMImportResult{
+		DryRun:  imp.dryRun,
+		Created: []*ipam.Address{},
+		Skipped: []*ipam.Address{},
+		Invalid: ipam.ValidateImport(sorted),
+	}
+	if len(result.Invalid) > 0 {
+		return result, nil, ErrIPAMImportInvalid
+	}
+
+	space, resp, err := s.GetSpace()
+	if err != nil {
+		return result, resp, err
+	}
+
+	for _, a := range sorted {
+		if space.Exists(a) {
+			result.Skipped = append(result.Skipped, a)
+			continue
+		}
+		if imp.dryRun {
+			result.Created = append(result.Created, a)
+			continue
+		}
+
+		created, r, err := s.CreateSubnet(a)
+		resp = r
+		if err != nil {
+			result.Failed = a
+			return result, resp, fmt.Errorf("importing %s in network %d: %w", a.Prefix, a.Network, err)
+		}
+		result.Created = append(result.Created, created)
+	}
+
+	return result, resp, nil
+}
+
+// Export writes all addresses in the given format, parents before
+// children, for ipam.ReadAddresses and Import to read back.
+func (s *IPAMService) Export(w io.Writer, format string) (*http.Response, error) {
+	tree, resp, err := s.GetTree()
+	if err != nil {
+		return resp, err
+	}
+
+	addrs := make([]*ipam.Address, 0, tree.Len())
+	tree.DepthFirst(func(n *ipam.Node) bool {
+		addrs = append(addrs, n.Address)
+		return true
+	})
+
+	return resp, ipam.WriteAddresses(w, format, addrs)
+}
@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/ipam"
+)
+
+func TestIPAMService_Import(t *testing.T) {
+	input := `prefix,name,tags
+10.30.1.0/24,web,env=prod
+10.30.0.0/16,site,
+10.20.0.64/26,app,
+10.20.0.0/16,existing,
+`
+	prefixes := func(addrs []*ipam.Address) []string {
+		out := []string{}
+		for _, a := range addrs {
+			out = append(out, a.Prefix)
+		}
+		return out
+	}
+
+	addrs, err := ipam.ReadAddresses(strings.NewReader(input), ipam.FormatCSV)
+	require.NoError(t, err)
+
+	srv := newIPAMServer()
+	srv.failCreate = "10.30.0.0/16"
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+	c.FollowPagination = false
+
+	result, _, err := c.IPAM.Import(addrs, SetIPAMImportNetwork(1), SetIPAMImportDryRun(true))
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, []string{"10.30.0.0/16", "10.30.1.0/24", "10.20.0.64/26"}, prefixes(result.Created))
+	assert.Equal(t, []string{"10.20.0.0/16"}, prefixes(result.Skipped))
+	assert.Len(t, srv.addrs, 4)
+
+	result, _, err = c.IPAM.Import(addrs, SetIPAMImportNetwork(1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "10.30.0.0/16")
+	assert.Equal(t, "10.30.0.0/16", result.Failed.Prefix)
+	assert.Empty(t, result.Created)
+	assert.Len(t, srv.addrs, 4)
+
+	// Once the failure is fixed, running the import again resumes it.
+	srv.failCreate = ""
+	result, _, err = c.IPAM.Import(addrs, SetIPAMImportNetwork(1))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.30.0.0/16", "10.30.1.0/24", "10.20.0.64/26"}, prefixes(result.Created))
+	assert.Nil(t, result.Failed)
+	assert.Equal(t, result.Created[0].ID, result.Created[1].Parent)
+
+	result, _, err = c.IPAM.Import(addrs, SetIPAMImportNetwork(1))
+	require.NoError(t, err)
+	assert.Empty(t, result.Created)
+	assert.Len(t, result.Skipped, 4)
+
+	t.Run("invalid", func(t *testing.T) {
+		result, _, err := c.IPAM.Import([]*ipam.Address{{Prefix: "10.0.0.1/8"}, {Prefix: "bogus", Network: 1}})
+		assert.Equal(t, ErrIPAMImportInvalid, err)
+		assert.Len(t, result.Invalid, 3)
+		assert.Empty(t, result.Created)
+	})
+}
+
+func TestIPAMService_Export(t *testing.T) {
+	srv := newIPAMServer()
+	srv.addrs[1].Name = "app"
+	srv.addrs[1].Tags = map[string]interface{}{"env": "prod"}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+	c.FollowPagination = false
+
+	var buf bytes.Buffer
+	_, err := c.IPAM.Export(&buf, ipam.FormatCSV)
+	require.NoError(t, err)
+	assert.Equal(t, `network,prefix,name,desc,status,tags,kvps
+1,10.20.0.0/16,,,,,
+1,10.20.0.0/26,app,,,env=prod,
+1,10.20.1.0/24,,,,,
+1,10.20.1.1/32,,,,,
+`, buf.String())
+
+	_, err = c.IPAM.Export(&buf, "xml")
+	assert.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"net/http"
+	"sync"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/ipam"
+)
+
+// defaultTreeConcurrency is how many children requests GetTree and GetSpace
+// make at once by default.
+const defaultTreeConcurrency = 4
+
+// TreeLoad configures how IPAMService.GetTree and GetSpace load the
+// addresses.
+type TreeLoad struct {
+	concurrency int
+}
+
+// SetTreeConcurrency sets how many children requests are made at once.
+func SetTreeConcurrency(n int) func(*TreeLoad) {
+	return func(l *TreeLoad) {
+		if n > 0 {
+			l.concurrency = n
+		}
+	}
+}
+
+// GetTree loads the parent/child graph of all addresses: the root
+// addresses of all networks and, concurrently, the children of every
+// address having any. The first failing request stops the load and its
+// error is returned.
+func (s *IPAMService) GetTree(options ...func(*TreeLoad)) (*ipam.Tree, *http.Response, error) {
+	addrs, resp, err := s.loadAddrs(options)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return ipam.NewTree(addrs), resp, nil
+}
+
+// loadAddrs lists the root addresses of all networks and concurrently loads
+// the children of every address having any.
+func (s *IPAMService) loadAddrs(options []func(*TreeLoad)) ([]*ipam.Address, *http.Response, error) {
+	l := &TreeLoad{concurrency: defaultTreeConcurrency}
+	for _, option := range options {
+		option(l)
+	}
+
+	roots, resp, err := s.ListAddrs()
+	if err != nil {
+		return nil, resp, err
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+		errResp  *http.Response
+	)
+	addrs := make([]*ipam.Address, 0, len(roots))
+	sem := make(chan struct{}, l.concurrency)
+
+	var load func(id int)
+	load = func(id int) {
+		defer wg.Done()
+
+		mu.Lock()
+		failed := firstErr != nil
+		mu.Unlock()
+		if failed {
+			return
+		}
+
+		sem <- struct{}{}
+		children, resp, err := s.GetChildren(id)
+		<-sem
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			if firstErr == nil {
+				firstErr, errResp = err, resp
+			}
+			return
+		}
+		for _, c := range children {
+			if c.Parent == 0 {
+				c.Parent = id
+			}
+			addrs = append(addrs, c)
+			if c.Children > 0 {
+				wg.Add(1)
+				go load(c.ID)
+			}
+		}
+	}
+
+	for i := range roots {
+		addrs = append(addrs, &roots[i])
+	}
+	for _, a := range roots {
+		if a.Children > 0 {
+			wg.Add(1)
+			go load(a.ID)
+		}
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, errResp, firstErr
+	}
+
+	return addrs, resp, nil
+}
@@ -0,0 +1,48 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIPAMService_GetTree(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		srv := newIPAMServer()
+		srv.add("10.20.1.0/28")
+		srv.add("172.16.0.0/12")
+		srv.addrs[0].Tags = map[string]interface{}{"env": "prod"}
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		tree, _, err := c.IPAM.GetTree(SetTreeConcurrency(2))
+		require.NoError(t, err)
+		assert.Equal(t, len(srv.addrs), tree.Len())
+		require.Len(t, tree.Roots, 2)
+		assert.Empty(t, tree.Orphans)
+
+		host := tree.Node(4)
+		require.NotNil(t, host)
+		assert.Equal(t, 2, host.Depth)
+		assert.True(t, host.HasTag("env", "prod"))
+		assert.Len(t, tree.Tagged("env", "prod"), 5)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		srv := newIPAMServer()
+		srv.failChildren = 3
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		tree, _, err := c.IPAM.GetTree()
+		assert.Nil(t, tree)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "internal error")
+	})
+}
@@ -0,0 +1,223 @@
+package account
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dataset"
+)
+
+// FindingKind is a kind of audit finding.
+type FindingKind string
+
+// List of the findings of Audit.
+const (
+	// FindingNo2FA is a user without two-factor authentication.
+	FindingNo2FA FindingKind = "no-2fa"
+	// FindingUnusedKey is an API key not used since the cutoff, or never.
+	FindingUnusedKey FindingKind = "unused-key"
+	// FindingNoIPWhitelist is an API key usable from any address.
+	FindingNoIPWhitelist FindingKind = "no-ip-whitelist"
+	// FindingLaxIPWhitelist is an API key with a non-strict IP whitelist.
+	FindingLaxIPWhitelist FindingKind = "non-strict-ip-whitelist"
+	// FindingManageAPIKeys is a user, API key or team that may manage API
+	// keys.
+	FindingManageAPIKeys FindingKind = "manage-apikeys"
+	// FindingManageUsers is a user, API key or team that may manage users.
+	FindingManageUsers FindingKind = "manage-users"
+	// FindingEmptyTeam is a team without users nor API keys.
+	FindingEmptyTeam FindingKind = "empty-team"
+)
+
+// Kinds of audited subjects.
+const (
+	SubjectUser   = "user"
+	SubjectAPIKey = "apikey"
+	SubjectTeam   = "team"
+)
+
+// Finding is a problem found by Audit.
+type Finding struct {
+	Kind FindingKind `json:"kind"`
+	// Subject is SubjectUser, SubjectAPIKey or SubjectTeam.
+	Subject string `json:"subject"`
+	// ID is the username of users, and the ID of API keys and teams.
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// AuditReport lists the findings of an account audit.
+type AuditReport struct {
+	Generated time.Time `json:"generated"`
+	Cutoff    time.Time `json:"cutoff"`
+	Users     int       `json:"users"`
+	APIKeys   int       `json:"apikeys"`
+	Teams     int       `json:"teams"`
+	// GlobalIPWhitelist lists the addresses allowed for the whole account.
+	GlobalIPWhitelist []string   `json:"global_ip_whitelist"`
+	Findings          []*Finding `json:"findings"`
+}
+
+// Audit reviews the users, API keys and teams of an account, finding:
+//   - users without two-factor authentication,
+//   - API keys not used since cutoff, or never used,
+//   - API keys with a non-strict IP whitelist, or with none of their own
+//     nor from their teams,
+//   - users, API keys and teams that may manage API keys or users, on their
+//     own or through their teams,
+//   - teams without users nor API keys.
+//
+// The global IP whitelists, which restrict all users and keys, are listed
+// in the report and noted in the findings about key whitelists.
+func Audit(users []*User, keys []*APIKey, teams []*Team, whitelists []*IPWhitelist, cutoff, now time.Time) *AuditReport {
+	r := &AuditReport{
+		Generated:         now,
+		Cutoff:            cutoff,
+		Users:             len(users),
+		APIKeys:           len(keys),
+		Teams:             len(teams),
+		GlobalIPWhitelist: []string{},
+		Findings:          []*Finding{},
+	}
+	for _, wl := range whitelists {
+		r.GlobalIPWhitelist = append(r.GlobalIPWhitelist, wl.Values...)
+	}
+	whitelistNote := "no global IP whitelist"
+	if len(r.GlobalIPWhitelist) > 0 {
+		whitelistNote = "global IP whitelist applies: " + strings.Join(r.GlobalIPWhitelist, " ")
+	}
+
+	members := map[string]int{}
+	for _, u := range users {
+		for _, id := range u.TeamIDs {
+			members[id]++
+		}
+		if !u.TwoFactorAuthEnabled {
+			r.add(FindingNo2FA, SubjectUser, u.Username, u.Name, "")
+		}
+		r.privileges(SubjectUser, u.Username, u.Name, u.Permissions, u.TeamIDs, teams)
+	}
+
+	for _, k := range keys {
+		for _, id := range k.TeamIDs {
+			members[id]++
+		}
+		switch {
+		case k.LastAccess == 0:
+			r.add(FindingUnusedKey, SubjectAPIKey, k.ID, k.Name, "never used")
+		case time.Unix(int64(k.LastAccess), 0).Before(cutoff):
+			r.add(FindingUnusedKey, SubjectAPIKey, k.ID, k.Name,
+				"last used "+time.Unix(int64(k.LastAccess), 0).UTC().Format(time.RFC3339))
+		}
+		switch {
+		case len(k.IPWhitelist) > 0:
+			if !k.IPWhitelistStrict {
+				r.add(FindingLaxIPWhitelist, SubjectAPIKey, k.ID, k.Name, strings.Join(k.IPWhitelist, " "))
+			}
+		case !teamWhitelisted(k.TeamIDs, teams):
+			r.add(FindingNoIPWhitelist, SubjectAPIKey, k.ID, k.Name, whitelistNote)
+		}
+		r.privileges(SubjectAPIKey, k.ID, k.Name, k.Permissions, k.TeamIDs, teams)
+	}
+
+	for _, t := range teams {
+		if members[t.ID] == 0 {
+			r.add(FindingEmptyTeam, SubjectTeam, t.ID, t.Name, "")
+		}
+		r.privileges(SubjectTeam, t.ID, t.Name, t.Permissions, nil, nil)
+	}
+
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		return r.Findings[i].Kind < r.Findings[j].Kind
+	})
+	return r
+}
+
+func (r *AuditReport) add(kind FindingKind, subject, id, name, detail string) {
+	r.Findings = append(r.Findings, &Finding{Kind: kind, Subject: subject, ID: id, Name: name, Detail: detail})
+}
+
+// teamWhitelisted reports whether any of the teams with the given IDs has
+// an IP whitelist.
+func teamWhitelisted(teamIDs []string, teams []*Team) bool {
+	for _, id := range teamIDs {
+		for _, t := range teams {
+			if t.ID != id {
+				continue
+			}
+			for _, wl := range t.IPWhitelist {
+				if len(wl.Values) > 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// privileges adds the findings of the account management permissions held
+// on one's own or through teams, telling where they come from.
+func (r *AuditReport) privileges(subject, id, name string, own PermissionsMap, teamIDs []string, teams []*Team) {
+	// Unlike NewEffective, tolerate unknown teams.
+	e := &Effective{Sources: []*PermissionSource{{Permissions: own}}}
+	for _, teamID := range teamIDs {
+		for _, t := range teams {
+			if t.ID == teamID {
+				e.Sources = append(e.Sources, &PermissionSource{Team: t, Permissions: t.Permissions})
+			}
+		}
+	}
+
+	for _, p := range []struct {
+		kind FindingKind
+		held func(*PermissionsMap) bool
+	}{
+		{FindingManageAPIKeys, func(p *PermissionsMap) bool { return p.Account.ManageApikeys }},
+		{FindingManageUsers, func(p *PermissionsMap) bool { return p.Account.ManageUsers }},
+	} {
+		sources := []string{}
+		for _, s := range e.Sources {
+			if p.held(&s.Permissions) {
+				sources = append(sources, s.String())
+			}
+		}
+		if len(sources) > 0 {
+			r.add(p.kind, subject, id, name, "granted by "+strings.Join(sources, ", "))
+		}
+	}
+}
+
+// auditColumns are the CSV columns of the findings.
+var auditColumns = []string{"kind", "subject", "id", "name", "detail"}
+
+// Write writes the report in the given export type: the whole report in
+// JSON, or a row per finding in CSV. Other types return
+// dataset.ErrUnsupportedExportType.
+func (r *AuditReport) Write(w io.Writer, format dataset.ExportType) error {
+	switch format {
+	case dataset.ExportTypeJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case dataset.ExportTypeCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(auditColumns); err != nil {
+			return err
+		}
+		for _, f := range r.Findings {
+			if err := cw.Write([]string{string(f.Kind), f.Subject, f.ID, f.Name, f.Detail}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("%w: %q", dataset.ErrUnsupportedExportType, format)
+	}
+}
@@ -0,0 +1,81 @@
+package account
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dataset"
+)
+
+func TestAudit(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	cutoff := now.AddDate(0, -3, 0)
+	teams := []*Team{
+		{ID: "t1", Name: "admins", Permissions: PermissionsMap{Account: PermissionsAccount{ManageUsers: true}}},
+		{ID: "t2", Name: "unused"},
+		{ID: "t3", Name: "ci", IPWhitelist: []IPWhitelist{{Name: "runners", Values: []string{"203.0.113.0/24"}}}},
+	}
+	users := []*User{
+		{Username: "alice", Name: "Alice", TwoFactorAuthEnabled: true, TeamIDs: []string{"t1"}},
+		{Username: "bob", Name: "Bob", TeamIDs: []string{"t3"}},
+	}
+	keys := []*APIKey{
+		{ID: "k1", Name: "ci", LastAccess: int(now.Add(-time.Hour).Unix()),
+			IPWhitelist: []string{"10.0.0.0/8"}, IPWhitelistStrict: true},
+		{ID: "k2", Name: "old", LastAccess: int(cutoff.Add(-time.Hour).Unix()),
+			IPWhitelist: []string{"10.0.0.0/8", "192.0.2.1"}, TeamIDs: []string{"t1"},
+			Permissions: PermissionsMap{Account: PermissionsAccount{ManageApikeys: true, ManageUsers: true}}},
+		{ID: "k3", Name: "new"},
+		{ID: "k4", Name: "runner", LastAccess: int(now.Unix()), TeamIDs: []string{"t3"}},
+	}
+	whitelists := []*IPWhitelist{{Name: "office", Values: []string{"198.51.100.0/24"}}}
+
+	r := Audit(users, keys, teams, whitelists, cutoff, now)
+	assert.Equal(t, 2, r.Users)
+	assert.Equal(t, 4, r.APIKeys)
+	assert.Equal(t, 3, r.Teams)
+	assert.Equal(t, []string{"198.51.100.0/24"}, r.GlobalIPWhitelist)
+
+	got := []Finding{}
+	for _, f := range r.Findings {
+		got = append(got, *f)
+	}
+	assert.Equal(t, []Finding{
+		{FindingEmptyTeam, SubjectTeam, "t2", "unused", ""},
+		{FindingManageAPIKeys, SubjectAPIKey, "k2", "old", "granted by own permissions"},
+		{FindingManageUsers, SubjectUser, "alice", "Alice", "granted by team admins (t1)"},
+		{FindingManageUsers, SubjectAPIKey, "k2", "old", "granted by own permissions, team admins (t1)"},
+		{FindingManageUsers, SubjectTeam, "t1", "admins", "granted by own permissions"},
+		{FindingNo2FA, SubjectUser, "bob", "Bob", ""},
+		{FindingNoIPWhitelist, SubjectAPIKey, "k3", "new", "global IP whitelist applies: 198.51.100.0/24"},
+		{FindingLaxIPWhitelist, SubjectAPIKey, "k2", "old", "10.0.0.0/8 192.0.2.1"},
+		{FindingUnusedKey, SubjectAPIKey, "k2", "old", "last used 2024-02-29T23:00:00Z"},
+		{FindingUnusedKey, SubjectAPIKey, "k3", "new", "never used"},
+	}, got)
+
+	r = Audit(nil, keys[2:], nil, nil, cutoff, now)
+	assert.Equal(t, "no global IP whitelist", r.Findings[0].Detail)
+}
+
+func TestAuditReport_Write(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	r := Audit([]*User{{Username: "bob", Name: "Bob, Jr"}}, nil, nil, nil, now, now)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf, dataset.ExportTypeCSV))
+	assert.Equal(t, "kind,subject,id,name,detail\nno-2fa,user,bob,\"Bob, Jr\",\n", buf.String())
+
+	buf.Reset()
+	require.NoError(t, r.Write(&buf, dataset.ExportTypeJSON))
+	var decoded AuditReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, *r, decoded)
+
+	err := r.Write(&buf, "xml")
+	assert.True(t, errors.Is(err, dataset.ErrUnsupportedExportType))
+}
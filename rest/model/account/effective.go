@@ -0,0 +1,355 @@
+package account
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PermissionSource is a set of permissions an Effective is made of: those
+// of the user or API key itself, or those of one of its teams.
+type PermissionSource struct {
+	// Team is nil for the own permissions of the user or API key.
+	Team        *Team
+	Permissions PermissionsMap
+}
+
+func (s *PermissionSource) String() string {
+	if s.Team == nil {
+		return "own permissions"
+	}
+	return fmt.Sprintf("team %s (%s)", s.Team.Name, s.Team.ID)
+}
+
+// Effective holds the permissions of a user or API key and those of its
+// teams. The holder may do whatever any of them allows.
+type Effective struct {
+	Sources []*PermissionSource
+}
+
+// Decision answers a permission question.
+type Decision struct {
+	Allowed bool
+	// Source is the permissions that allow the action, if allowed.
+	Source *PermissionSource
+	// Reason explains the decision; denials list the reason of each
+	// source.
+	Reason string
+}
+
+func (d *Decision) String() string {
+	if d.Allowed {
+		return fmt.Sprintf("allowed by %s: %s", d.Source, d.Reason)
+	}
+	return "denied: " + d.Reason
+}
+
+// NewEffective returns the effective permissions of a user or API key with
+// the given permissions and team IDs, looking the teams up in teams, e.g.
+// as returned by TeamsService.List.
+func NewEffective(own PermissionsMap, teamIDs []string, teams []*Team) (*Effective, error) {
+	e := &Effective{Sources: []*PermissionSource{{Permissions: own}}}
+	for _, id := range teamIDs {
+		var team *Team
+		for _, t := range teams {
+			if t.ID == id {
+				team = t
+				break
+			}
+		}
+		if team == nil {
+			return nil, fmt.Errorf("unknown team %s", id)
+		}
+		e.Sources = append(e.Sources, &PermissionSource{Team: team, Permissions: team.Permissions})
+	}
+	return e, nil
+}
+
+// EffectiveForUser returns the effective permissions of a user.
+func EffectiveForUser(u *User, teams []*Team) (*Effective, error) {
+	return NewEffective(u.Permissions, u.TeamIDs, teams)
+}
+
+// EffectiveForAPIKey returns the effective permissions of an API key.
+func EffectiveForAPIKey(k *APIKey, teams []*Team) (*Effective, error) {
+	return NewEffective(k.Permissions, k.TeamIDs, teams)
+}
+
+// Flags returns the permission flags granted by any source, along with all
+// the zone, record and tag rules. The rules are only listed: they are
+// evaluated per source by the Can methods.
+func (e *Effective) Flags() PermissionsMap {
+	var m PermissionsMap
+	for _, s := range e.Sources {
+		p := s.Permissions
+		m.DNS.ViewZones = m.DNS.ViewZones || p.DNS.ViewZones
+		m.DNS.ManageZones = m.DNS.ManageZones || p.DNS.ManageZones
+		m.DNS.ZonesAllowByDefault = m.DNS.ZonesAllowByDefault || p.DNS.ZonesAllowByDefault
+		m.DNS.ZonesAllow = append(m.DNS.ZonesAllow, p.DNS.ZonesAllow...)
+		m.DNS.ZonesDeny = append(m.DNS.ZonesDeny, p.DNS.ZonesDeny...)
+		m.DNS.RecordsAllow = append(m.DNS.RecordsAllow, p.DNS.RecordsAllow...)
+		m.DNS.RecordsDeny = append(m.DNS.RecordsDeny, p.DNS.RecordsDeny...)
+
+		m.Data.PushToDatafeeds = m.Data.PushToDatafeeds || p.Data.PushToDatafeeds
+		m.Data.ManageDatasources = m.Data.ManageDatasources || p.Data.ManageDatasources
+		m.Data.ManageDatafeeds = m.Data.ManageDatafeeds || p.Data.ManageDatafeeds
+
+		m.Account.ManageUsers = m.Account.ManageUsers || p.Account.ManageUsers
+		m.Account.ManagePaymentMethods = m.Account.ManagePaymentMethods || p.Account.ManagePaymentMethods
+		m.Account.ManagePlan = m.Account.ManagePlan || p.Account.ManagePlan
+		m.Account.ManageTeams = m.Account.ManageTeams || p.Account.ManageTeams
+		m.Account.ManageApikeys = m.Account.ManageApikeys || p.Account.ManageApikeys
+		m.Account.ManageAccountSettings = m.Account.ManageAccountSettings || p.Account.ManageAccountSettings
+		m.Account.ViewActivityLog = m.Account.ViewActivityLog || p.Account.ViewActivityLog
+		m.Account.ViewInvoices = m.Account.ViewInvoices || p.Account.ViewInvoices
+		m.Account.ManageIPWhitelist = m.Account.ManageIPWhitelist || p.Account.ManageIPWhitelist
+
+		m.Monitoring.ManageLists = m.Monitoring.ManageLists || p.Monitoring.ManageLists
+		m.Monitoring.ManageJobs = m.Monitoring.ManageJobs || p.Monitoring.ManageJobs
+		m.Monitoring.ViewJobs = m.Monitoring.ViewJobs || p.Monitoring.ViewJobs
+
+		if p.Security != nil {
+			if m.Security == nil {
+				m.Security = &PermissionsSecurity{}
+			}
+			m.Security.ManageGlobal2FA = m.Security.ManageGlobal2FA || p.Security.ManageGlobal2FA
+			m.Security.ManageActiveDirectory = m.Security.ManageActiveDirectory || p.Security.ManageActiveDirectory
+		}
+		if p.DHCP != nil {
+			if m.DHCP == nil {
+				m.DHCP = &PermissionsDHCP{}
+			}
+			m.DHCP.ManageDHCP = m.DHCP.ManageDHCP || p.DHCP.ManageDHCP
+			m.DHCP.ViewDHCP = m.DHCP.ViewDHCP || p.DHCP.ViewDHCP
+			m.DHCP.TagsAllow = appendTags(m.DHCP.TagsAllow, p.DHCP.TagsAllow)
+			m.DHCP.TagsDeny = appendTags(m.DHCP.TagsDeny, p.DHCP.TagsDeny)
+		}
+		if p.IPAM != nil {
+			if m.IPAM == nil {
+				m.IPAM = &PermissionsIPAM{}
+			}
+			m.IPAM.ManageIPAM = m.IPAM.ManageIPAM || p.IPAM.ManageIPAM
+			m.IPAM.ViewIPAM = m.IPAM.ViewIPAM || p.IPAM.ViewIPAM
+			m.IPAM.TagsAllow = appendTags(m.IPAM.TagsAllow, p.IPAM.TagsAllow)
+			m.IPAM.TagsDeny = appendTags(m.IPAM.TagsDeny, p.IPAM.TagsDeny)
+		}
+	}
+	return m
+}
+
+func appendTags(tags, more *[]AuthTag) *[]AuthTag {
+	if more == nil {
+		return tags
+	}
+	if tags == nil {
+		tags = &[]AuthTag{}
+	}
+	all := append(*tags, *more...)
+	return &all
+}
+
+// decide allows if any source allows, as told by check.
+func (e *Effective) decide(check func(p *PermissionsMap) (bool, string)) *Decision {
+	reasons := make([]string, 0, len(e.Sources))
+	for _, s := range e.Sources {
+		allowed, reason := check(&s.Permissions)
+		if allowed {
+			return &Decision{Allowed: true, Source: s, Reason: reason}
+		}
+		reasons = append(reasons, s.String()+": "+reason)
+	}
+	return &Decision{Reason: strings.Join(reasons, "; ")}
+}
+
+// CanViewZone tells whether the zone may be viewed.
+func (e *Effective) CanViewZone(zone string) *Decision {
+	return e.decide(func(p *PermissionsMap) (bool, string) {
+		if !p.DNS.ViewZones {
+			return false, "cannot view zones"
+		}
+		return zoneAllowed(&p.DNS, zone)
+	})
+}
+
+// CanManageZone tells whether the zone may be modified.
+func (e *Effective) CanManageZone(zone string) *Decision {
+	return e.decide(func(p *PermissionsMap) (bool, string) {
+		if !p.DNS.ManageZones {
+			return false, "cannot manage zones"
+		}
+		return zoneAllowed(&p.DNS, zone)
+	})
+}
+
+// CanModifyRecord tells whether the record of the given domain and type,
+// e.g. "www.example.com" and "A", may be modified in the zone.
+//
+// Record rules take precedence over zone rules: a record matching a
+// RecordsDeny rule may not be modified, and one matching a RecordsAllow
+// rule may be, even in a zone that is not allowed. Other records may be
+// modified with the ManageZones permission in allowed zones.
+func (e *Effective) CanModifyRecord(zone, domain, recordType string) *Decision {
+	return e.decide(func(p *PermissionsMap) (bool, string) {
+		for _, r := range p.DNS.RecordsDeny {
+			if recordMatches(r, zone, domain, recordType) {
+				return false, "record denied by " + recordRule(r)
+			}
+		}
+		for _, r := range p.DNS.RecordsAllow {
+			if recordMatches(r, zone, domain, recordType) {
+				return true, "record allowed by " + recordRule(r)
+			}
+		}
+		if !p.DNS.ManageZones {
+			return false, "cannot manage zones"
+		}
+		return zoneAllowed(&p.DNS, zone)
+	})
+}
+
+// zoneAllowed applies the zone rules: the rule of the closest enclosing
+// zone applies, an entry for "example.com" covering "sub.example.com" as
+// well, and ZonesAllowByDefault applies when no entry does. An allow entry
+// wins over a deny entry for the same zone.
+func zoneAllowed(p *PermissionsDNS, zone string) (bool, string) {
+	zone = normalizeName(zone)
+	best, allowed := -1, false
+	var rule string
+	for _, list := range []struct {
+		names []string
+		allow bool
+	}{{p.ZonesDeny, false}, {p.ZonesAllow, true}} {
+		for _, name := range list.names {
+			n := normalizeName(name)
+			if !isSubdomain(zone, n) || len(n) < best {
+				continue
+			}
+			best, allowed, rule = len(n), list.allow, name
+		}
+	}
+
+	switch {
+	case best >= 0 && allowed:
+		return true, fmt.Sprintf("zone %s allowed by %q", zone, rule)
+	case best >= 0:
+		return false, fmt.Sprintf("zone %s denied by %q", zone, rule)
+	case p.ZonesAllowByDefault:
+		return true, fmt.Sprintf("zone %s allowed by default", zone)
+	default:
+		return false, fmt.Sprintf("zone %s not allowed", zone)
+	}
+}
+
+// recordMatches tells whether a record rule covers a record. Rules without
+// zone or type cover all of them, and rules including subdomains cover the
+// domains below theirs.
+func recordMatches(r PermissionsRecord, zone, domain, recordType string) bool {
+	if r.Zone != "" && normalizeName(r.Zone) != normalizeName(zone) {
+		return false
+	}
+	if r.RecordType != "" && r.RecordType != "*" && !strings.EqualFold(r.RecordType, recordType) {
+		return false
+	}
+	domain, ruleDomain := normalizeName(domain), normalizeName(r.Domain)
+	return domain == ruleDomain || r.Subdomains && isSubdomain(domain, ruleDomain)
+}
+
+func recordRule(r PermissionsRecord) string {
+	s := r.Domain
+	if r.Subdomains {
+		s = "*." + s
+	}
+	if r.RecordType != "" {
+		s += " " + r.RecordType
+	}
+	if r.Zone != "" {
+		s += " in " + r.Zone
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+// CanViewIPAM tells whether an IPAM object with the given tags may be
+// viewed.
+func (e *Effective) CanViewIPAM(tags map[string]string) *Decision {
+	return e.decide(func(p *PermissionsMap) (bool, string) {
+		if p.IPAM == nil || !p.IPAM.ViewIPAM && !p.IPAM.ManageIPAM {
+			return false, "cannot view IPAM"
+		}
+		return tagsAllowed(p.IPAM.TagsAllow, p.IPAM.TagsDeny, tags)
+	})
+}
+
+// CanManageIPAM tells whether an IPAM object with the given tags may be
+// modified.
+func (e *Effective) CanManageIPAM(tags map[string]string) *Decision {
+	return e.decide(func(p *PermissionsMap) (bool, string) {
+		if p.IPAM == nil || !p.IPAM.ManageIPAM {
+			return false, "cannot manage IPAM"
+		}
+		return tagsAllowed(p.IPAM.TagsAllow, p.IPAM.TagsDeny, tags)
+	})
+}
+
+// CanViewDHCP tells whether a DHCP object with the given tags may be
+// viewed.
+func (e *Effective) CanViewDHCP(tags map[string]string) *Decision {
+	return e.decide(func(p *PermissionsMap) (bool, string) {
+		if p.DHCP == nil || !p.DHCP.ViewDHCP && !p.DHCP.ManageDHCP {
+			return false, "cannot view DHCP"
+		}
+		return tagsAllowed(p.DHCP.TagsAllow, p.DHCP.TagsDeny, tags)
+	})
+}
+
+// CanManageDHCP tells whether a DHCP object with the given tags may be
+// modified.
+func (e *Effective) CanManageDHCP(tags map[string]string) *Decision {
+	return e.decide(func(p *PermissionsMap) (bool, string) {
+		if p.DHCP == nil || !p.DHCP.ManageDHCP {
+			return false, "cannot manage DHCP"
+		}
+		return tagsAllowed(p.DHCP.TagsAllow, p.DHCP.TagsDeny, tags)
+	})
+}
+
+// tagsAllowed applies tag rules to the tags of an object: a denied tag
+// denies, and when there are allowed tags, one of them is required. A rule
+// with an empty value matches any value of the tag.
+func tagsAllowed(allow, deny *[]AuthTag, tags map[string]string) (bool, string) {
+	if deny != nil {
+		for _, t := range *deny {
+			if tagMatches(t, tags) {
+				return false, fmt.Sprintf("tag %s denied", tagRule(t))
+			}
+		}
+	}
+	if allow == nil || len(*allow) == 0 {
+		return true, "no tag restriction"
+	}
+	for _, t := range *allow {
+		if tagMatches(t, tags) {
+			return true, fmt.Sprintf("tag %s allowed", tagRule(t))
+		}
+	}
+	return false, "no allowed tag"
+}
+
+func tagMatches(t AuthTag, tags map[string]string) bool {
+	v, ok := tags[t.Name]
+	return ok && (t.Value == "" || t.Value == v)
+}
+
+func tagRule(t AuthTag) string {
+	if t.Value == "" {
+		return t.Name
+	}
+	return t.Name + "=" + t.Value
+}
+
+func normalizeName(s string) string {
+	return strings.ToLower(strings.TrimSuffix(s, "."))
+}
+
+// isSubdomain tells whether name is domain or below it.
+func isSubdomain(name, domain string) bool {
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
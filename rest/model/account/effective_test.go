@@ -0,0 +1,96 @@
+package account
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffective(t *testing.T) {
+	teams := []*Team{
+		{ID: "t1", Name: "dns", Permissions: PermissionsMap{DNS: PermissionsDNS{
+			ViewZones: true, ManageZones: true,
+			ZonesAllow: []string{"example.com"},
+			ZonesDeny:  []string{"prod.example.com"},
+		}}},
+		{ID: "t2", Name: "ddi", Permissions: PermissionsMap{
+			IPAM: &PermissionsIPAM{ViewIPAM: true, ManageIPAM: true,
+				TagsAllow: &[]AuthTag{{Name: "auth:site", Value: "paris"}},
+				TagsDeny:  &[]AuthTag{{Name: "auth:locked"}}},
+			DHCP: &PermissionsDHCP{ViewDHCP: true},
+		}},
+	}
+	k := &APIKey{Name: "deploy", TeamIDs: []string{"t1", "t2"}, Permissions: PermissionsMap{
+		Account: PermissionsAccount{ManageApikeys: true},
+		DNS: PermissionsDNS{
+			RecordsAllow: []PermissionsRecord{{Domain: "www.prod.example.com", Zone: "prod.example.com", RecordType: "A"}},
+			RecordsDeny:  []PermissionsRecord{{Domain: "mail.example.com", Subdomains: true}},
+		},
+	}}
+
+	e, err := EffectiveForAPIKey(k, teams)
+	require.NoError(t, err)
+	require.Len(t, e.Sources, 3)
+
+	flags := e.Flags()
+	assert.True(t, flags.Account.ManageApikeys)
+	assert.True(t, flags.DNS.ManageZones)
+	assert.Equal(t, []string{"example.com"}, flags.DNS.ZonesAllow)
+	require.NotNil(t, flags.IPAM)
+	assert.True(t, flags.IPAM.ManageIPAM)
+	assert.Nil(t, flags.Security)
+
+	for _, tc := range []struct {
+		name     string
+		decision *Decision
+		allowed  bool
+		want     string
+	}{
+		{"zone", e.CanManageZone("example.com"), true, "allowed by team dns (t1): zone example.com allowed by \"example.com\""},
+		{"subzone", e.CanViewZone("dev.example.com."), true, "allowed by team dns (t1): zone dev.example.com allowed by \"example.com\""},
+		{"denied subzone", e.CanManageZone("app.prod.example.com"), false,
+			"denied: own permissions: cannot manage zones; team dns (t1): zone app.prod.example.com denied by \"prod.example.com\"; team ddi (t2): cannot manage zones"},
+		{"other zone", e.CanViewZone("example.net"), false,
+			"denied: own permissions: cannot view zones; team dns (t1): zone example.net not allowed; team ddi (t2): cannot view zones"},
+		{"record", e.CanModifyRecord("example.com", "www.example.com", "A"), true, "allowed by team dns (t1): zone example.com allowed by \"example.com\""},
+		{"record allowed", e.CanModifyRecord("prod.example.com", "WWW.prod.example.com", "a"), true,
+			"allowed by own permissions: record allowed by \"www.prod.example.com A in prod.example.com\""},
+		{"record type", e.CanModifyRecord("prod.example.com", "www.prod.example.com", "AAAA"), false, ""},
+		{"record denied", e.CanModifyRecord("example.com", "smtp.mail.example.com", "MX"), true, "allowed by team dns (t1): zone example.com allowed by \"example.com\""},
+		{"ipam", e.CanManageIPAM(map[string]string{"auth:site": "paris"}), true, "allowed by team ddi (t2): tag auth:site=paris allowed"},
+		{"ipam other site", e.CanViewIPAM(map[string]string{"auth:site": "lyon"}), false, ""},
+		{"ipam locked", e.CanManageIPAM(map[string]string{"auth:site": "paris", "auth:locked": "yes"}), false, ""},
+		{"dhcp view", e.CanViewDHCP(nil), true, "allowed by team ddi (t2): no tag restriction"},
+		{"dhcp manage", e.CanManageDHCP(nil), false, ""},
+	} {
+		assert.Equal(t, tc.allowed, tc.decision.Allowed, tc.name)
+		if tc.want != "" {
+			assert.Equal(t, tc.want, tc.decision.String(), tc.name)
+		}
+	}
+
+	// Record denials only apply to the source holding them.
+	own, err := NewEffective(k.Permissions, nil, nil)
+	require.NoError(t, err)
+	d := own.CanModifyRecord("example.com", "smtp.mail.example.com", "MX")
+	assert.Equal(t, "denied: own permissions: record denied by \"*.mail.example.com\"", d.String())
+
+	_, err = NewEffective(k.Permissions, []string{"t3"}, teams)
+	assert.Error(t, err)
+}
+
+func TestEffective_ZonesAllowByDefault(t *testing.T) {
+	e, err := NewEffective(PermissionsMap{DNS: PermissionsDNS{
+		ViewZones: true, ZonesAllowByDefault: true,
+		ZonesDeny:  []string{"example.com"},
+		ZonesAllow: []string{"public.example.com"},
+	}}, nil, nil)
+	require.NoError(t, err)
+
+	assert.True(t, e.CanViewZone("example.net").Allowed)
+	assert.False(t, e.CanViewZone("example.com").Allowed)
+	assert.False(t, e.CanViewZone("a.example.com").Allowed)
+	assert.True(t, e.CanViewZone("a.public.example.com").Allowed)
+	assert.True(t, e.CanViewZone("notexample.com").Allowed)
+}
@@ -1,5 +1,12 @@
 package data
 
+// Destination types, i.e. the level of a record's metadata a feed applies to.
+const (
+	DestinationTypeAnswer = "answer"
+	DestinationTypeRegion = "region"
+	DestinationTypeRecord = "record"
+)
+
 // Destination is the target resource the receives data from a feed/source.
 type Destination struct {
 	ID string `json:"destid"`
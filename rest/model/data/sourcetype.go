@@ -0,0 +1,659 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SourceType identifies the integration behind an NS1 data source.
+type SourceType string
+
+// Source types supported by the NS1 API.
+const (
+	SourceTypeNS1API        SourceType = "nsone_v1"
+	SourceTypeNS1Monitoring SourceType = "nsone_monitoring"
+	SourceTypeA10           SourceType = "a10"
+	SourceTypeDatadog       SourceType = "datadog"
+	SourceTypeNewRelic      SourceType = "newrelic"
+	SourceTypePingdom       SourceType = "pingdom"
+	SourceTypeRackspace     SourceType = "rackspace"
+	SourceTypeRoute53       SourceType = "route53"
+	SourceTypeCloudWatch    SourceType = "cloudwatch"
+)
+
+// SourceConfig is implemented by the typed config of each source type.
+type SourceConfig interface {
+	SourceType() SourceType
+	Validate() error
+}
+
+// FeedConfig is implemented by the typed feed config of each source type.
+type FeedConfig interface {
+	SourceType() SourceType
+	Validate() error
+}
+
+// NS1APISourceConfig configures an NS1 API (push) data source.
+// The source itself takes no parameters.
+type NS1APISourceConfig struct{}
+
+// SourceType satisfies the SourceConfig interface.
+func (c *NS1APISourceConfig) SourceType() SourceType { return SourceTypeNS1API }
+
+// Validate satisfies the SourceConfig interface.
+func (c *NS1APISourceConfig) Validate() error { return nil }
+
+// NS1APIFeedConfig configures a feed of an NS1 API data source.
+type NS1APIFeedConfig struct {
+	// Label is the key under which data is published to the source.
+	Label string `json:"label"`
+}
+
+// SourceType satisfies the FeedConfig interface.
+func (c *NS1APIFeedConfig) SourceType() SourceType { return SourceTypeNS1API }
+
+// Validate satisfies the FeedConfig interface.
+func (c *NS1APIFeedConfig) Validate() error {
+	return requireField(SourceTypeNS1API, "label", c.Label)
+}
+
+// NS1MonitoringSourceConfig configures an NS1 monitoring data source.
+// The source itself takes no parameters.
+type NS1MonitoringSourceConfig struct{}
+
+// SourceType satisfies the SourceConfig interface.
+func (c *NS1MonitoringSourceConfig) SourceType() SourceType { return SourceTypeNS1Monitoring }
+
+// Validate satisfies the SourceConfig interface.
+func (c *NS1MonitoringSourceConfig) Validate() error { return nil }
+
+// NS1MonitoringFeedConfig configures a feed of an NS1 monitoring data source.
+type NS1MonitoringFeedConfig struct {
+	// JobID is the monitoring job whose status drives the feed.
+	JobID string `json:"jobid"`
+}
+
+// SourceType satisfies the FeedConfig interface.
+func (c *NS1MonitoringFeedConfig) SourceType() SourceType { return SourceTypeNS1Monitoring }
+
+// Validate satisfies the FeedConfig interface.
+func (c *NS1MonitoringFeedConfig) Validate() error {
+	return requireField(SourceTypeNS1Monitoring, "jobid", c.JobID)
+}
+
+// A10SourceConfig configures an A10 Networks data source.
+// The source itself takes no parameters.
+type A10SourceConfig struct{}
+
+// SourceType satisfies the SourceConfig interface.
+func (c *A10SourceConfig) SourceType() SourceType { return SourceTypeA10 }
+
+// Validate satisfies the SourceConfig interface.
+func (c *A10SourceConfig) Validate() error { return nil }
+
+// A10FeedConfig configures a feed of an A10 Networks data source.
+type A10FeedConfig struct {
+	// Label is the service group or virtual server reported by the device.
+	Label string `json:"label"`
+}
+
+// SourceType satisfies the FeedConfig interface.
+func (c *A10FeedConfig) SourceType() SourceType { return SourceTypeA10 }
+
+// Validate satisfies the FeedConfig interface.
+func (c *A10FeedConfig) Validate() error {
+	return requireField(SourceTypeA10, "label", c.Label)
+}
+
+// DatadogSourceConfig configures a Datadog webhook data source.
+// The source itself takes no parameters.
+type DatadogSourceConfig struct{}
+
+// SourceType satisfies the SourceConfig interface.
+func (c *DatadogSourceConfig) SourceType() SourceType { return SourceTypeDatadog }
+
+// Validate satisfies the SourceConfig interface.
+func (c *DatadogSourceConfig) Validate() error { return nil }
+
+// DatadogFeedConfig configures a feed of a Datadog data source.
+type DatadogFeedConfig struct {
+	// MonitorID is the Datadog monitor whose alerts drive the feed.
+	MonitorID string `json:"monitor_id"`
+}
+
+// SourceType satisfies the FeedConfig interface.
+func (c *DatadogFeedConfig) SourceType() SourceType { return SourceTypeDatadog }
+
+// Validate satisfies the FeedConfig interface.
+func (c *DatadogFeedConfig) Validate() error {
+	return requireField(SourceTypeDatadog, "monitor_id", c.MonitorID)
+}
+
+// NewRelicSourceConfig configures a New Relic webhook data source.
+// The source itself takes no parameters.
+type NewRelicSourceConfig struct{}
+
+// SourceType satisfies the SourceConfig interface.
+func (c *NewRelicSourceConfig) SourceType() SourceType { return SourceTypeNewRelic }
+
+// Validate satisfies the SourceConfig interface.
+func (c *NewRelicSourceConfig) Validate() error { return nil }
+
+// NewRelicFeedConfig configures a feed of a New Relic data source.
+type NewRelicFeedConfig struct {
+	// ApplicationID is the New Relic application whose alerts drive the feed.
+	ApplicationID string `json:"application_id"`
+}
+
+// SourceType satisfies the FeedConfig interface.
+func (c *NewRelicFeedConfig) SourceType() SourceType { return SourceTypeNewRelic }
+
+// Validate satisfies the FeedConfig interface.
+func (c *NewRelicFeedConfig) Validate() error {
+	return requireField(SourceTypeNewRelic, "application_id", c.ApplicationID)
+}
+
+// PingdomSourceConfig configures a Pingdom data source.
+type PingdomSourceConfig struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	APIKey   string `json:"apikey"`
+}
+
+// SourceType satisfies the SourceConfig interface.
+func (c *PingdomSourceConfig) SourceType() SourceType { return SourceTypePingdom }
+
+// Validate satisfies the SourceConfig interface.
+func (c *PingdomSourceConfig) Validate() error {
+	if err := requireField(SourceTypePingdom, "username", c.Username); err != nil {
+		return err
+	}
+	if err := requireField(SourceTypePingdom, "password", c.Password); err != nil {
+		return err
+	}
+	return requireField(SourceTypePingdom, "apikey", c.APIKey)
+}
+
+// PingdomFeedConfig configures a feed of a Pingdom data source.
+type PingdomFeedConfig struct {
+	// CheckID is the Pingdom check whose status drives the feed.
+	CheckID string `json:"check"`
+}
+
+// SourceType satisfies the FeedConfig interface.
+func (c *PingdomFeedConfig) SourceType() SourceType { return SourceTypePingdom }
+
+// Validate satisfies the FeedConfig interface.
+func (c *PingdomFeedConfig) Validate() error {
+	return requireField(SourceTypePingdom, "check", c.CheckID)
+}
+
+// RackspaceSourceConfig configures a Rackspace Cloud Monitoring data source.
+type RackspaceSourceConfig struct {
+	// WebhookToken is the secret Rackspace includes in each notification.
+	WebhookToken string `json:"webhook_token"`
+}
+
+// SourceType satisfies the SourceConfig interface.
+func (c *RackspaceSourceConfig) SourceType() SourceType { return SourceTypeRackspace }
+
+// Validate satisfies the SourceConfig interface.
+func (c *RackspaceSourceConfig) Validate() error {
+	return requireField(SourceTypeRackspace, "webhook_token", c.WebhookToken)
+}
+
+// RackspaceFeedConfig configures a feed of a Rackspace data source.
+type RackspaceFeedConfig struct {
+	EntityID string `json:"entity_id"`
+	CheckID  string `json:"check_id"`
+}
+
+// SourceType satisfies the FeedConfig interface.
+func (c *RackspaceFeedConfig) SourceType() SourceType { return SourceTypeRackspace }
+
+// Validate satisfies the FeedConfig interface.
+func (c *RackspaceFeedConfig) Validate() error {
+	if err := requireField(SourceTypeRackspace, "entity_id", c.EntityID); err != nil {
+		return err
+	}
+	return requireField(SourceTypeRackspace, "check_id", c.CheckID)
+}
+
+// Route53SourceConfig configures an Amazon Route 53 health check data source.
+type Route53SourceConfig struct {
+	AWSAccessKeyID     string `json:"aws_access_key_id"`
+	AWSSecretAccessKey string `json:"aws_secret_access_key"`
+}
+
+// SourceType satisfies the SourceConfig interface.
+func (c *Route53SourceConfig) SourceType() SourceType { return SourceTypeRoute53 }
+
+// Validate satisfies the SourceConfig interface.
+func (c *Route53SourceConfig) Validate() error {
+	return validateAWSCredentials(SourceTypeRoute53, c.AWSAccessKeyID, c.AWSSecretAccessKey)
+}
+
+// Route53FeedConfig configures a feed of a Route 53 data source.
+type Route53FeedConfig struct {
+	HealthCheckID string `json:"health_check_id"`
+}
+
+// SourceType satisfies the FeedConfig interface.
+func (c *Route53FeedConfig) SourceType() SourceType { return SourceTypeRoute53 }
+
+// Validate satisfies the FeedConfig interface.
+func (c *Route53FeedConfig) Validate() error {
+	return requireField(SourceTypeRoute53, "health_check_id", c.HealthCheckID)
+}
+
+// CloudWatchSourceConfig configures an Amazon CloudWatch data source.
+type CloudWatchSourceConfig struct {
+	AWSAccessKeyID     string `json:"aws_access_key_id"`
+	AWSSecretAccessKey string `json:"aws_secret_access_key"`
+}
+
+// SourceType satisfies the SourceConfig interface.
+func (c *CloudWatchSourceConfig) SourceType() SourceType { return SourceTypeCloudWatch }
+
+// Validate satisfies the SourceConfig interface.
+func (c *CloudWatchSourceConfig) Validate() error {
+	return validateAWSCredentials(SourceTypeCloudWatch, c.AWSAccessKeyID, c.AWSSecretAccessKey)
+}
+
+// CloudWatchFeedConfig configures a feed of a CloudWatch data source.
+type CloudWatchFeedConfig struct {
+	AlarmName string `json:"alarm_name"`
+	Region    string `json:"region"`
+}
+
+// SourceType satisfies the FeedConfig interface.
+func (c *CloudWatchFeedConfig) SourceType() SourceType { return SourceTypeCloudWatch }
+
+// Validate satisfies the FeedConfig interface.
+func (c *CloudWatchFeedConfig) Validate() error {
+	if err := requireField(SourceTypeCloudWatch, "alarm_name", c.AlarmName); err != nil {
+		return err
+	}
+	return requireField(SourceTypeCloudWatch, "region", c.Region)
+}
+
+// SourceTypeInfo describes a source type: how its source and feeds are
+// configured and which meta fields its feeds are able to drive.
+type SourceTypeInfo struct {
+	Type SourceType
+
+	// Human readable name of the integration.
+	Name string
+
+	// Provides holds the json names of the meta fields (e.g. "up",
+	// "connections") that feeds of this source type can supply.
+	Provides []string
+
+	newSourceConfig func() SourceConfig
+	newFeedConfig   func() FeedConfig
+}
+
+// NewSourceConfig returns an empty typed source config for the source type.
+func (i *SourceTypeInfo) NewSourceConfig() SourceConfig {
+	return i.newSourceConfig()
+}
+
+// NewFeedConfig returns an empty typed feed config for the source type.
+func (i *SourceTypeInfo) NewFeedConfig() FeedConfig {
+	return i.newFeedConfig()
+}
+
+// CanProvide reports whether feeds of the source type can drive the given
+// meta field, named as in its json tag.
+func (i *SourceTypeInfo) CanProvide(field string) bool {
+	for _, p := range i.Provides {
+		if p == field {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceTypes is the catalog of known source types.
+var sourceTypes = map[SourceType]*SourceTypeInfo{
+	SourceTypeNS1API: {
+		Type:            SourceTypeNS1API,
+		Name:            "NS1 API",
+		Provides:        metaFieldNames(),
+		newSourceConfig: func() SourceConfig { return &NS1APISourceConfig{} },
+		newFeedConfig:   func() FeedConfig { return &NS1APIFeedConfig{} },
+	},
+	SourceTypeNS1Monitoring: {
+		Type:            SourceTypeNS1Monitoring,
+		Name:            "NS1 Monitoring",
+		Provides:        []string{"up"},
+		newSourceConfig: func() SourceConfig { return &NS1MonitoringSourceConfig{} },
+		newFeedConfig:   func() FeedConfig { return &NS1MonitoringFeedConfig{} },
+	},
+	SourceTypeA10: {
+		Type:            SourceTypeA10,
+		Name:            "A10 Networks",
+		Provides:        []string{"up", "connections", "requests", "loadavg"},
+		newSourceConfig: func() SourceConfig { return &A10SourceConfig{} },
+		newFeedConfig:   func() FeedConfig { return &A10FeedConfig{} },
+	},
+	SourceTypeDatadog: {
+		Type:            SourceTypeDatadog,
+		Name:            "Datadog",
+		Provides:        []string{"up"},
+		newSourceConfig: func() SourceConfig { return &DatadogSourceConfig{} },
+		newFeedConfig:   func() FeedConfig { return &DatadogFeedConfig{} },
+	},
+	SourceTypeNewRelic: {
+		Type:            SourceTypeNewRelic,
+		Name:            "New Relic",
+		Provides:        []string{"up"},
+		newSourceConfig: func() SourceConfig { return &NewRelicSourceConfig{} },
+		newFeedConfig:   func() FeedConfig { return &NewRelicFeedConfig{} },
+	},
+	SourceTypePingdom: {
+		Type:            SourceTypePingdom,
+		Name:            "Pingdom",
+		Provides:        []string{"up"},
+		newSourceConfig: func() SourceConfig { return &PingdomSourceConfig{} },
+		newFeedConfig:   func() FeedConfig { return &PingdomFeedConfig{} },
+	},
+	SourceTypeRackspace: {
+		Type:            SourceTypeRackspace,
+		Name:            "Rackspace Cloud Monitoring",
+		Provides:        []string{"up"},
+		newSourceConfig: func() SourceConfig { return &RackspaceSourceConfig{} },
+		newFeedConfig:   func() FeedConfig { return &RackspaceFeedConfig{} },
+	},
+	SourceTypeRoute53: {
+		Type:            SourceTypeRoute53,
+		Name:            "Amazon Route 53 Health Checks",
+		Provides:        []string{"up"},
+		newSourceConfig: func() SourceConfig { return &Route53SourceConfig{} },
+		newFeedConfig:   func() FeedConfig { return &Route53FeedConfig{} },
+	},
+	SourceTypeCloudWatch: {
+		Type:            SourceTypeCloudWatch,
+		Name:            "Amazon CloudWatch",
+		Provides:        []string{"up"},
+		newSourceConfig: func() SourceConfig { return &CloudWatchSourceConfig{} },
+		newFeedConfig:   func() FeedConfig { return &CloudWatchFeedConfig{} },
+	},
+}
+
+// LookupSourceType returns the catalog entry for a source type.
+func LookupSourceType(t SourceType) (*SourceTypeInfo, bool) {
+	info, ok := sourceTypes[t]
+	return info, ok
+}
+
+// SourceTypes returns the catalog of known source types, sorted by type.
+func SourceTypes() []*SourceTypeInfo {
+	infos := make([]*SourceTypeInfo, 0, len(sourceTypes))
+	for _, info := range sourceTypes {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Type < infos[j].Type })
+	return infos
+}
+
+// NewSourceWithConfig takes a name and a typed config and returns a source
+// of the config's type. The config is validated first.
+func NewSourceWithConfig(name string, cfg SourceConfig) (*Source, error) {
+	s := NewSource(name, string(cfg.SourceType()))
+	if err := s.SetConfig(cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// SetConfig validates a typed config and stores it as the source's config.
+func (s *Source) SetConfig(cfg SourceConfig) error {
+	if s.Type != string(cfg.SourceType()) {
+		return fmt.Errorf("cannot use %s config for source of type %s", cfg.SourceType(), s.Type)
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	c, err := toConfig(cfg)
+	if err != nil {
+		return err
+	}
+	s.Config = c
+	return nil
+}
+
+// TypedConfig decodes the source's config into the typed config of its type.
+func (s *Source) TypedConfig() (SourceConfig, error) {
+	info, ok := LookupSourceType(SourceType(s.Type))
+	if !ok {
+		return nil, fmt.Errorf("unknown source type: %s", s.Type)
+	}
+	cfg := info.NewSourceConfig()
+	if err := fromConfig(s.Config, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the source's type and config, as well as the config of
+// every feed attached to it.
+func (s *Source) Validate() (errs []error) {
+	info, ok := LookupSourceType(SourceType(s.Type))
+	if !ok {
+		return []error{fmt.Errorf("unknown source type: %s", s.Type)}
+	}
+
+	cfg, err := s.TypedConfig()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	for _, f := range s.Feeds {
+		fc := info.NewFeedConfig()
+		err := fromConfig(f.Config, fc)
+		if err == nil {
+			err = fc.Validate()
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("feed %q: %s", f.Name, err))
+		}
+	}
+
+	return errs
+}
+
+// ValidateMeta checks that every meta field pointing at one of the source's
+// feeds is a field the source type is able to drive. Fields pointing at
+// feeds of other sources are ignored.
+func (s *Source) ValidateMeta(meta *Meta) (errs []error) {
+	info, ok := LookupSourceType(SourceType(s.Type))
+	if !ok {
+		return []error{fmt.Errorf("unknown source type: %s", s.Type)}
+	}
+
+	feeds := make(map[string]*Feed, len(s.Feeds))
+	for _, f := range s.Feeds {
+		feeds[f.ID] = f
+	}
+
+	for field, feedID := range meta.FeedPtrs() {
+		f, ok := feeds[feedID]
+		if !ok {
+			continue
+		}
+		if !info.CanProvide(field) {
+			errs = append(errs, fmt.Errorf(
+				"meta field '%s' cannot be driven by feed %q of %s source %q",
+				field, f.Name, info.Name, s.Name,
+			))
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+
+	return errs
+}
+
+// FeedPtrs returns the feed id of every meta field driven by a feed, keyed
+// by the field's json name.
+func (meta *Meta) FeedPtrs() map[string]string {
+	ptrs := make(map[string]string)
+	v := reflect.Indirect(reflect.ValueOf(meta))
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fv := v.Field(i)
+		if fv.IsNil() {
+			continue
+		}
+		if id, ok := feedID(fv.Interface()); ok {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			ptrs[name] = id
+		}
+	}
+	return ptrs
+}
+
+// NewFeedWithConfig takes a name and a typed config and returns a feed.
+// The config is validated first.
+func NewFeedWithConfig(name string, cfg FeedConfig) (*Feed, error) {
+	f := NewFeed(name, nil)
+	if err := f.SetConfig(cfg); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// SetConfig validates a typed config and stores it as the feed's config.
+func (f *Feed) SetConfig(cfg FeedConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	c, err := toConfig(cfg)
+	if err != nil {
+		return err
+	}
+	f.Config = c
+	return nil
+}
+
+// TypedConfig decodes the feed's config into the typed feed config of the
+// given source type.
+func (f *Feed) TypedConfig(t SourceType) (FeedConfig, error) {
+	info, ok := LookupSourceType(t)
+	if !ok {
+		return nil, fmt.Errorf("unknown source type: %s", t)
+	}
+	cfg := info.NewFeedConfig()
+	if err := fromConfig(f.Config, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// feedID returns the feed id if v is a feed pointer, including the raw map
+// form found in decoded json.
+func feedID(v interface{}) (string, bool) {
+	switch p := v.(type) {
+	case FeedPtr:
+		return p.FeedID, p.FeedID != ""
+	case *FeedPtr:
+		if p == nil {
+			return "", false
+		}
+		return p.FeedID, p.FeedID != ""
+	case map[string]interface{}:
+		id, ok := p["feed"].(string)
+		return id, ok && id != ""
+	}
+	return "", false
+}
+
+// metaFieldNames returns the json names of all meta fields.
+func metaFieldNames() []string {
+	t := reflect.TypeOf(Meta{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		names = append(names, strings.Split(t.Field(i).Tag.Get("json"), ",")[0])
+	}
+	return names
+}
+
+func requireField(t SourceType, name, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s config requires '%s'", t, name)
+	}
+	return nil
+}
+
+func validateAWSCredentials(t SourceType, keyID, secret string) error {
+	if err := requireField(t, "aws_access_key_id", keyID); err != nil {
+		return err
+	}
+	return requireField(t, "aws_secret_access_key", secret)
+}
+
+func toConfig(v interface{}) (Config, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	c := Config{}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// fromConfig decodes c into the typed config v. The API may return numeric
+// values, e.g. Pingdom check ids, for fields typed as strings; those are
+// converted to their decimal form.
+func fromConfig(c Config, v interface{}) error {
+	if c == nil {
+		return nil
+	}
+	c = stringifyNumbers(c, v)
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// stringifyNumbers returns a copy of c where the numbers destined to string
+// fields of the struct pointed to by v are replaced by their decimal form.
+func stringifyNumbers(c Config, v interface{}) Config {
+	t := reflect.TypeOf(v)
+	if t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct {
+		return c
+	}
+	t = t.Elem()
+	out := make(Config, len(c))
+	for k, val := range c {
+		out[k] = val
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Type.Kind() != reflect.String {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		switch n := out[name].(type) {
+		case float64:
+			out[name] = strconv.FormatFloat(n, 'f', -1, 64)
+		case json.Number:
+			out[name] = n.String()
+		case int:
+			out[name] = strconv.Itoa(n)
+		case int64:
+			out[name] = strconv.FormatInt(n, 10)
+		}
+	}
+	return out
+}
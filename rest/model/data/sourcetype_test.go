@@ -0,0 +1,112 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSourceTypes(t *testing.T) {
+	infos := SourceTypes()
+	require.NotEmpty(t, infos)
+
+	for _, info := range infos {
+		assert.Equal(t, info.Type, info.NewSourceConfig().SourceType())
+		assert.Equal(t, info.Type, info.NewFeedConfig().SourceType())
+		assert.NotEmpty(t, info.Provides, info.Type)
+	}
+
+	info, ok := LookupSourceType(SourceTypeNS1API)
+	require.True(t, ok)
+	assert.True(t, info.CanProvide("loadavg"))
+	assert.True(t, info.CanProvide("ip_prefixes"))
+
+	info, ok = LookupSourceType(SourceTypeNS1Monitoring)
+	require.True(t, ok)
+	assert.True(t, info.CanProvide("up"))
+	assert.False(t, info.CanProvide("connections"))
+
+	_, ok = LookupSourceType("bogus")
+	assert.False(t, ok)
+}
+
+func TestSource_TypedConfig(t *testing.T) {
+	cfg := &Route53SourceConfig{AWSAccessKeyID: "key", AWSSecretAccessKey: "secret"}
+	s, err := NewSourceWithConfig("r53", cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "route53", s.Type)
+	assert.Equal(t, Config{"aws_access_key_id": "key", "aws_secret_access_key": "secret"}, s.Config)
+
+	typed, err := s.TypedConfig()
+	require.NoError(t, err)
+	assert.Equal(t, cfg, typed)
+
+	_, err = NewSourceWithConfig("r53", &Route53SourceConfig{AWSAccessKeyID: "key"})
+	assert.EqualError(t, err, "route53 config requires 'aws_secret_access_key'")
+
+	err = s.SetConfig(&PingdomSourceConfig{})
+	assert.EqualError(t, err, "cannot use pingdom config for source of type route53")
+
+	s = NewSource("unknown", "bogus")
+	_, err = s.TypedConfig()
+	assert.EqualError(t, err, "unknown source type: bogus")
+}
+
+func TestFeed_TypedConfig(t *testing.T) {
+	f, err := NewFeedWithConfig("monitor", &NS1MonitoringFeedConfig{JobID: "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, Config{"jobid": "abc"}, f.Config)
+
+	typed, err := f.TypedConfig(SourceTypeNS1Monitoring)
+	require.NoError(t, err)
+	assert.Equal(t, &NS1MonitoringFeedConfig{JobID: "abc"}, typed)
+
+	_, err = NewFeedWithConfig("monitor", &NS1MonitoringFeedConfig{})
+	assert.EqualError(t, err, "nsone_monitoring config requires 'jobid'")
+}
+
+func TestFeed_TypedConfigNumbers(t *testing.T) {
+	var f Feed
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "pingdom", "config": {"check": 1234567}}`), &f))
+
+	typed, err := f.TypedConfig(SourceTypePingdom)
+	require.NoError(t, err)
+	assert.Equal(t, &PingdomFeedConfig{CheckID: "1234567"}, typed)
+}
+
+func TestSource_Validate(t *testing.T) {
+	s := NewSource("api", string(SourceTypeNS1API))
+	s.Feeds = []*Feed{
+		NewFeed("good", Config{"label": "London-UK"}),
+		NewFeed("bad", Config{}),
+	}
+	errs := s.Validate()
+	require.Len(t, errs, 1)
+	assert.EqualError(t, errs[0], `feed "bad": nsone_v1 config requires 'label'`)
+
+	s = NewSource("unknown", "bogus")
+	assert.Len(t, s.Validate(), 1)
+}
+
+func TestSource_ValidateMeta(t *testing.T) {
+	s := NewSource("monitoring", string(SourceTypeNS1Monitoring))
+	s.Feeds = []*Feed{{ID: "f1", Name: "lhr"}}
+
+	meta := &Meta{
+		Up:          FeedPtr{FeedID: "f1"},
+		Connections: map[string]interface{}{"feed": "f1"},
+		Priority:    FeedPtr{FeedID: "other-source-feed"},
+		Weight:      10,
+	}
+	assert.Equal(t, map[string]string{
+		"up":          "f1",
+		"connections": "f1",
+		"priority":    "other-source-feed",
+	}, meta.FeedPtrs())
+
+	errs := s.ValidateMeta(meta)
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "meta field 'connections' cannot be driven")
+}
@@ -0,0 +1,346 @@
+package dataset
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedExportType is returned when parsing a report in a format
+// other than csv or json.
+var ErrUnsupportedExportType = errors.New("unsupported report export type")
+
+// Row fields populated from report columns.
+const (
+	columnTimestamp  = "timestamp"
+	columnZone       = "zone"
+	columnDomain     = "domain"
+	columnRecordType = "type"
+	columnNetwork    = "network"
+	columnRank       = "rank"
+	columnValue      = "value"
+)
+
+// reportColumns maps the headers of exported reports, lower cased, to the
+// row field they populate. The value column is named after the datatype.
+var reportColumns = map[string]string{
+	"timestamp": columnTimestamp,
+	"zone":      columnZone,
+	"domain":    columnDomain,
+	"type":      columnRecordType,
+	"network":   columnNetwork,
+	"rank":      columnRank,
+
+	string(DatatypeTypeNumQueries):   columnValue,
+	string(DatatypeTypeEBOTResponse): columnValue,
+	string(DatatypeTypeNXDResponse):  columnValue,
+}
+
+// ReportRow is a single row of a dataset report. Which fields are set
+// depends on the scope of the dataset: zone scopes set Zone, record scopes
+// also set Domain and RecordType, network scopes set Network.
+type ReportRow struct {
+	// Timestamp is the start of the period the row covers, zero for
+	// reports aggregated over the whole timeframe.
+	Timestamp time.Time
+
+	Zone       string
+	Domain     string
+	RecordType string
+	Network    *int
+
+	// Rank is the 1-based position of the row in top_n_* reports.
+	Rank int
+
+	// Value is the count of the dataset's datatype: queries, EBOT or NXD
+	// responses. It is always 0 for zero_queries reports.
+	Value int64
+
+	// Extra holds the columns that are not recognized, by header.
+	Extra map[string]string
+}
+
+// ReportData is a parsed dataset report.
+type ReportData struct {
+	Datatype *Datatype
+	Rows     []*ReportRow
+}
+
+// ParseReport decodes a report in the given export type, as returned by
+// DatasetsService.GetReport, into rows. The datatype of the dataset is used
+// to check that the columns required by its scope are present.
+func ParseReport(r io.Reader, exportType ExportType, dt *Datatype) (*ReportData, error) {
+	var records []map[string]string
+	var err error
+
+	switch exportType {
+	case ExportTypeCSV:
+		records, err = readCSVReport(r)
+	case ExportTypeJSON:
+		records, err = readJSONReport(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedExportType, exportType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	data := &ReportData{Datatype: dt, Rows: make([]*ReportRow, 0, len(records))}
+	for i, rec := range records {
+		row, err := parseReportRow(rec)
+		if err != nil {
+			return nil, fmt.Errorf("report row %d: %w", i+1, err)
+		}
+		if err := checkReportRow(row, rec, dt); err != nil {
+			return nil, fmt.Errorf("report row %d: %w", i+1, err)
+		}
+		if row.Rank == 0 && dt != nil && isTopN(dt.Scope) {
+			row.Rank = i + 1
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	return data, nil
+}
+
+// Total returns the sum of the values of all rows.
+func (d *ReportData) Total() int64 {
+	var total int64
+	for _, row := range d.Rows {
+		total += row.Value
+	}
+	return total
+}
+
+// Top returns the n rows with the highest values, in descending order.
+// Ties keep their report order.
+func (d *ReportData) Top(n int) []*ReportRow {
+	rows := append([]*ReportRow{}, d.Rows...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].Value > rows[j].Value })
+	if n < len(rows) {
+		rows = rows[:n]
+	}
+	return rows
+}
+
+func readCSVReport(r io.Reader) ([]map[string]string, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return []map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Strip a UTF-8 byte order mark, as spreadsheet tools add.
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	if err := checkHeaders(header); err != nil {
+		return nil, err
+	}
+
+	records := []map[string]string{}
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(fields) > len(header) {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("line %d has %d fields, header has %d", line, len(fields), len(header))
+		}
+
+		rec := make(map[string]string, len(fields))
+		for i, f := range fields {
+			rec[header[i]] = f
+		}
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// readJSONReport reads an array of row objects.
+func readJSONReport(r io.Reader) ([]map[string]string, error) {
+	var objects []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, err
+	}
+
+	records := make([]map[string]string, len(objects))
+	for i, obj := range objects {
+		rec := make(map[string]string, len(obj))
+		for k, v := range obj {
+			switch v := v.(type) {
+			case nil:
+			case string:
+				rec[k] = v
+			case float64:
+				rec[k] = strconv.FormatFloat(v, 'f', -1, 64)
+			default:
+				b, _ := json.Marshal(v)
+				rec[k] = string(b)
+			}
+		}
+		records[i] = rec
+	}
+
+	return records, nil
+}
+
+// checkHeaders rejects reports where several headers populate the same
+// row field, e.g. "Zone" and "zone".
+func checkHeaders(headers []string) error {
+	seen := map[string]string{}
+	for _, header := range headers {
+		column, ok := reportColumns[strings.ToLower(strings.TrimSpace(header))]
+		if !ok {
+			continue
+		}
+		if other, ok := seen[column]; ok {
+			return fmt.Errorf("headers %q and %q both set the %s column", other, header, column)
+		}
+		seen[column] = header
+	}
+	return nil
+}
+
+func parseReportRow(rec map[string]string) (*ReportRow, error) {
+	headers := make([]string, 0, len(rec))
+	for header := range rec {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+	if err := checkHeaders(headers); err != nil {
+		return nil, err
+	}
+
+	row := &ReportRow{}
+	for _, header := range headers {
+		value := strings.TrimSpace(rec[header])
+
+		column, ok := reportColumns[strings.ToLower(strings.TrimSpace(header))]
+		if !ok {
+			if row.Extra == nil {
+				row.Extra = map[string]string{}
+			}
+			row.Extra[header] = value
+			continue
+		}
+		if value == "" {
+			continue
+		}
+
+		var err error
+		switch column {
+		case columnTimestamp:
+			row.Timestamp, err = parseReportTime(value)
+		case columnZone:
+			row.Zone = value
+		case columnDomain:
+			row.Domain = value
+		case columnRecordType:
+			row.RecordType = strings.ToUpper(value)
+		case columnNetwork:
+			var n int
+			n, err = strconv.Atoi(value)
+			row.Network = &n
+		case columnRank:
+			row.Rank, err = strconv.Atoi(value)
+		case columnValue:
+			row.Value, err = parseReportCount(value)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q", header, value)
+		}
+	}
+	return row, nil
+}
+
+// checkReportRow checks that the row has the columns its scope needs.
+func checkReportRow(row *ReportRow, rec map[string]string, dt *Datatype) error {
+	if dt == nil {
+		return nil
+	}
+
+	var missing []string
+	switch dt.Scope {
+	case DatatypeScopeZoneEach, DatatypeScopeTopNZones:
+		if row.Zone == "" {
+			missing = append(missing, columnZone)
+		}
+	case DatatypeScopeRecordEach, DatatypeScopeTopNRecords:
+		if row.Domain == "" {
+			missing = append(missing, columnDomain)
+		}
+		if row.RecordType == "" {
+			missing = append(missing, columnRecordType)
+		}
+	case DatatypeScopeNetworkEach:
+		if row.Network == nil {
+			missing = append(missing, columnNetwork)
+		}
+	}
+
+	if dt.Type != DatatypeTypeZeroQueries && !hasColumn(rec, columnValue) {
+		missing = append(missing, string(dt.Type))
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%s report is missing %s", dt.Scope, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func hasColumn(rec map[string]string, column string) bool {
+	for header := range rec {
+		if reportColumns[strings.ToLower(strings.TrimSpace(header))] == column {
+			return true
+		}
+	}
+	return false
+}
+
+func isTopN(scope DatatypeScope) bool {
+	return scope == DatatypeScopeTopNZones || scope == DatatypeScopeTopNRecords
+}
+
+// parseReportTime accepts unix seconds, RFC 3339 timestamps and dates.
+func parseReportTime(s string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(unix, 0).UTC(), nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", s)
+}
+
+// parseReportCount accepts integers, optionally with thousands separators
+// or written as whole floats, e.g. "1,234" or "1234.0".
+func parseReportCount(s string) (int64, error) {
+	s = strings.ReplaceAll(s, ",", "")
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != float64(int64(f)) {
+		return 0, fmt.Errorf("not a count: %q", s)
+	}
+	return int64(f), nil
+}
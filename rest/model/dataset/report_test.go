@@ -0,0 +1,139 @@
+package dataset
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReportCSV(t *testing.T) {
+	t.Run("record each", func(t *testing.T) {
+		report := "\ufeffTimestamp,Zone,Domain,Type,Num_Queries,Note\n" +
+			"2024-03-01,example.com,www.example.com,a,\"1,500\",first\n" +
+			"2024-03-02,example.com,mail.example.com,MX,20,\n"
+		dt := NewDatatype(DatatypeTypeNumQueries, DatatypeScopeRecordEach, nil)
+
+		data, err := ParseReport(strings.NewReader(report), ExportTypeCSV, dt)
+		require.NoError(t, err)
+		require.Len(t, data.Rows, 2)
+
+		row := data.Rows[0]
+		assert.Equal(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), row.Timestamp)
+		assert.Equal(t, "example.com", row.Zone)
+		assert.Equal(t, "www.example.com", row.Domain)
+		assert.Equal(t, "A", row.RecordType)
+		assert.Equal(t, int64(1500), row.Value)
+		assert.Equal(t, map[string]string{"Note": "first"}, row.Extra)
+		assert.Equal(t, 0, row.Rank)
+
+		assert.Equal(t, int64(1520), data.Total())
+	})
+
+	t.Run("zero queries", func(t *testing.T) {
+		report := "zone\nidle.com\nunused.net\n"
+		dt := NewDatatype(DatatypeTypeZeroQueries, DatatypeScopeZoneEach, nil)
+
+		data, err := ParseReport(strings.NewReader(report), ExportTypeCSV, dt)
+		require.NoError(t, err)
+		require.Len(t, data.Rows, 2)
+		assert.Equal(t, "unused.net", data.Rows[1].Zone)
+		assert.Equal(t, int64(0), data.Total())
+	})
+
+	t.Run("missing scope column", func(t *testing.T) {
+		report := "zone,num_queries\nexample.com,10\n"
+		dt := NewDatatype(DatatypeTypeNumQueries, DatatypeScopeRecordEach, nil)
+
+		_, err := ParseReport(strings.NewReader(report), ExportTypeCSV, dt)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing domain, type")
+	})
+
+	t.Run("missing value column", func(t *testing.T) {
+		report := "zone\nexample.com\n"
+		dt := NewDatatype(DatatypeTypeNXDResponse, DatatypeScopeZoneEach, nil)
+
+		_, err := ParseReport(strings.NewReader(report), ExportTypeCSV, dt)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "num_nxd_response")
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		report := "zone,num_queries\nexample.com,lots\n"
+
+		_, err := ParseReport(strings.NewReader(report), ExportTypeCSV, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "report row 1")
+	})
+
+	t.Run("ambiguous columns", func(t *testing.T) {
+		report := "zone,num_queries,num_nxd_response\nexample.com,10,2\n"
+
+		_, err := ParseReport(strings.NewReader(report), ExportTypeCSV, nil)
+		assert.EqualError(t, err, `headers "num_queries" and "num_nxd_response" both set the value column`)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		data, err := ParseReport(strings.NewReader(""), ExportTypeCSV, nil)
+		require.NoError(t, err)
+		assert.Empty(t, data.Rows)
+	})
+}
+
+func TestParseReportJSON(t *testing.T) {
+	t.Run("array", func(t *testing.T) {
+		report := `[
+			{"network": 0, "num_ebot_response": 12, "timestamp": 1709251200},
+			{"network": 1, "num_ebot_response": 3, "timestamp": 1709251200}
+		]`
+		dt := NewDatatype(DatatypeTypeEBOTResponse, DatatypeScopeNetworkEach, nil)
+
+		data, err := ParseReport(strings.NewReader(report), ExportTypeJSON, dt)
+		require.NoError(t, err)
+		require.Len(t, data.Rows, 2)
+		require.NotNil(t, data.Rows[1].Network)
+		assert.Equal(t, 1, *data.Rows[1].Network)
+		assert.Equal(t, int64(3), data.Rows[1].Value)
+		assert.Equal(t, time.Unix(1709251200, 0).UTC(), data.Rows[0].Timestamp)
+	})
+
+	t.Run("top n", func(t *testing.T) {
+		report := `[
+			{"zone": "b.com", "domain": "www.b.com", "type": "A", "num_queries": 50},
+			{"zone": "a.com", "domain": "www.a.com", "type": "AAAA", "num_queries": 90}
+		]`
+		dt := NewDatatype(DatatypeTypeNumQueries, DatatypeScopeTopNRecords, nil)
+
+		data, err := ParseReport(strings.NewReader(report), ExportTypeJSON, dt)
+		require.NoError(t, err)
+		require.Len(t, data.Rows, 2)
+		assert.Equal(t, 1, data.Rows[0].Rank)
+		assert.Equal(t, 2, data.Rows[1].Rank)
+
+		top := data.Top(1)
+		require.Len(t, top, 1)
+		assert.Equal(t, "www.a.com", top[0].Domain)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ParseReport(strings.NewReader(`"nope"`), ExportTypeJSON, nil)
+		require.Error(t, err)
+	})
+
+	t.Run("ambiguous columns", func(t *testing.T) {
+		report := `[{"zone": "a.com", "Zone": "b.com", "num_queries": 1}]`
+
+		_, err := ParseReport(strings.NewReader(report), ExportTypeJSON, nil)
+		assert.EqualError(t, err, `report row 1: headers "Zone" and "zone" both set the zone column`)
+	})
+}
+
+func TestParseReportUnsupported(t *testing.T) {
+	_, err := ParseReport(strings.NewReader(""), ExportTypeXLSX, nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrUnsupportedExportType))
+}
@@ -0,0 +1,315 @@
+package dhcp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Option spaces of the standard options.
+const (
+	SpaceV4 = "dhcpv4"
+	SpaceV6 = "dhcpv6"
+)
+
+// standardOptions lists the standard DHCPv4 and DHCPv6 options, named as
+// in Kea and NS1.
+var standardOptions = []OptionDef{
+	stdOption(SpaceV4, 1, "subnet-mask", SchemaTypeIPv4Address),
+	stdOption(SpaceV4, 2, "time-offset", SchemaTypeInt32),
+	stdArray(SpaceV4, 3, "routers", ItemTypeIPv4Address),
+	stdArray(SpaceV4, 4, "time-servers", ItemTypeIPv4Address),
+	stdArray(SpaceV4, 5, "name-servers", ItemTypeIPv4Address),
+	stdArray(SpaceV4, 6, "domain-name-servers", ItemTypeIPv4Address),
+	stdArray(SpaceV4, 7, "log-servers", ItemTypeIPv4Address),
+	stdOption(SpaceV4, 12, "host-name", SchemaTypeString),
+	stdOption(SpaceV4, 15, "domain-name", SchemaTypeFQDN),
+	stdOption(SpaceV4, 17, "root-path", SchemaTypeString),
+	stdOption(SpaceV4, 19, "ip-forwarding", SchemaTypeBoolean),
+	stdOption(SpaceV4, 23, "default-ip-ttl", SchemaTypeUint8),
+	stdOption(SpaceV4, 26, "interface-mtu", SchemaTypeUint16),
+	stdOption(SpaceV4, 28, "broadcast-address", SchemaTypeIPv4Address),
+	stdArray(SpaceV4, 33, "static-routes", ItemTypeIPv4Address),
+	stdArray(SpaceV4, 41, "nis-servers", ItemTypeIPv4Address),
+	stdArray(SpaceV4, 42, "ntp-servers", ItemTypeIPv4Address),
+	stdOption(SpaceV4, 43, "vendor-encapsulated-options", SchemaTypeEmpty),
+	stdArray(SpaceV4, 44, "netbios-name-servers", ItemTypeIPv4Address),
+	stdOption(SpaceV4, 46, "netbios-node-type", SchemaTypeUint8),
+	stdOption(SpaceV4, 47, "netbios-scope", SchemaTypeString),
+	stdOption(SpaceV4, 51, "dhcp-lease-time", SchemaTypeUint32),
+	stdOption(SpaceV4, 58, "dhcp-renewal-time", SchemaTypeUint32),
+	stdOption(SpaceV4, 59, "dhcp-rebinding-time", SchemaTypeUint32),
+	stdOption(SpaceV4, 60, "vendor-class-identifier", SchemaTypeString),
+	stdOption(SpaceV4, 66, "tftp-server-name", SchemaTypeString),
+	stdOption(SpaceV4, 67, "boot-file-name", SchemaTypeString),
+	stdArray(SpaceV4, 69, "smtp-server", ItemTypeIPv4Address),
+	stdArray(SpaceV4, 119, "domain-search", ItemTypeFQDN),
+	stdArray(SpaceV4, 150, "tftp-server-address", ItemTypeIPv4Address),
+
+	stdOption(SpaceV6, 7, "preference", SchemaTypeUint8),
+	stdArray(SpaceV6, 21, "sip-server-dns", ItemTypeFQDN),
+	stdArray(SpaceV6, 22, "sip-server-addr", ItemTypeIPv6Address),
+	stdArray(SpaceV6, 23, "dns-servers", ItemTypeIPv6Address),
+	stdArray(SpaceV6, 24, "domain-search", ItemTypeFQDN),
+	stdArray(SpaceV6, 27, "nis-servers", ItemTypeIPv6Address),
+	stdArray(SpaceV6, 31, "sntp-servers", ItemTypeIPv6Address),
+	stdOption(SpaceV6, 32, "information-refresh-time", SchemaTypeUint32),
+	stdArray(SpaceV6, 56, "ntp-server", ItemTypeIPv6Address),
+	stdOption(SpaceV6, 59, "bootfile-url", SchemaTypeString),
+}
+
+func stdOption(space string, code int, name string, t SchemaType) OptionDef {
+	return OptionDef{Space: &space, FriendlyName: name, Code: code, Schema: OptionDefSchema{Type: t}}
+}
+
+func stdArray(space string, code int, name string, item ItemType) OptionDef {
+	items := string(item)
+	def := stdOption(space, code, name, SchemaTypeArray)
+	def.Schema.Items = &items
+	return def
+}
+
+// StandardOptionDefs returns the definitions of the standard DHCPv4 and
+// DHCPv6 options.
+func StandardOptionDefs() []OptionDef {
+	defs := make([]OptionDef, len(standardOptions))
+	copy(defs, standardOptions)
+	return defs
+}
+
+// OptionName returns the name options of the definition are set with,
+// e.g. "dhcpv4/routers". Definitions without space are DHCPv4 ones.
+func (d *OptionDef) OptionName() string {
+	space := SpaceV4
+	if d.Space != nil && *d.Space != "" {
+		space = *d.Space
+	}
+	return space + "/" + d.FriendlyName
+}
+
+// OptionCatalog looks up option definitions by name or code.
+type OptionCatalog struct {
+	byName map[string]*OptionDef
+	byCode map[string]map[int]*OptionDef
+}
+
+// NewOptionCatalog returns a catalog of the standard options and the given
+// custom ones, e.g. as returned by OptionDefService.List. Custom
+// definitions take precedence over standard ones.
+func NewOptionCatalog(custom ...OptionDef) *OptionCatalog {
+	c := &OptionCatalog{byName: map[string]*OptionDef{}, byCode: map[string]map[int]*OptionDef{}}
+	for _, defs := range [][]OptionDef{standardOptions, custom} {
+		for i := range defs {
+			d := defs[i]
+			name := d.OptionName()
+			space := strings.SplitN(name, "/", 2)[0]
+			c.byName[name] = &d
+			if c.byCode[space] == nil {
+				c.byCode[space] = map[int]*OptionDef{}
+			}
+			c.byCode[space][d.Code] = &d
+		}
+	}
+	return c
+}
+
+// Lookup returns the definition of the option with the given name, e.g.
+// "dhcpv4/routers".
+func (c *OptionCatalog) Lookup(name string) (*OptionDef, bool) {
+	d, ok := c.byName[name]
+	return d, ok
+}
+
+// LookupCode returns the definition of the option with the given code in
+// the given space, e.g. "dhcpv4".
+func (c *OptionCatalog) LookupCode(space string, code int) (*OptionDef, bool) {
+	d, ok := c.byCode[space][code]
+	return d, ok
+}
+
+// Names returns the names of the options of the catalog, sorted.
+func (c *OptionCatalog) Names() []string {
+	names := make([]string, 0, len(c.byName))
+	for name := range c.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseText converts an option value in the comma-separated text form
+// used by ISC dhcpd and Kea, e.g. "192.0.2.1, 192.0.2.2", into an option
+// Value of the definition's type: a string, number or boolean for single
+// values, and a slice of them for arrays and records.
+func (d *OptionDef) ParseText(s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	switch d.Schema.Type {
+	case SchemaTypeArray:
+		if d.Schema.Items == nil {
+			return nil, fmt.Errorf("%s: array definition lacks an item type", d.OptionName())
+		}
+		values := []interface{}{}
+		for _, item := range splitText(s) {
+			v, err := parseItem(ItemType(*d.Schema.Items), item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	case SchemaTypeRecord:
+		items := splitText(s)
+		fields := d.Schema.Fields
+		multiple := d.Schema.MultipleFinalValue != nil && *d.Schema.MultipleFinalValue
+		if len(items) != len(fields) && !(multiple && len(fields) > 0 && len(items) >= len(fields)) {
+			return nil, fmt.Errorf("%s: expected %d record fields, got %d", d.OptionName(), len(fields), len(items))
+		}
+		values := []interface{}{}
+		for i, item := range items {
+			f := fields[len(fields)-1]
+			if i < len(fields) {
+				f = fields[i]
+			}
+			v, err := parseItem(f.Type, item)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	default:
+		return parseItem(ItemType(d.Schema.Type), s)
+	}
+}
+
+// splitText splits comma-separated values, keeping commas within quotes.
+func splitText(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	items := []string{}
+	var b strings.Builder
+	quoted := false
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			b.WriteRune(r)
+		case r == ',' && !quoted:
+			items = append(items, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return append(items, strings.TrimSpace(b.String()))
+}
+
+// parseItem converts a single text value to the given item type.
+func parseItem(t ItemType, s string) (interface{}, error) {
+	s = strings.TrimSpace(s)
+	invalid := func() error { return fmt.Errorf("invalid %s value %q", t, s) }
+
+	switch t {
+	case ItemTypeIPv4Address:
+		a, err := netip.ParseAddr(s)
+		if err != nil || !a.Is4() {
+			return nil, invalid()
+		}
+		return a.String(), nil
+	case ItemTypeIPv6Address:
+		a, err := netip.ParseAddr(s)
+		if err != nil || !a.Is6() {
+			return nil, invalid()
+		}
+		return a.String(), nil
+	case ItemTypeIPv6Prefix:
+		p, err := netip.ParsePrefix(s)
+		if err != nil || !p.Addr().Is6() {
+			return nil, invalid()
+		}
+		return p.String(), nil
+	case ItemTypeFQDN:
+		name := unquote(s)
+		if !validFQDN(name) {
+			return nil, invalid()
+		}
+		return name, nil
+	case ItemTypeString, ItemTypeTuple, ItemTypePSID:
+		return unquote(s), nil
+	case ItemTypeBoolean:
+		switch strings.ToLower(s) {
+		case "true", "on", "1":
+			return true, nil
+		case "false", "off", "0":
+			return false, nil
+		}
+		return nil, invalid()
+	case ItemTypeInt8, ItemTypeInt16, ItemTypeInt32, ItemTypeUint8, ItemTypeUint16, ItemTypeUint32:
+		n, err := parseInt(t, s)
+		if err != nil {
+			return nil, invalid()
+		}
+		return n, nil
+	case ItemTypeBinary:
+		b, err := hex.DecodeString(strings.NewReplacer(":", "", " ", "").Replace(unquote(s)))
+		if err != nil {
+			return nil, invalid()
+		}
+		return hex.EncodeToString(b), nil
+	case ItemTypeEmpty:
+		if s != "" {
+			return nil, invalid()
+		}
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown item type %q", t)
+	}
+}
+
+// parseInt parses an integer within the range of an integer item type.
+func parseInt(t ItemType, s string) (int, error) {
+	bits := map[ItemType]int{
+		ItemTypeInt8: 8, ItemTypeInt16: 16, ItemTypeInt32: 32,
+		ItemTypeUint8: 8, ItemTypeUint16: 16, ItemTypeUint32: 32,
+	}[t]
+	if strings.HasPrefix(string(t), "uint") {
+		n, err := strconv.ParseUint(s, 10, bits)
+		return int(n), err
+	}
+	n, err := strconv.ParseInt(s, 10, bits)
+	return int(n), err
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// validFQDN reports whether s is a valid domain name; the trailing dot is
+// optional. The root is written ".".
+func validFQDN(s string) bool {
+	if s == "." {
+		return true
+	}
+	s = strings.TrimSuffix(s, ".")
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(s, ".") {
+		if len(label) == 0 || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
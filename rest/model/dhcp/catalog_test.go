@@ -0,0 +1,73 @@
+package dhcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionCatalog(t *testing.T) {
+	space := SpaceV4
+	c := NewOptionCatalog(OptionDef{Space: &space, FriendlyName: "site-routers", Code: 3, Schema: OptionDefSchema{Type: SchemaTypeString}})
+
+	d, ok := c.Lookup("dhcpv4/routers")
+	require.True(t, ok)
+	assert.Equal(t, 3, d.Code)
+	d, ok = c.LookupCode(SpaceV4, 3)
+	require.True(t, ok)
+	assert.Equal(t, "dhcpv4/site-routers", d.OptionName())
+	d, ok = c.LookupCode(SpaceV6, 23)
+	require.True(t, ok)
+	assert.Equal(t, "dhcpv6/dns-servers", d.OptionName())
+	_, ok = c.Lookup("dhcpv4/nope")
+	assert.False(t, ok)
+	assert.Contains(t, c.Names(), "dhcpv6/bootfile-url")
+
+	assert.Equal(t, "dhcpv4/x", (&OptionDef{FriendlyName: "x"}).OptionName())
+	assert.Equal(t, len(standardOptions), len(StandardOptionDefs()))
+}
+
+func TestOptionDef_ParseText(t *testing.T) {
+	items := string(ItemTypeIPv4Address)
+	multiple := true
+	defs := map[string]*OptionDef{
+		"array":  {FriendlyName: "a", Schema: OptionDefSchema{Type: SchemaTypeArray, Items: &items}},
+		"string": {FriendlyName: "s", Schema: OptionDefSchema{Type: SchemaTypeString}},
+		"fqdn":   {FriendlyName: "f", Schema: OptionDefSchema{Type: SchemaTypeFQDN}},
+		"uint16": {FriendlyName: "u", Schema: OptionDefSchema{Type: SchemaTypeUint16}},
+		"bool":   {FriendlyName: "b", Schema: OptionDefSchema{Type: SchemaTypeBoolean}},
+		"binary": {FriendlyName: "h", Schema: OptionDefSchema{Type: SchemaTypeBinary}},
+		"record": {FriendlyName: "r", Schema: OptionDefSchema{Type: SchemaTypeRecord, MultipleFinalValue: &multiple,
+			Fields: []OptionDefSchemaItems{{Name: "n", Type: ItemTypeUint8}, {Name: "ip", Type: ItemTypeIPv4Address}}}},
+	}
+
+	for _, tc := range []struct {
+		def, text string
+		want      interface{}
+	}{
+		{"array", "192.0.2.1, 192.0.2.2", []interface{}{"192.0.2.1", "192.0.2.2"}},
+		{"string", `"a, b"`, "a, b"},
+		{"fqdn", "example.com.", "example.com."},
+		{"uint16", "8080", 8080},
+		{"bool", "on", true},
+		{"binary", "0A:0b:0C", "0a0b0c"},
+		{"record", "1, 192.0.2.1, 192.0.2.2", []interface{}{1, "192.0.2.1", "192.0.2.2"}},
+	} {
+		v, err := defs[tc.def].ParseText(tc.text)
+		require.NoError(t, err, tc.def)
+		assert.Equal(t, tc.want, v, tc.def)
+	}
+
+	for _, tc := range []struct{ def, text string }{
+		{"array", "192.0.2.1, 2001:db8::1"},
+		{"fqdn", "exa mple.com"},
+		{"uint16", "70000"},
+		{"bool", "maybe"},
+		{"binary", "0g"},
+		{"record", "1"},
+	} {
+		_, err := defs[tc.def].ParseText(tc.text)
+		assert.Error(t, err, tc.def)
+	}
+}
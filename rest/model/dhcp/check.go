@@ -0,0 +1,276 @@
+package dhcp
+
+import (
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ProblemKind is a kind of problem found by CheckReservations.
+type ProblemKind string
+
+// List of the problems found by CheckReservations.
+const (
+	// ProblemUnknownAddress is a scope or reservation whose address is
+	// unknown or invalid.
+	ProblemUnknownAddress ProblemKind = "unknown-address"
+	// ProblemOutsideScopes is a reservation outside the subnets served by
+	// the scopes of its scope group, which is never offered.
+	ProblemOutsideScopes ProblemKind = "outside-scopes"
+	// ProblemInDynamicPool is a reservation within the pool of a scope,
+	// whose address may be leased to another client.
+	ProblemInDynamicPool ProblemKind = "in-dynamic-pool"
+	// ProblemDuplicateAddress is an address reserved more than once.
+	ProblemDuplicateAddress ProblemKind = "duplicate-address"
+	// ProblemInvalidMAC is a reservation whose hardware address is invalid.
+	ProblemInvalidMAC ProblemKind = "invalid-mac"
+	// ProblemDuplicateMAC is a hardware address reserved more than once.
+	ProblemDuplicateMAC ProblemKind = "duplicate-mac"
+	// ProblemDuplicateIdentifier is a client identifier reserved more than
+	// once.
+	ProblemDuplicateIdentifier ProblemKind = "duplicate-identifier"
+	// ProblemOverlappingScopes is scopes whose pools overlap.
+	ProblemOverlappingScopes ProblemKind = "overlapping-scopes"
+)
+
+// Problem is a problem with scopes and reservations.
+type Problem struct {
+	Kind         ProblemKind
+	Scopes       []*Scope
+	Reservations []*Reservation
+	// Detail is the address, hardware address or identifier at fault.
+	Detail string
+}
+
+func (p *Problem) String() string {
+	objects := []string{}
+	for _, sc := range p.Scopes {
+		objects = append(objects, "scope "+scopeLabel(sc))
+	}
+	for _, r := range p.Reservations {
+		objects = append(objects, "reservation "+reservationLabel(r))
+	}
+	s := string(p.Kind)
+	if p.Detail != "" {
+		s += " " + p.Detail
+	}
+	return s + ": " + strings.Join(objects, ", ")
+}
+
+func scopeLabel(sc *Scope) string {
+	if sc.ID != 0 {
+		return strconv.Itoa(sc.ID)
+	}
+	if sc.AddressDetails != nil {
+		return sc.AddressDetails.Prefix
+	}
+	return "?"
+}
+
+func reservationLabel(r *Reservation) string {
+	if r.ID != nil {
+		return strconv.Itoa(*r.ID)
+	}
+	if r.AddressDetails != nil {
+		return r.AddressDetails.Prefix
+	}
+	return "?"
+}
+
+// Placement locates the IPAM address of a scope or reservation.
+type Placement struct {
+	Network int
+	Prefix  netip.Prefix
+	// Subnet is the subnet holding the pool of a scope, if known.
+	Subnet netip.Prefix
+}
+
+// CheckReservations checks reservations against the scopes, returning the
+// problems found, grouped by kind. Placements, by IPAM address ID, give the
+// network and prefix of scopes and reservations; those missing from it are
+// located with their AddressDetails, in an unknown network.
+//
+// A reservation must lie within the subnet of a scope of its scope group,
+// or within the pool of the scope when the subnet is unknown, but not
+// within the pool of any scope. Hardware addresses and identifiers are
+// compared in normalized form, and must be unique within a scope group
+// for each of DHCPv4 and DHCPv6.
+func CheckReservations(scopes []Scope, reservations []Reservation, placements map[int]Placement) []*Problem {
+	c := &checker{byKind: map[ProblemKind][]*Problem{}}
+
+	type placedScope struct {
+		*Scope
+		Placement
+	}
+	placed := []placedScope{}
+	for i := range scopes {
+		sc := &scopes[i]
+		p, ok := locate(sc.IDAddress, sc.AddressDetails, placements)
+		if !ok {
+			c.add(&Problem{Kind: ProblemUnknownAddress, Scopes: []*Scope{sc}})
+			continue
+		}
+		for _, other := range placed {
+			if sameNetwork(p.Network, other.Network) && p.Prefix.Overlaps(other.Prefix) {
+				c.add(&Problem{Kind: ProblemOverlappingScopes, Scopes: []*Scope{other.Scope, sc}, Detail: p.Prefix.String()})
+			}
+		}
+		placed = append(placed, placedScope{sc, p})
+	}
+
+	// Reservations by the address, hardware address or identifier they
+	// hold, to report duplicates in the order they are first found.
+	type held struct {
+		kind        ProblemKind
+		key, detail string
+	}
+	holders := map[held][]*Reservation{}
+	var order []held
+	hold := func(h held, r *Reservation) {
+		if holders[h] == nil {
+			order = append(order, h)
+		}
+		holders[h] = append(holders[h], r)
+	}
+	for i := range reservations {
+		r := &reservations[i]
+		p, ok := locate(r.IDAddress, r.AddressDetails, placements)
+		if !ok {
+			c.add(&Problem{Kind: ProblemUnknownAddress, Reservations: []*Reservation{r}})
+		} else {
+			addr := p.Prefix.Addr()
+			covered := false
+			for _, sc := range placed {
+				if !sameNetwork(p.Network, sc.Network) {
+					continue
+				}
+				if sc.Prefix.Contains(addr) {
+					c.add(&Problem{Kind: ProblemInDynamicPool, Scopes: []*Scope{sc.Scope}, Reservations: []*Reservation{r}, Detail: addr.String()})
+				}
+				subnet := sc.Subnet
+				if !subnet.IsValid() {
+					subnet = sc.Prefix
+				}
+				covered = covered || sameScopeGroup(r.IDScopeGroup, sc.IDScopeGroup) && subnet.Contains(addr)
+			}
+			if !covered {
+				c.add(&Problem{Kind: ProblemOutsideScopes, Reservations: []*Reservation{r}, Detail: addr.String()})
+			}
+			hold(held{ProblemDuplicateAddress, strconv.Itoa(p.Network), addr.String()}, r)
+		}
+
+		group := "-"
+		if r.IDScopeGroup != nil {
+			group = strconv.Itoa(*r.IDScopeGroup)
+		}
+		family := "v4"
+		if r.DHCPv6 != nil && *r.DHCPv6 {
+			family = "v6"
+		}
+		scope := group + " " + family
+
+		// A hardware address given as both Mac and identifier counts once.
+		var mac string
+		if r.Mac != "" {
+			normalized, err := NormalizeMAC(r.Mac)
+			if err != nil {
+				c.add(&Problem{Kind: ProblemInvalidMAC, Reservations: []*Reservation{r}, Detail: r.Mac})
+			} else {
+				mac = normalized
+				hold(held{ProblemDuplicateMAC, scope, mac}, r)
+			}
+		}
+		if id := r.Identifier; id != nil && id.Value != "" {
+			value := normalizeIdentifier(id)
+			if id.Type == HWAddressType {
+				if value == mac {
+					continue
+				}
+				if _, err := NormalizeMAC(id.Value); err != nil {
+					c.add(&Problem{Kind: ProblemInvalidMAC, Reservations: []*Reservation{r}, Detail: id.Value})
+					continue
+				}
+				hold(held{ProblemDuplicateMAC, scope, value}, r)
+				continue
+			}
+			hold(held{ProblemDuplicateIdentifier, scope, string(id.Type) + "=" + value}, r)
+		}
+	}
+
+	for _, h := range order {
+		if rs := holders[h]; len(rs) > 1 {
+			c.add(&Problem{Kind: h.kind, Reservations: rs, Detail: h.detail})
+		}
+	}
+
+	return c.problems()
+}
+
+type checker struct {
+	byKind map[ProblemKind][]*Problem
+}
+
+func (c *checker) add(p *Problem) {
+	c.byKind[p.Kind] = append(c.byKind[p.Kind], p)
+}
+
+func (c *checker) problems() []*Problem {
+	kinds := make([]string, 0, len(c.byKind))
+	for k := range c.byKind {
+		kinds = append(kinds, string(k))
+	}
+	sort.Strings(kinds)
+	problems := []*Problem{}
+	for _, k := range kinds {
+		problems = append(problems, c.byKind[ProblemKind(k)]...)
+	}
+	return problems
+}
+
+// locate returns the placement of an address, by ID or else by details.
+func locate(id *int, details *AddressDetails, placements map[int]Placement) (Placement, bool) {
+	if id != nil {
+		if p, ok := placements[*id]; ok {
+			return p, true
+		}
+	}
+	if details == nil || details.Prefix == "" {
+		return Placement{}, false
+	}
+	prefix, err := netip.ParsePrefix(details.Prefix)
+	if err != nil {
+		addr, err := netip.ParseAddr(details.Prefix)
+		if err != nil {
+			return Placement{}, false
+		}
+		prefix = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	return Placement{Prefix: prefix.Masked()}, true
+}
+
+// sameNetwork tells whether two networks may be the same, 0 being an
+// unknown network.
+func sameNetwork(a, b int) bool {
+	return a == 0 || b == 0 || a == b
+}
+
+func sameScopeGroup(a, b *int) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// normalizeIdentifier returns the value of an identifier in a comparable
+// form: hardware addresses as by NormalizeMAC, and hex identifiers in lower
+// case without separators.
+func normalizeIdentifier(id *Identifier) string {
+	switch id.Type {
+	case HWAddressType:
+		if mac, err := NormalizeMAC(id.Value); err == nil {
+			return mac
+		}
+		return id.Value
+	case DUIDType, ClientIDType:
+		return strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "", " ", "").Replace(id.Value))
+	}
+	return id.Value
+}
@@ -0,0 +1,61 @@
+package dhcp
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckReservations(t *testing.T) {
+	id := func(i int) *int { return &i }
+	v6 := true
+	placements := map[int]Placement{
+		1:  {Network: 1, Prefix: netip.MustParsePrefix("10.0.0.128/25"), Subnet: netip.MustParsePrefix("10.0.0.0/24")},
+		2:  {Network: 1, Prefix: netip.MustParsePrefix("10.0.0.192/26"), Subnet: netip.MustParsePrefix("10.0.0.0/24")},
+		3:  {Network: 2, Prefix: netip.MustParsePrefix("10.0.0.128/25"), Subnet: netip.MustParsePrefix("10.0.0.0/24")},
+		10: {Network: 1, Prefix: netip.MustParsePrefix("10.0.0.10/32")},
+		11: {Network: 1, Prefix: netip.MustParsePrefix("10.0.0.130/32")},
+		12: {Network: 1, Prefix: netip.MustParsePrefix("10.0.1.10/32")},
+	}
+	scopes := []Scope{
+		{ID: 1, IDAddress: id(1), IDScopeGroup: id(1)},
+		{ID: 2, IDAddress: id(2), IDScopeGroup: id(1)},
+		{ID: 3, IDAddress: id(3), IDScopeGroup: id(2)},
+		{ID: 4, IDScopeGroup: id(1)},
+		{ID: 5, IDScopeGroup: id(2), AddressDetails: &AddressDetails{Prefix: "2001:db8::/120"}},
+	}
+	reservations := []Reservation{
+		{ID: id(1), IDAddress: id(10), IDScopeGroup: id(1), Mac: "00:1A:2B:3C:4D:5E",
+			Identifier: &Identifier{Type: HWAddressType, Value: "001a.2b3c.4d5e"}},
+		{ID: id(2), IDAddress: id(11), IDScopeGroup: id(1), Mac: "00-1a-2b-3c-4d-5e"},
+		{ID: id(3), IDAddress: id(12), IDScopeGroup: id(1), Mac: "bad"},
+		{ID: id(4), IDAddress: id(10), IDScopeGroup: id(1),
+			Identifier: &Identifier{Type: ClientIDType, Value: "01:AA:BB"}},
+		{ID: id(5), IDScopeGroup: id(2), AddressDetails: &AddressDetails{Prefix: "10.0.0.5"},
+			Identifier: &Identifier{Type: ClientIDType, Value: "01aabb"}},
+		{ID: id(6), IDScopeGroup: id(2), AddressDetails: &AddressDetails{Prefix: "10.0.0.6/32"},
+			Identifier: &Identifier{Type: ClientIDType, Value: "01-aa-bb"}},
+		{ID: id(7), IDScopeGroup: id(2), DHCPv6: &v6, AddressDetails: &AddressDetails{Prefix: "2001:db8::1:1"},
+			Identifier: &Identifier{Type: DUIDType, Value: "00:01"}},
+		{ID: id(8)},
+	}
+
+	problems := CheckReservations(scopes, reservations, placements)
+	got := []string{}
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{
+		"duplicate-address 10.0.0.10: reservation 1, reservation 4",
+		"duplicate-identifier client-id=01aabb: reservation 5, reservation 6",
+		"duplicate-mac 00:1a:2b:3c:4d:5e: reservation 1, reservation 2",
+		"in-dynamic-pool 10.0.0.130: scope 1, reservation 2",
+		"invalid-mac bad: reservation 3",
+		"outside-scopes 10.0.1.10: reservation 3",
+		"outside-scopes 2001:db8::1:1: reservation 7",
+		"overlapping-scopes 10.0.0.192/26: scope 1, scope 2",
+		"unknown-address: scope 4",
+		"unknown-address: reservation 8",
+	}, got)
+}
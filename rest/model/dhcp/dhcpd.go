@@ -0,0 +1,524 @@
+package dhcp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// dhcpdOptionNames maps ISC dhcpd option names that differ from the Kea
+// and NS1 ones. DHCPv6 names lose their "dhcp6." prefix before lookup.
+var dhcpdOptionNames = map[string]string{
+	"bootfile-name":               "boot-file-name",
+	"dhcp6.name-servers":          "dns-servers",
+	"dhcp6.sip-servers-names":     "sip-server-dns",
+	"dhcp6.sip-servers-addresses": "sip-server-addr",
+	"dhcp6.info-refresh-time":     "information-refresh-time",
+	"dhcp6.nis-servers":           "nis-servers",
+}
+
+// dhcpdStatement is a parsed dhcpd.conf statement, with its block if any.
+type dhcpdStatement struct {
+	line  int
+	words []string
+	block []*dhcpdStatement
+}
+
+// dhcpdContext holds what a dhcpd.conf block inherits from the enclosing
+// ones.
+type dhcpdContext struct {
+	where      string
+	options    OptionSet
+	lease      *int
+	nextServer string
+	filename   string
+	serverName string
+}
+
+// ParseDhcpd converts an ISC dhcpd.conf configuration into a Migration
+// for a scope group of the given name. Options are mapped with the given
+// catalog, which may be nil to only map standard options.
+//
+// Global settings and options go to the scope group; shared-network and
+// group declarations are flattened, their options being inherited by the
+// subnets and hosts they hold. Each range of a subnet becomes a scope, and
+// each host with a fixed address a reservation.
+func ParseDhcpd(r io.Reader, name string, catalog *OptionCatalog) (*Migration, error) {
+	if catalog == nil {
+		catalog = NewOptionCatalog()
+	}
+	stmts, err := parseDhcpdStatements(r)
+	if err != nil {
+		return nil, err
+	}
+
+	m := newMigration(name)
+	global := &dhcpdContext{where: "global"}
+	var v4, v6 bool
+	for _, st := range stmts {
+		switch st.words[0] {
+		case "subnet", "subnet6", "shared-network", "group", "host":
+			isV6, err := m.dhcpdDeclaration(catalog, global, st)
+			if err != nil {
+				return nil, err
+			}
+			v4, v6 = v4 || !isV6 && st.words[0] != "host", v6 || isV6
+			continue
+		}
+		if err := m.dhcpdSetting(catalog, global, st); err != nil {
+			return nil, err
+		}
+	}
+
+	sg := m.ScopeGroup
+	for _, o := range global.options {
+		if strings.HasPrefix(o.Name, SpaceV6+"/") {
+			sg.DHCP6.Options = append(sg.DHCP6.Options, o)
+			v6 = true
+		} else {
+			sg.DHCP4.Options = append(sg.DHCP4.Options, o)
+		}
+	}
+	if v4 || !v6 {
+		enabled := true
+		sg.DHCP4.Enabled = &enabled
+		sg.DHCP4.ValidLifetimeSecs = global.lease
+		if global.nextServer != "" {
+			sg.DHCP4.NextServer = &global.nextServer
+		}
+		if global.filename != "" {
+			sg.DHCP4.BootFileName = &global.filename
+		}
+	}
+	if v6 {
+		enabled := true
+		sg.DHCP6.Enabled = &enabled
+		sg.DHCP6.ValidLifetimeSecs = global.lease
+	}
+	return m, nil
+}
+
+// dhcpdSetting applies a statement that is not a declaration to ctx.
+func (m *Migration) dhcpdSetting(c *OptionCatalog, ctx *dhcpdContext, st *dhcpdStatement) error {
+	w := st.words
+	arg := func() (string, error) {
+		if len(w) < 2 {
+			return "", fmt.Errorf("line %d: %s needs a value", st.line, w[0])
+		}
+		return unquote(strings.Join(w[1:], " ")), nil
+	}
+
+	switch w[0] {
+	case "option":
+		if len(w) < 3 {
+			return fmt.Errorf("line %d: option needs a name and value", st.line)
+		}
+		if w[1] == "space" || len(w) >= 4 && w[2] == "code" {
+			m.ignore(ctx.where, "line %d: option definition %q must be created with the option definitions API", st.line, strings.Join(w[1:], " "))
+			return nil
+		}
+		space, name := SpaceV4, w[1]
+		if strings.HasPrefix(name, "dhcp6.") {
+			space = SpaceV6
+		}
+		if mapped, ok := dhcpdOptionNames[name]; ok {
+			name = mapped
+		}
+		name = strings.TrimPrefix(name, "dhcp6.")
+		if o, ok := m.option(c, ctx.where, space, name, 0, strings.Join(w[2:], " ")); ok {
+			ctx.options = mergeOptions(ctx.options, OptionSet{o})
+		}
+	case "default-lease-time":
+		s, err := arg()
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid lease time %q", st.line, s)
+		}
+		ctx.lease = &n
+	case "next-server":
+		s, err := arg()
+		if err != nil {
+			return err
+		}
+		ctx.nextServer = s
+	case "filename":
+		s, err := arg()
+		if err != nil {
+			return err
+		}
+		ctx.filename = s
+	case "server-name":
+		s, err := arg()
+		if err != nil {
+			return err
+		}
+		ctx.serverName = s
+	default:
+		m.ignore(ctx.where, "line %d: %s", st.line, strings.Join(w, " "))
+	}
+	return nil
+}
+
+// dhcpdDeclaration converts a subnet, shared-network, group or host
+// declaration, reporting whether it declares DHCPv6 subnets.
+func (m *Migration) dhcpdDeclaration(c *OptionCatalog, parent *dhcpdContext, st *dhcpdStatement) (bool, error) {
+	w := st.words
+	ctx := *parent
+	ctx.options = append(OptionSet{}, parent.options...)
+	if parent.where == "global" {
+		// Global settings and options belong to the scope group.
+		ctx = dhcpdContext{where: parent.where, options: OptionSet{}}
+	}
+
+	switch w[0] {
+	case "subnet":
+		if len(w) != 4 || w[2] != "netmask" {
+			return false, fmt.Errorf("line %d: expected \"subnet <address> netmask <mask>\"", st.line)
+		}
+		addr, err := netip.ParseAddr(w[1])
+		if err != nil {
+			return false, fmt.Errorf("line %d: invalid subnet address %q", st.line, w[1])
+		}
+		mask, err := netip.ParseAddr(w[3])
+		if err != nil || !mask.Is4() {
+			return false, fmt.Errorf("line %d: invalid netmask %q", st.line, w[3])
+		}
+		bits := 0
+		for _, b := range mask.AsSlice() {
+			for ; b&0x80 != 0; b <<= 1 {
+				bits++
+			}
+		}
+		if netip.PrefixFrom(mask, bits).Masked().Addr() != mask {
+			return false, fmt.Errorf("line %d: invalid netmask %q", st.line, w[3])
+		}
+		prefix := netip.PrefixFrom(addr, bits).Masked()
+		return false, m.dhcpdSubnet(c, &ctx, st, prefix, false)
+	case "subnet6":
+		if len(w) != 2 {
+			return false, fmt.Errorf("line %d: expected \"subnet6 <prefix>\"", st.line)
+		}
+		prefix, err := netip.ParsePrefix(w[1])
+		if err != nil {
+			return false, fmt.Errorf("line %d: invalid subnet6 prefix %q", st.line, w[1])
+		}
+		return true, m.dhcpdSubnet(c, &ctx, st, prefix.Masked(), true)
+	case "host":
+		return false, m.dhcpdHost(c, &ctx, st)
+	default:
+		if w[0] == "shared-network" && len(w) == 2 {
+			ctx.where = "shared-network " + unquote(w[1])
+		} else if w[0] == "group" {
+			ctx.where = fmt.Sprintf("group at line %d", st.line)
+		}
+		isV6 := false
+		// Settings apply to the whole block, wherever they appear in it.
+		for _, sub := range st.block {
+			if !isDhcpdDeclaration(sub) {
+				if err := m.dhcpdSetting(c, &ctx, sub); err != nil {
+					return false, err
+				}
+			}
+		}
+		for _, sub := range st.block {
+			if isDhcpdDeclaration(sub) {
+				v6, err := m.dhcpdDeclaration(c, &ctx, sub)
+				if err != nil {
+					return false, err
+				}
+				isV6 = isV6 || v6
+			}
+		}
+		return isV6, nil
+	}
+}
+
+func isDhcpdDeclaration(st *dhcpdStatement) bool {
+	switch st.words[0] {
+	case "subnet", "subnet6", "shared-network", "group", "host":
+		return true
+	}
+	return false
+}
+
+func (m *Migration) dhcpdSubnet(c *OptionCatalog, ctx *dhcpdContext, st *dhcpdStatement, prefix netip.Prefix, v6 bool) error {
+	ctx.where = "subnet " + prefix.String()
+	m.Subnets = append(m.Subnets, prefix.String())
+
+	type pool struct {
+		ranges []string
+		ctx    *dhcpdContext
+	}
+	pools := []*pool{}
+	subnetPool := &pool{ctx: &dhcpdContext{}}
+	hosts := []*dhcpdStatement{}
+
+	rangeKeyword := "range"
+	if v6 {
+		rangeKeyword = "range6"
+	}
+	addRange := func(p *pool, sub *dhcpdStatement) error {
+		words := sub.words[1:]
+		if len(words) > 0 && words[0] == "dynamic-bootp" {
+			words = words[1:]
+		}
+		if len(words) == 0 || len(words) > 2 {
+			return fmt.Errorf("line %d: invalid %s", sub.line, sub.words[0])
+		}
+		p.ranges = append(p.ranges, strings.Join(words, " - "))
+		return nil
+	}
+
+	for _, sub := range st.block {
+		switch sub.words[0] {
+		case rangeKeyword:
+			if err := addRange(subnetPool, sub); err != nil {
+				return err
+			}
+		case "pool", "pool6":
+			p := &pool{ctx: &dhcpdContext{where: fmt.Sprintf("%s pool at line %d", ctx.where, sub.line)}}
+			for _, ps := range sub.block {
+				if ps.words[0] == rangeKeyword {
+					if err := addRange(p, ps); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := m.dhcpdSetting(c, p.ctx, ps); err != nil {
+					return err
+				}
+			}
+			pools = append(pools, p)
+		case "host":
+			hosts = append(hosts, sub)
+		default:
+			if isDhcpdDeclaration(sub) {
+				m.ignore(ctx.where, "line %d: %s within a subnet", sub.line, sub.words[0])
+				continue
+			}
+			if err := m.dhcpdSetting(c, ctx, sub); err != nil {
+				return err
+			}
+		}
+	}
+	pools = append([]*pool{subnetPool}, pools...)
+
+	for _, p := range pools {
+		for _, r := range p.ranges {
+			prefixes, err := parseRange(r)
+			if err != nil {
+				return fmt.Errorf("%s: %w", ctx.where, err)
+			}
+			for _, pp := range prefixes {
+				if !prefix.Contains(pp.Addr()) {
+					return fmt.Errorf("%s: range %s lies outside the subnet", ctx.where, r)
+				}
+				m.Scopes = append(m.Scopes, dhcpdScope(pp, ctx, p.ctx))
+			}
+		}
+	}
+
+	for _, h := range hosts {
+		if err := m.dhcpdHost(c, ctx, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dhcpdScope returns the scope of a pool prefix, with the settings of the
+// pool overriding those of the subnet.
+func dhcpdScope(prefix netip.Prefix, subnet, pool *dhcpdContext) *Scope {
+	pick := func(subnet, pool string) *string {
+		if pool != "" {
+			return &pool
+		}
+		if subnet != "" {
+			return &subnet
+		}
+		return nil
+	}
+
+	sc := &Scope{
+		AddressDetails:    &AddressDetails{Prefix: prefix.String()},
+		Options:           mergeOptions(subnet.options, pool.options),
+		ValidLifetimeSecs: subnet.lease,
+		NextServer:        pick(subnet.nextServer, pool.nextServer),
+		BootFileName:      pick(subnet.filename, pool.filename),
+		ServerHostname:    pick(subnet.serverName, pool.serverName),
+	}
+	if pool.lease != nil {
+		sc.ValidLifetimeSecs = pool.lease
+	}
+	return sc
+}
+
+func (m *Migration) dhcpdHost(c *OptionCatalog, parent *dhcpdContext, st *dhcpdStatement) error {
+	if len(st.words) != 2 {
+		return fmt.Errorf("line %d: expected \"host <name>\"", st.line)
+	}
+	name := unquote(st.words[1])
+	ctx := *parent
+	ctx.where = "host " + name
+	ctx.options = OptionSet{}
+
+	res := &Reservation{AddressDetails: &AddressDetails{Name: name}}
+	for _, sub := range st.block {
+		w := sub.words
+		switch {
+		case w[0] == "hardware" && len(w) == 3:
+			mac, err := NormalizeMAC(w[2])
+			if err != nil {
+				return fmt.Errorf("line %d: %w", sub.line, err)
+			}
+			res.Mac = mac
+			if res.Identifier == nil {
+				res.Identifier = &Identifier{Type: HWAddressType, Value: mac}
+			}
+		case (w[0] == "fixed-address" || w[0] == "fixed-address6") && len(w) >= 2:
+			addr := strings.TrimSuffix(w[1], ",")
+			if _, err := netip.ParseAddr(addr); err != nil {
+				m.ignore(ctx.where, "line %d: fixed address %q is not an IP address", sub.line, addr)
+				continue
+			}
+			if len(w) > 2 {
+				m.ignore(ctx.where, "line %d: only the first fixed address %s is reserved", sub.line, addr)
+			}
+			res.AddressDetails.Prefix = addr
+			if w[0] == "fixed-address6" {
+				v6 := true
+				res.DHCPv6 = &v6
+			}
+		case w[0] == "host-identifier" && len(w) == 4 && w[1] == "option":
+			idType := map[string]IdentifierType{
+				"dhcp6.client-id":        DUIDType,
+				"dhcp-client-identifier": ClientIDType,
+				"agent.circuit-id":       CircuitIDType,
+			}[w[2]]
+			if idType == "" {
+				m.ignore(ctx.where, "line %d: unsupported host identifier %s", sub.line, w[2])
+				continue
+			}
+			res.Identifier = &Identifier{Type: idType, Value: unquote(w[3])}
+		default:
+			if err := m.dhcpdSetting(c, &ctx, sub); err != nil {
+				return err
+			}
+		}
+	}
+
+	if res.AddressDetails.Prefix == "" {
+		m.ignore(ctx.where, "line %d: host without fixed address", st.line)
+		return nil
+	}
+	if res.Identifier == nil {
+		m.ignore(ctx.where, "line %d: host without hardware address or identifier", st.line)
+		return nil
+	}
+	res.Options = mergeOptions(parent.options, ctx.options)
+	res.NextServer = ctx.nextServer
+	res.BootFileName = ctx.filename
+	res.ServerHostname = ctx.serverName
+	m.Reservations = append(m.Reservations, res)
+	return nil
+}
+
+// parseDhcpdStatements parses dhcpd.conf into statements.
+func parseDhcpdStatements(r io.Reader) ([]*dhcpdStatement, error) {
+	type token struct {
+		text string
+		line int
+	}
+	tokens := []token{}
+
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := s.Text()
+		for i := 0; i < len(text); {
+			ch := text[i]
+			switch {
+			case ch == '#':
+				i = len(text)
+			case ch == ' ' || ch == '\t' || ch == '\r':
+				i++
+			case ch == ';' || ch == '{' || ch == '}':
+				tokens = append(tokens, token{string(ch), line})
+				i++
+			case ch == '"':
+				end := strings.IndexByte(text[i+1:], '"')
+				if end < 0 {
+					return nil, fmt.Errorf("line %d: unterminated string", line)
+				}
+				tokens = append(tokens, token{text[i : i+end+2], line})
+				i += end + 2
+			default:
+				j := i
+				for j < len(text) && !strings.ContainsRune(" \t\r;{}\"#", rune(text[j])) {
+					j++
+				}
+				tokens = append(tokens, token{text[i:j], line})
+				i = j
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	var parse func(pos int, nested bool) ([]*dhcpdStatement, int, error)
+	parse = func(pos int, nested bool) ([]*dhcpdStatement, int, error) {
+		stmts := []*dhcpdStatement{}
+		var cur *dhcpdStatement
+		for pos < len(tokens) {
+			t := tokens[pos]
+			pos++
+			switch t.text {
+			case ";":
+				if cur != nil {
+					stmts = append(stmts, cur)
+					cur = nil
+				}
+			case "{":
+				if cur == nil {
+					return nil, 0, fmt.Errorf("line %d: block without declaration", t.line)
+				}
+				block, next, err := parse(pos, true)
+				if err != nil {
+					return nil, 0, err
+				}
+				cur.block, pos = block, next
+				stmts = append(stmts, cur)
+				cur = nil
+			case "}":
+				if !nested {
+					return nil, 0, fmt.Errorf("line %d: unexpected }", t.line)
+				}
+				if cur != nil {
+					return nil, 0, fmt.Errorf("line %d: missing ; after %q", cur.line, strings.Join(cur.words, " "))
+				}
+				return stmts, pos, nil
+			default:
+				if cur == nil {
+					cur = &dhcpdStatement{line: t.line}
+				}
+				cur.words = append(cur.words, t.text)
+			}
+		}
+		if nested {
+			return nil, 0, fmt.Errorf("missing }")
+		}
+		if cur != nil {
+			return nil, 0, fmt.Errorf("line %d: missing ; after %q", cur.line, strings.Join(cur.words, " "))
+		}
+		return stmts, pos, nil
+	}
+
+	stmts, _, err := parse(0, false)
+	return stmts, err
+}
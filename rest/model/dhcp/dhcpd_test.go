@@ -0,0 +1,135 @@
+package dhcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDhcpdConf = `
+# global settings
+option domain-name "example.com";
+option domain-name-servers 192.0.2.53, 192.0.2.54;
+option space vendor;
+option vendor.mode code 1 = unsigned integer 8;
+default-lease-time 3600;
+authoritative;
+
+subnet 192.0.2.0 netmask 255.255.255.0 {
+	option routers 192.0.2.1;
+	range 192.0.2.10 192.0.2.19;
+	option unknown-thing "x";
+}
+
+shared-network office {
+	option ntp-servers 198.51.100.123;
+	subnet 198.51.100.0 netmask 255.255.255.0 {
+		pool {
+			option routers 198.51.100.254;
+			range 198.51.100.128 198.51.100.255;
+		}
+	}
+}
+
+group {
+	filename "pxe.efi";
+	host printer {
+		hardware ethernet 00:1A:2B:3C:4D:5E;
+		fixed-address 192.0.2.50;
+		option host-name "printer";
+	}
+	host nowhere {
+		hardware ethernet 00:1a:2b:3c:4d:5f;
+	}
+}
+
+subnet6 2001:db8::/64 {
+	range6 2001:db8::100 2001:db8::1ff;
+	option dhcp6.name-servers 2001:db8::53;
+}
+`
+
+func TestParseDhcpd(t *testing.T) {
+	m, err := ParseDhcpd(strings.NewReader(testDhcpdConf), "office", nil)
+	require.NoError(t, err)
+
+	sg := m.ScopeGroup
+	assert.Equal(t, "office", sg.Name)
+	require.NotNil(t, sg.DHCP4.Enabled)
+	require.NotNil(t, sg.DHCP6.Enabled)
+	assert.Equal(t, 3600, *sg.DHCP4.ValidLifetimeSecs)
+	assert.Equal(t, OptionSet{
+		{Name: "dhcpv4/domain-name", Value: "example.com"},
+		{Name: "dhcpv4/domain-name-servers", Value: []interface{}{"192.0.2.53", "192.0.2.54"}},
+	}, sg.DHCP4.Options)
+
+	assert.Equal(t, []string{"192.0.2.0/24", "198.51.100.0/24", "2001:db8::/64"}, m.Subnets)
+
+	prefixes := []string{}
+	for _, sc := range m.Scopes {
+		prefixes = append(prefixes, sc.AddressDetails.Prefix)
+	}
+	assert.Equal(t, []string{
+		"192.0.2.10/31", "192.0.2.12/30", "192.0.2.16/30",
+		"198.51.100.128/25",
+		"2001:db8::100/120",
+	}, prefixes)
+	assert.Equal(t, OptionSet{{Name: "dhcpv4/routers", Value: []interface{}{"192.0.2.1"}}}, m.Scopes[0].Options)
+	assert.Equal(t, OptionSet{
+		{Name: "dhcpv4/ntp-servers", Value: []interface{}{"198.51.100.123"}},
+		{Name: "dhcpv4/routers", Value: []interface{}{"198.51.100.254"}},
+	}, m.Scopes[3].Options)
+	v6 := m.Scopes[len(m.Scopes)-1]
+	assert.Equal(t, "2001:db8::100/120", v6.AddressDetails.Prefix)
+	assert.Equal(t, OptionSet{{Name: "dhcpv6/dns-servers", Value: []interface{}{"2001:db8::53"}}}, v6.Options)
+
+	require.Len(t, m.Reservations, 1)
+	r := m.Reservations[0]
+	assert.Equal(t, "192.0.2.50", r.AddressDetails.Prefix)
+	assert.Equal(t, "printer", r.AddressDetails.Name)
+	assert.Equal(t, "00:1a:2b:3c:4d:5e", r.Mac)
+	assert.Equal(t, &Identifier{Type: HWAddressType, Value: "00:1a:2b:3c:4d:5e"}, r.Identifier)
+	assert.Equal(t, "pxe.efi", r.BootFileName)
+	assert.Equal(t, OptionSet{{Name: "dhcpv4/host-name", Value: "printer"}}, r.Options)
+
+	require.Len(t, m.Unmapped, 1)
+	assert.Equal(t, "unknown-thing", m.Unmapped[0].Name)
+	assert.Equal(t, "no known option definition", m.Unmapped[0].Reason)
+	assert.Contains(t, m.Unmapped[0].String(), "subnet 192.0.2.0/24")
+
+	ignored := strings.Join(m.Ignored, "\n")
+	assert.Contains(t, ignored, "option definition")
+	assert.Contains(t, ignored, "authoritative")
+	assert.Contains(t, ignored, "nowhere")
+}
+
+func TestParseDhcpd_Errors(t *testing.T) {
+	for name, conf := range map[string]string{
+		"unterminated block":  "subnet 192.0.2.0 netmask 255.255.255.0 {",
+		"unbalanced brace":    "}",
+		"bad netmask":         "subnet 192.0.2.0 netmask 255.0.255.0 { }",
+		"range outside":       "subnet 192.0.2.0 netmask 255.255.255.0 { range 198.51.100.1 198.51.100.2; }",
+		"bad mac":             "host a { hardware ethernet zz:zz; fixed-address 192.0.2.1; }",
+		"unnamed host":        "host { fixed-address 192.0.2.1; }",
+		"unnamed subnet host": "subnet 192.0.2.0 netmask 255.255.255.0 { host { fixed-address 192.0.2.5; } }",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseDhcpd(strings.NewReader(conf), "sg", nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNormalizeMAC(t *testing.T) {
+	for _, s := range []string{"00:1A:2B:3C:4D:5E", "00-1a-2b-3c-4d-5e", "001a.2b3c.4d5e", "001a2b3c4d5e", "0:1a:2b:3c:4d:5e"} {
+		mac, err := NormalizeMAC(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, "00:1a:2b:3c:4d:5e", mac, s)
+	}
+	for _, s := range []string{"", "00:1a:2b", "001a2b3c4d5", "00:1a:2b:3c:4d:5g"} {
+		_, err := NormalizeMAC(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,414 @@
+package dhcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/netip"
+	"strings"
+)
+
+// keaConfig is the part of a Kea configuration file that is converted.
+type keaConfig struct {
+	Dhcp4 *keaServer `json:"Dhcp4"`
+	Dhcp6 *keaServer `json:"Dhcp6"`
+}
+
+type keaServer struct {
+	keaParams
+
+	MatchClientID     *bool         `json:"match-client-id"`
+	PreferredLifetime *int          `json:"preferred-lifetime"`
+	OptionDef         []keaOption   `json:"option-def"`
+	Subnet4           []*keaSubnet  `json:"subnet4"`
+	Subnet6           []*keaSubnet  `json:"subnet6"`
+	SharedNetworks    []*keaNetwork `json:"shared-networks"`
+	Reservations      []*keaHost    `json:"reservations"`
+}
+
+// keaParams are the parameters inherited from the server by shared
+// networks and subnets.
+type keaParams struct {
+	ValidLifetime  *int        `json:"valid-lifetime"`
+	RenewTimer     *int        `json:"renew-timer"`
+	RebindTimer    *int        `json:"rebind-timer"`
+	OptionData     []keaOption `json:"option-data"`
+	NextServer     string      `json:"next-server"`
+	BootFileName   string      `json:"boot-file-name"`
+	ServerHostname string      `json:"server-hostname"`
+	ClientClass    *string     `json:"client-class"`
+	RequireClasses *[]string   `json:"require-client-classes"`
+	Relay          *struct {
+		IPAddresses []string `json:"ip-addresses"`
+	} `json:"relay"`
+}
+
+type keaNetwork struct {
+	keaParams
+
+	Name    string       `json:"name"`
+	Subnet4 []*keaSubnet `json:"subnet4"`
+	Subnet6 []*keaSubnet `json:"subnet6"`
+}
+
+type keaSubnet struct {
+	keaParams
+
+	Subnet       string     `json:"subnet"`
+	Pools        []keaPool  `json:"pools"`
+	PDPools      []keaPool  `json:"pd-pools"`
+	Reservations []*keaHost `json:"reservations"`
+}
+
+type keaPool struct {
+	Pool       string      `json:"pool"`
+	Prefix     string      `json:"prefix"`
+	OptionData []keaOption `json:"option-data"`
+}
+
+type keaOption struct {
+	Name       string `json:"name"`
+	Code       int    `json:"code"`
+	Space      string `json:"space"`
+	Data       string `json:"data"`
+	CSVFormat  *bool  `json:"csv-format"`
+	AlwaysSend *bool  `json:"always-send"`
+}
+
+type keaHost struct {
+	HWAddress      string      `json:"hw-address"`
+	DUID           string      `json:"duid"`
+	ClientID       string      `json:"client-id"`
+	CircuitID      string      `json:"circuit-id"`
+	IPAddress      string      `json:"ip-address"`
+	IPAddresses    []string    `json:"ip-addresses"`
+	Hostname       string      `json:"hostname"`
+	OptionData     []keaOption `json:"option-data"`
+	NextServer     string      `json:"next-server"`
+	BootFileName   string      `json:"boot-file-name"`
+	ServerHostname string      `json:"server-hostname"`
+	ClientClasses  []string    `json:"client-classes"`
+}
+
+// ParseKea converts a Kea DHCPv4 and/or DHCPv6 JSON configuration, which
+// may hold comments, into a Migration for a scope group of the given name.
+// Options are mapped with the given catalog, which may be nil to only map
+// standard options.
+//
+// Server parameters and options go to the scope group; shared networks
+// are flattened, their parameters and options being inherited by their
+// subnets. Each pool of a subnet becomes a scope, and each reservation
+// with an address a reservation. Prefix delegation pools are ignored.
+func ParseKea(r io.Reader, name string, catalog *OptionCatalog) (*Migration, error) {
+	if catalog == nil {
+		catalog = NewOptionCatalog()
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var conf keaConfig
+	if err := json.Unmarshal(stripJSONComments(b), &conf); err != nil {
+		return nil, fmt.Errorf("invalid Kea configuration: %w", err)
+	}
+	if conf.Dhcp4 == nil && conf.Dhcp6 == nil {
+		return nil, fmt.Errorf("Kea configuration has neither Dhcp4 nor Dhcp6")
+	}
+
+	m := newMigration(name)
+	sg := m.ScopeGroup
+	enabled := true
+	if srv := conf.Dhcp4; srv != nil {
+		m.keaOptionDefs(srv, "Dhcp4")
+		sg.DHCP4.Enabled = &enabled
+		m.keaSettings(&sg.DHCP4.Settings, &srv.keaParams, catalog, SpaceV4, "Dhcp4")
+		sg.DHCP4.MatchClientID = srv.MatchClientID
+		if srv.NextServer != "" {
+			sg.DHCP4.NextServer = &srv.NextServer
+		}
+		if srv.BootFileName != "" {
+			sg.DHCP4.BootFileName = &srv.BootFileName
+		}
+		if err := m.keaServer(catalog, srv, SpaceV4); err != nil {
+			return nil, err
+		}
+	}
+	if srv := conf.Dhcp6; srv != nil {
+		m.keaOptionDefs(srv, "Dhcp6")
+		sg.DHCP6.Enabled = &enabled
+		m.keaSettings(&sg.DHCP6.Settings, &srv.keaParams, catalog, SpaceV6, "Dhcp6")
+		sg.DHCP6.PreferredLifetimeSecs = srv.PreferredLifetime
+		if err := m.keaServer(catalog, srv, SpaceV6); err != nil {
+			return nil, err
+		}
+	}
+
+	return m, nil
+}
+
+func (m *Migration) keaOptionDefs(srv *keaServer, where string) {
+	for _, d := range srv.OptionDef {
+		m.ignore(where, "option definition %s (code %d) must be created with the option definitions API", d.Name, d.Code)
+	}
+}
+
+func (m *Migration) keaSettings(s *Settings, p *keaParams, c *OptionCatalog, space, where string) {
+	s.ValidLifetimeSecs = p.ValidLifetime
+	s.RenewTimerSecs = p.RenewTimer
+	s.RebindTimerSecs = p.RebindTimer
+	s.Options = m.keaOptions(c, where, space, p.OptionData)
+}
+
+func (m *Migration) keaServer(c *OptionCatalog, srv *keaServer, space string) error {
+	subnets := srv.Subnet4
+	if space == SpaceV6 {
+		subnets = srv.Subnet6
+	}
+	for _, sn := range subnets {
+		if err := m.keaSubnet(c, sn, nil, space); err != nil {
+			return err
+		}
+	}
+
+	for _, net := range srv.SharedNetworks {
+		subnets := net.Subnet4
+		if space == SpaceV6 {
+			subnets = net.Subnet6
+		}
+		for _, sn := range subnets {
+			if err := m.keaSubnet(c, sn, net, space); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, h := range srv.Reservations {
+		if err := m.keaHost(c, h, "global reservations", OptionSet{}, space); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Migration) keaSubnet(c *OptionCatalog, sn *keaSubnet, net *keaNetwork, space string) error {
+	prefix, err := netip.ParsePrefix(sn.Subnet)
+	if err != nil {
+		return fmt.Errorf("invalid subnet %q", sn.Subnet)
+	}
+	prefix = prefix.Masked()
+	where := "subnet " + prefix.String()
+	m.Subnets = append(m.Subnets, prefix.String())
+
+	// Subnet parameters override those of the shared network.
+	params := sn.keaParams
+	options := OptionSet{}
+	if net != nil {
+		options = m.keaOptions(c, "shared-network "+net.Name, space, net.OptionData)
+		inherit(&params, &net.keaParams)
+	}
+	options = mergeOptions(options, m.keaOptions(c, where, space, sn.OptionData))
+
+	for _, p := range sn.Pools {
+		prefixes, err := parseRange(p.Pool)
+		if err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		poolOptions := mergeOptions(options, m.keaOptions(c, where+" pool "+p.Pool, space, p.OptionData))
+		for _, pp := range prefixes {
+			if !prefix.Contains(pp.Addr()) {
+				return fmt.Errorf("%s: pool %s lies outside the subnet", where, p.Pool)
+			}
+			sc := &Scope{
+				AddressDetails:       &AddressDetails{Prefix: pp.String()},
+				Options:              poolOptions,
+				ValidLifetimeSecs:    params.ValidLifetime,
+				ClientClass:          params.ClientClass,
+				RequireClientClasses: params.RequireClasses,
+			}
+			if params.NextServer != "" {
+				sc.NextServer = &params.NextServer
+			}
+			if params.BootFileName != "" {
+				sc.BootFileName = &params.BootFileName
+			}
+			if params.ServerHostname != "" {
+				sc.ServerHostname = &params.ServerHostname
+			}
+			if params.Relay != nil {
+				sc.Relays = params.Relay.IPAddresses
+			}
+			m.Scopes = append(m.Scopes, sc)
+		}
+	}
+	for _, p := range sn.PDPools {
+		m.ignore(where, "prefix delegation pool %s", p.Prefix)
+	}
+
+	for _, h := range sn.Reservations {
+		if err := m.keaHost(c, h, where, options, space); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inherit fills the unset parameters of p from parent.
+func inherit(p, parent *keaParams) {
+	if p.ValidLifetime == nil {
+		p.ValidLifetime = parent.ValidLifetime
+	}
+	if p.NextServer == "" {
+		p.NextServer = parent.NextServer
+	}
+	if p.BootFileName == "" {
+		p.BootFileName = parent.BootFileName
+	}
+	if p.ServerHostname == "" {
+		p.ServerHostname = parent.ServerHostname
+	}
+	if p.ClientClass == nil {
+		p.ClientClass = parent.ClientClass
+	}
+	if p.RequireClasses == nil {
+		p.RequireClasses = parent.RequireClasses
+	}
+	if p.Relay == nil {
+		p.Relay = parent.Relay
+	}
+}
+
+func (m *Migration) keaHost(c *OptionCatalog, h *keaHost, where string, inherited OptionSet, space string) error {
+	res := &Reservation{AddressDetails: &AddressDetails{Name: h.Hostname}}
+	label := h.Hostname
+
+	switch {
+	case h.HWAddress != "":
+		mac, err := NormalizeMAC(h.HWAddress)
+		if err != nil {
+			return fmt.Errorf("%s: %w", where, err)
+		}
+		res.Mac = mac
+		res.Identifier = &Identifier{Type: HWAddressType, Value: mac}
+	case h.DUID != "":
+		res.Identifier = &Identifier{Type: DUIDType, Value: strings.ToLower(h.DUID)}
+	case h.ClientID != "":
+		res.Identifier = &Identifier{Type: ClientIDType, Value: strings.ToLower(h.ClientID)}
+	case h.CircuitID != "":
+		res.Identifier = &Identifier{Type: CircuitIDType, Value: h.CircuitID}
+	}
+	if res.Identifier != nil && label == "" {
+		label = res.Identifier.Value
+	}
+	where = where + " reservation " + label
+
+	addr := h.IPAddress
+	if space == SpaceV6 {
+		if len(h.IPAddresses) == 0 {
+			m.ignore(where, "reservation without address")
+			return nil
+		}
+		addr = h.IPAddresses[0]
+		if len(h.IPAddresses) > 1 {
+			m.ignore(where, "only the first address %s is reserved", addr)
+		}
+		v6 := true
+		res.DHCPv6 = &v6
+	}
+	if addr == "" {
+		m.ignore(where, "reservation without address")
+		return nil
+	}
+	if res.Identifier == nil {
+		m.ignore(where, "reservation without identifier")
+		return nil
+	}
+	if _, err := netip.ParseAddr(addr); err != nil {
+		return fmt.Errorf("%s: invalid address %q", where, addr)
+	}
+
+	res.AddressDetails.Prefix = addr
+	res.Options = mergeOptions(inherited, m.keaOptions(c, where, space, h.OptionData))
+	res.NextServer = h.NextServer
+	res.BootFileName = h.BootFileName
+	res.ServerHostname = h.ServerHostname
+	res.ClientClasses = h.ClientClasses
+	m.Reservations = append(m.Reservations, res)
+	return nil
+}
+
+// keaOptions converts Kea option data. Options of spaces other than the
+// standard ones are looked up in the catalog under their own space.
+func (m *Migration) keaOptions(c *OptionCatalog, where, space string, data []keaOption) OptionSet {
+	options := OptionSet{}
+	for _, d := range data {
+		optSpace := space
+		switch d.Space {
+		case "", "dhcp4", "dhcp6":
+		default:
+			optSpace = d.Space
+		}
+
+		value := d.Data
+		if d.CSVFormat != nil && !*d.CSVFormat && d.Data != "" {
+			def, ok := c.Lookup(optSpace + "/" + d.Name)
+			if d.Name == "" {
+				def, ok = c.LookupCode(optSpace, d.Code)
+			}
+			if !ok || def.Schema.Type != SchemaTypeBinary {
+				m.Unmapped = append(m.Unmapped, &UnmappedOption{
+					Where: where, Name: d.Name, Code: d.Code, Value: d.Data,
+					Reason: "hex data of a non binary option",
+				})
+				continue
+			}
+		}
+
+		o, ok := m.option(c, where, optSpace, d.Name, d.Code, value)
+		if !ok {
+			continue
+		}
+		o.AlwaysSend = d.AlwaysSend
+		options = append(options, o)
+	}
+	return options
+}
+
+// stripJSONComments removes the #, // and /* */ comments Kea allows in its
+// configuration files, leaving strings untouched.
+func stripJSONComments(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		switch {
+		case b[i] == '"':
+			j := i + 1
+			for j < len(b) && b[j] != '"' {
+				if b[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(b) {
+				j = len(b) - 1
+			}
+			out = append(out, b[i:j+1]...)
+			i = j
+		case b[i] == '#' || b[i] == '/' && i+1 < len(b) && b[i+1] == '/':
+			for i < len(b) && b[i] != '\n' {
+				i++
+			}
+			if i < len(b) {
+				out = append(out, '\n')
+			}
+		case b[i] == '/' && i+1 < len(b) && b[i+1] == '*':
+			end := strings.Index(string(b[i+2:]), "*/")
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		default:
+			out = append(out, b[i])
+		}
+	}
+	return out
+}
@@ -0,0 +1,147 @@
+package dhcp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKeaConf = `{
+	// Kea allows comments
+	"Dhcp4": {
+		"valid-lifetime": 4000, # seconds
+		"match-client-id": false,
+		"option-def": [{"name": "mode", "code": 1, "space": "vendor", "type": "uint8"}],
+		"option-data": [
+			{"name": "domain-name-servers", "data": "192.0.2.53, 192.0.2.54"},
+			{"code": 15, "data": "example.com", "always-send": true}
+		],
+		/* a subnet
+		   with a pool */
+		"subnet4": [{
+			"subnet": "192.0.2.0/24",
+			"pools": [{"pool": "192.0.2.10 - 192.0.2.19"}, {"pool": "192.0.2.64/26",
+				"option-data": [{"name": "routers", "data": "192.0.2.65"}]}],
+			"option-data": [{"name": "routers", "data": "192.0.2.1"}, {"name": "mystery", "data": "1"}],
+			"reservations": [
+				{"hw-address": "00-1A-2B-3C-4D-5E", "ip-address": "192.0.2.50", "hostname": "printer",
+					"option-data": [{"name": "host-name", "data": "printer"}]},
+				{"client-id": "01AABB", "ip-address": "192.0.2.51"},
+				{"hw-address": "00:1a:2b:3c:4d:5f", "hostname": "no-address"}
+			]
+		}],
+		"shared-networks": [{
+			"name": "office",
+			"boot-file-name": "pxe.efi",
+			"option-data": [{"name": "ntp-servers", "data": "198.51.100.123"}],
+			"subnet4": [{"subnet": "198.51.100.0/24", "pools": [{"pool": "198.51.100.128/25"}]}]
+		}]
+	},
+	"Dhcp6": {
+		"preferred-lifetime": 3000,
+		"subnet6": [{
+			"subnet": "2001:db8::/64",
+			"pools": [{"pool": "2001:db8::100-2001:db8::1ff"}],
+			"pd-pools": [{"prefix": "2001:db8:8::", "prefix-len": 56, "delegated-len": 64}],
+			"option-data": [{"name": "dns-servers", "data": "2001:db8::53"}],
+			"reservations": [{"duid": "00:03:00:01:AA:BB", "ip-addresses": ["2001:db8::10"]}]
+		}]
+	}
+}`
+
+func TestParseKea(t *testing.T) {
+	m, err := ParseKea(strings.NewReader(testKeaConf), "office", nil)
+	require.NoError(t, err)
+
+	sg := m.ScopeGroup
+	require.NotNil(t, sg.DHCP4.Enabled)
+	require.NotNil(t, sg.DHCP6.Enabled)
+	assert.Equal(t, 4000, *sg.DHCP4.ValidLifetimeSecs)
+	assert.Equal(t, 3000, *sg.DHCP6.PreferredLifetimeSecs)
+	assert.False(t, *sg.DHCP4.MatchClientID)
+	alwaysSend := true
+	assert.Equal(t, OptionSet{
+		{Name: "dhcpv4/domain-name-servers", Value: []interface{}{"192.0.2.53", "192.0.2.54"}},
+		{Name: "dhcpv4/domain-name", Value: "example.com", AlwaysSend: &alwaysSend},
+	}, sg.DHCP4.Options)
+
+	assert.Equal(t, []string{"192.0.2.0/24", "198.51.100.0/24", "2001:db8::/64"}, m.Subnets)
+
+	prefixes := []string{}
+	for _, sc := range m.Scopes {
+		prefixes = append(prefixes, sc.AddressDetails.Prefix)
+	}
+	assert.Equal(t, []string{
+		"192.0.2.10/31", "192.0.2.12/30", "192.0.2.16/30", "192.0.2.64/26",
+		"198.51.100.128/25", "2001:db8::100/120",
+	}, prefixes)
+	assert.Equal(t, OptionSet{{Name: "dhcpv4/routers", Value: []interface{}{"192.0.2.1"}}}, m.Scopes[0].Options)
+	assert.Equal(t, OptionSet{{Name: "dhcpv4/routers", Value: []interface{}{"192.0.2.65"}}}, m.Scopes[3].Options)
+	assert.Equal(t, OptionSet{{Name: "dhcpv4/ntp-servers", Value: []interface{}{"198.51.100.123"}}}, m.Scopes[4].Options)
+	require.NotNil(t, m.Scopes[4].BootFileName)
+	assert.Equal(t, "pxe.efi", *m.Scopes[4].BootFileName)
+
+	require.Len(t, m.Reservations, 3)
+	r := m.Reservations[0]
+	assert.Equal(t, "192.0.2.50", r.AddressDetails.Prefix)
+	assert.Equal(t, "00:1a:2b:3c:4d:5e", r.Mac)
+	assert.Equal(t, OptionSet{
+		{Name: "dhcpv4/routers", Value: []interface{}{"192.0.2.1"}},
+		{Name: "dhcpv4/host-name", Value: "printer"},
+	}, r.Options)
+	assert.Equal(t, &Identifier{Type: ClientIDType, Value: "01aabb"}, m.Reservations[1].Identifier)
+	r = m.Reservations[2]
+	assert.Equal(t, "2001:db8::10", r.AddressDetails.Prefix)
+	assert.Equal(t, &Identifier{Type: DUIDType, Value: "00:03:00:01:aa:bb"}, r.Identifier)
+	require.NotNil(t, r.DHCPv6)
+	assert.True(t, *r.DHCPv6)
+
+	require.Len(t, m.Unmapped, 1)
+	assert.Equal(t, "mystery", m.Unmapped[0].Name)
+
+	ignored := strings.Join(m.Ignored, "\n")
+	assert.Contains(t, ignored, "option definition mode")
+	assert.Contains(t, ignored, "no-address")
+	assert.Contains(t, ignored, "prefix delegation pool 2001:db8:8::")
+}
+
+func TestParseKea_CustomOptions(t *testing.T) {
+	space, items := "vendor", string(ItemTypeUint8)
+	c := NewOptionCatalog(OptionDef{
+		Space: &space, FriendlyName: "modes", Code: 1,
+		Schema: OptionDefSchema{Type: SchemaTypeArray, Items: &items},
+	})
+	conf := `{"Dhcp4": {"option-data": [
+		{"name": "modes", "space": "vendor", "data": "1, 2"},
+		{"code": 1, "space": "vendor", "data": "300"}
+	]}}`
+
+	m, err := ParseKea(strings.NewReader(conf), "sg", c)
+	require.NoError(t, err)
+	assert.Equal(t, OptionSet{{Name: "vendor/modes", Value: []interface{}{1, 2}}}, m.ScopeGroup.DHCP4.Options)
+	require.Len(t, m.Unmapped, 1)
+	assert.Contains(t, m.Unmapped[0].Reason, "invalid uint8 value")
+}
+
+func TestParseKea_Errors(t *testing.T) {
+	for name, conf := range map[string]string{
+		"invalid json":  `{"Dhcp4": `,
+		"no server":     `{"Control-agent": {}}`,
+		"bad subnet":    `{"Dhcp4": {"subnet4": [{"subnet": "192.0.2.0"}]}}`,
+		"bad pool":      `{"Dhcp4": {"subnet4": [{"subnet": "192.0.2.0/24", "pools": [{"pool": "x - y"}]}]}}`,
+		"pool outside":  `{"Dhcp4": {"subnet4": [{"subnet": "192.0.2.0/24", "pools": [{"pool": "198.51.100.0/28"}]}]}}`,
+		"bad hwaddress": `{"Dhcp4": {"reservations": [{"hw-address": "xx", "ip-address": "192.0.2.1"}]}}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParseKea(strings.NewReader(conf), "sg", nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestStripJSONComments(t *testing.T) {
+	in := "{\"a\": \"x // not # a /* comment\", # c\n \"b\": 1 // c\n /* c */}"
+	assert.Equal(t, "{\"a\": \"x // not # a /* comment\", \n \"b\": 1 \n }", string(stripJSONComments([]byte(in))))
+}
@@ -0,0 +1,218 @@
+package dhcp
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Migration is the result of converting the configuration of another DHCP
+// server, by ParseDhcpd or ParseKea.
+type Migration struct {
+	// ScopeGroup holds the global settings and options.
+	ScopeGroup *ScopeGroup
+
+	// Subnets holds the prefixes of the subnets declared, which must exist
+	// in IPAM for their scopes and reservations to be created.
+	Subnets []string
+
+	// Scopes holds a scope per dynamic pool; AddressDetails.Prefix is the
+	// prefix of the pool. Pools that are not prefixes are split into the
+	// smallest set of prefixes covering them.
+	Scopes []*Scope
+
+	// Reservations holds the host reservations; AddressDetails.Prefix is
+	// the reserved address.
+	Reservations []*Reservation
+
+	// Unmapped lists the options that were left out because they match no
+	// known option definition or have an invalid value.
+	Unmapped []*UnmappedOption
+
+	// Ignored lists the statements that have no NS1 equivalent.
+	Ignored []string
+}
+
+// UnmappedOption is an option left out of a Migration.
+type UnmappedOption struct {
+	// Where tells where the option was set, e.g. "subnet 192.0.2.0/24".
+	Where string
+	Name  string
+	Code  int
+	Value string
+
+	Reason string
+}
+
+func (u *UnmappedOption) String() string {
+	name := u.Name
+	if name == "" {
+		name = fmt.Sprintf("code %d", u.Code)
+	}
+	return fmt.Sprintf("%s: option %s = %q: %s", u.Where, name, u.Value, u.Reason)
+}
+
+func newMigration(name string) *Migration {
+	return &Migration{
+		ScopeGroup: &ScopeGroup{
+			Name:  name,
+			DHCP4: SettingsV4{Settings: Settings{Options: OptionSet{}}},
+			DHCP6: SettingsV6{Settings: Settings{Options: OptionSet{}}},
+		},
+		Subnets:      []string{},
+		Scopes:       []*Scope{},
+		Reservations: []*Reservation{},
+		Unmapped:     []*UnmappedOption{},
+		Ignored:      []string{},
+	}
+}
+
+// option converts an option given by name or code in a space, with its
+// value in text form, recording it as unmapped if that fails.
+func (m *Migration) option(c *OptionCatalog, where, space, name string, code int, value string) (Option, bool) {
+	unmapped := func(reason string) (Option, bool) {
+		m.Unmapped = append(m.Unmapped, &UnmappedOption{Where: where, Name: name, Code: code, Value: value, Reason: reason})
+		return Option{}, false
+	}
+
+	var def *OptionDef
+	var ok bool
+	if name != "" {
+		def, ok = c.Lookup(space + "/" + name)
+	} else {
+		def, ok = c.LookupCode(space, code)
+	}
+	if !ok {
+		return unmapped("no known option definition")
+	}
+
+	v, err := def.ParseText(value)
+	if err != nil {
+		return unmapped(err.Error())
+	}
+	return Option{Name: def.OptionName(), Value: v}, true
+}
+
+func (m *Migration) ignore(where, format string, args ...interface{}) {
+	m.Ignored = append(m.Ignored, where+": "+fmt.Sprintf(format, args...))
+}
+
+// mergeOptions returns inherited overridden by own, by option name.
+func mergeOptions(inherited, own OptionSet) OptionSet {
+	merged := OptionSet{}
+	for _, o := range inherited {
+		overridden := false
+		for _, p := range own {
+			overridden = overridden || p.Name == o.Name
+		}
+		if !overridden {
+			merged = append(merged, o)
+		}
+	}
+	return append(merged, own...)
+}
+
+// rangePrefixes returns the smallest set of prefixes covering the
+// addresses from first to last, inclusive.
+func rangePrefixes(first, last netip.Addr) ([]netip.Prefix, error) {
+	if first.BitLen() != last.BitLen() || last.Less(first) {
+		return nil, fmt.Errorf("invalid range %s - %s", first, last)
+	}
+
+	prefixes := []netip.Prefix{}
+	for {
+		// Grow the prefix starting at first as long as it stays aligned
+		// and within the range.
+		bits := first.BitLen()
+		for bits > 0 {
+			p, err := first.Prefix(bits - 1)
+			if err != nil || p.Addr() != first || lastAddr(p).Compare(last) > 0 {
+				break
+			}
+			bits--
+		}
+		p := netip.PrefixFrom(first, bits)
+		prefixes = append(prefixes, p)
+
+		end := lastAddr(p)
+		if end == last || !end.Next().IsValid() {
+			return prefixes, nil
+		}
+		first = end.Next()
+	}
+}
+
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Masked().Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// parseRange parses a pool given as a prefix or as "first - last".
+func parseRange(s string) ([]netip.Prefix, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pool %q", s)
+		}
+		return []netip.Prefix{p.Masked()}, nil
+	}
+
+	bounds := strings.Fields(strings.Replace(s, "-", " ", 1))
+	if len(bounds) == 1 {
+		bounds = append(bounds, bounds[0])
+	}
+	if len(bounds) != 2 {
+		return nil, fmt.Errorf("invalid pool %q", s)
+	}
+	first, err := netip.ParseAddr(bounds[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool %q", s)
+	}
+	last, err := netip.ParseAddr(bounds[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid pool %q", s)
+	}
+	return rangePrefixes(first, last)
+}
+
+// NormalizeMAC returns a hardware address in the lower case,
+// colon-separated form, e.g. "00:1a:2b:3c:4d:5e", accepting the forms
+// "00-1A-2B-3C-4D-5E", "001a.2b3c.4d5e" and "001a2b3c4d5e" as well as
+// single digit octets like "0:1a:2b:3c:4d:5e".
+func NormalizeMAC(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	var octets []string
+	switch {
+	case strings.ContainsAny(s, ":-"):
+		octets = strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == '-' })
+		for i, o := range octets {
+			if len(o) == 1 {
+				octets[i] = "0" + o
+			}
+		}
+	default:
+		hex := strings.ReplaceAll(s, ".", "")
+		if len(hex)%2 != 0 {
+			return "", fmt.Errorf("invalid hardware address %q", s)
+		}
+		for i := 0; i < len(hex); i += 2 {
+			octets = append(octets, hex[i:i+2])
+		}
+	}
+
+	if len(octets) < 6 || len(octets) > 20 {
+		return "", fmt.Errorf("invalid hardware address %q", s)
+	}
+	for _, o := range octets {
+		if len(o) != 2 || strings.Trim(o, "0123456789abcdef") != "" {
+			return "", fmt.Errorf("invalid hardware address %q", s)
+		}
+	}
+	return strings.Join(octets, ":"), nil
+}
@@ -0,0 +1,276 @@
+package dhcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// OptionError is a problem with the value of an option.
+type OptionError struct {
+	// Where tells where the option is set, e.g. "dhcpv4" for the DHCPv4
+	// options of a scope group.
+	Where string
+	// Index is the position of the option in its option set.
+	Index int
+	Name  string
+	// Item locates the faulty part of the value, e.g. "item 2" of an array
+	// or "field prefix" of a record; it is empty for the whole value.
+	Item string
+	Err  error
+}
+
+func (e *OptionError) Error() string {
+	where := fmt.Sprintf("option %d", e.Index)
+	if e.Name != "" {
+		where = fmt.Sprintf("option %s", e.Name)
+	}
+	if e.Where != "" {
+		where = e.Where + " " + where
+	}
+	if e.Item != "" {
+		where += " " + e.Item
+	}
+	return where + ": " + e.Err.Error()
+}
+
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// OptionErrors lists the problems found validating options.
+type OptionErrors []*OptionError
+
+func (errs OptionErrors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	return fmt.Sprintf("%d invalid options: %s", len(errs), strings.Join(msgs, "; "))
+}
+
+// ValidateOptions checks the options of a set against their definitions,
+// returning a copy of the set with normalized values: integers as int, IP
+// addresses and prefixes in canonical form, binary data as lower case hex
+// and array and record values as []interface{}. Where is reported in the
+// errors to tell where the set comes from.
+//
+// Every option must be named "space/name" after a definition of the
+// catalog, and be set only once.
+func (c *OptionCatalog) ValidateOptions(where string, options OptionSet) (OptionSet, OptionErrors) {
+	normalized := make(OptionSet, 0, len(options))
+	var errs OptionErrors
+	seen := map[string]bool{}
+	for i, o := range options {
+		fail := func(item string, err error) {
+			errs = append(errs, &OptionError{Where: where, Index: i, Name: o.Name, Item: item, Err: err})
+		}
+
+		if !strings.Contains(o.Name, "/") {
+			fail("", fmt.Errorf("name must be \"space/name\""))
+			continue
+		}
+		if seen[o.Name] {
+			fail("", fmt.Errorf("option set more than once"))
+			continue
+		}
+		seen[o.Name] = true
+
+		def, ok := c.Lookup(o.Name)
+		if !ok {
+			fail("", fmt.Errorf("no known option definition"))
+			continue
+		}
+		if o.Encapsulate != nil && def.Encapsulate == nil {
+			fail("", fmt.Errorf("option does not encapsulate an option space"))
+			continue
+		}
+
+		v, item, err := def.normalize(o.Value)
+		if err != nil {
+			fail(item, err)
+			continue
+		}
+		o.Value = v
+		normalized = append(normalized, o)
+	}
+	return normalized, errs
+}
+
+// ValidateScopeGroup validates the DHCPv4 and DHCPv6 options of a scope
+// group with ValidateOptions, returning a copy of it with normalized
+// options. DHCPv4 options must not be of the DHCPv6 space, and the other
+// way round.
+func (c *OptionCatalog) ValidateScopeGroup(sg *ScopeGroup) (*ScopeGroup, OptionErrors) {
+	normalized := *sg
+	var errs OptionErrors
+	for _, set := range []struct {
+		space, other string
+		options      *OptionSet
+	}{
+		{SpaceV4, SpaceV6, &normalized.DHCP4.Options},
+		{SpaceV6, SpaceV4, &normalized.DHCP6.Options},
+	} {
+		if *set.options == nil {
+			continue
+		}
+		options, setErrs := c.ValidateOptions(set.space, *set.options)
+		for i, o := range *set.options {
+			if strings.HasPrefix(o.Name, set.other+"/") {
+				setErrs = append(setErrs, &OptionError{Where: set.space, Index: i, Name: o.Name, Err: fmt.Errorf("option of the %s space", set.other)})
+			}
+		}
+		*set.options = options
+		errs = append(errs, setErrs...)
+	}
+	return &normalized, errs
+}
+
+// Validate checks an option value against the definition, as
+// ValidateOptions does, returning the normalized value.
+func (d *OptionDef) Validate(v interface{}) (interface{}, error) {
+	v, item, err := d.normalize(v)
+	if err != nil && item != "" {
+		return nil, fmt.Errorf("%s: %w", item, err)
+	}
+	return v, err
+}
+
+// normalize returns the normalized value, or the faulty item and error.
+func (d *OptionDef) normalize(v interface{}) (interface{}, string, error) {
+	switch d.Schema.Type {
+	case SchemaTypeArray:
+		if d.Schema.Items == nil {
+			return nil, "", fmt.Errorf("array definition lacks an item type")
+		}
+		values, ok := toSlice(v)
+		if !ok {
+			return nil, "", fmt.Errorf("expected an array of %s, got %T", *d.Schema.Items, v)
+		}
+		for i, item := range values {
+			n, err := normalizeItem(ItemType(*d.Schema.Items), item)
+			if err != nil {
+				return nil, fmt.Sprintf("item %d", i), err
+			}
+			values[i] = n
+		}
+		return values, "", nil
+	case SchemaTypeRecord:
+		values, ok := toSlice(v)
+		if !ok {
+			return nil, "", fmt.Errorf("expected a record, got %T", v)
+		}
+		fields := d.Schema.Fields
+		multiple := d.Schema.MultipleFinalValue != nil && *d.Schema.MultipleFinalValue
+		if len(values) != len(fields) && !(multiple && len(fields) > 0 && len(values) >= len(fields)) {
+			return nil, "", fmt.Errorf("expected %d record fields, got %d", len(fields), len(values))
+		}
+		for i, item := range values {
+			f := fields[len(fields)-1]
+			if i < len(fields) {
+				f = fields[i]
+			}
+			n, err := normalizeItem(f.Type, item)
+			if err != nil {
+				return nil, "field " + f.Name, err
+			}
+			values[i] = n
+		}
+		return values, "", nil
+	default:
+		n, err := normalizeItem(ItemType(d.Schema.Type), v)
+		return n, "", err
+	}
+}
+
+// toSlice returns a copy of v as a []interface{}, if it is a slice.
+func toSlice(v interface{}) ([]interface{}, bool) {
+	switch s := v.(type) {
+	case []interface{}:
+		return append([]interface{}{}, s...), true
+	case []string:
+		values := make([]interface{}, len(s))
+		for i := range s {
+			values[i] = s[i]
+		}
+		return values, true
+	case []int:
+		values := make([]interface{}, len(s))
+		for i := range s {
+			values[i] = s[i]
+		}
+		return values, true
+	}
+	return nil, false
+}
+
+// normalizeItem checks a single value against an item type, as decoded
+// from JSON or set from Go.
+func normalizeItem(t ItemType, v interface{}) (interface{}, error) {
+	switch t {
+	case ItemTypeBoolean:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+		return nil, fmt.Errorf("expected a boolean, got %T", v)
+	case ItemTypeEmpty:
+		if v != nil {
+			return nil, fmt.Errorf("expected no value, got %T", v)
+		}
+		return nil, nil
+	case ItemTypeInt8, ItemTypeInt16, ItemTypeInt32, ItemTypeUint8, ItemTypeUint16, ItemTypeUint32:
+		n, ok := toInt64(v)
+		if !ok {
+			return nil, fmt.Errorf("expected an integer, got %v", v)
+		}
+		i, err := parseInt(t, strconv.FormatInt(n, 10))
+		if err != nil {
+			return nil, fmt.Errorf("%d is out of %s range", n, t)
+		}
+		return i, nil
+	case ItemTypeString, ItemTypeTuple, ItemTypePSID:
+		if s, ok := v.(string); ok {
+			return s, nil
+		}
+		return nil, fmt.Errorf("expected a string, got %T", v)
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return nil, fmt.Errorf("expected a %s string, got %T", t, v)
+	}
+	return parseItem(t, s)
+}
+
+// toInt64 converts a Go or JSON decoded integral number.
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int8:
+		return int64(n), true
+	case int16:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint8:
+		return int64(n), true
+	case uint16:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case float64:
+		if n != math.Trunc(n) || math.Abs(n) > 1<<53 {
+			return 0, false
+		}
+		return int64(n), true
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,112 @@
+package dhcp
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionCatalog_ValidateOptions(t *testing.T) {
+	space, items := "vendor", string(ItemTypeIPv6Prefix)
+	multiple := true
+	c := NewOptionCatalog(
+		OptionDef{Space: &space, FriendlyName: "prefixes", Code: 1, Schema: OptionDefSchema{Type: SchemaTypeArray, Items: &items}},
+		OptionDef{Space: &space, FriendlyName: "route", Code: 2, Schema: OptionDefSchema{Type: SchemaTypeRecord, MultipleFinalValue: &multiple,
+			Fields: []OptionDefSchemaItems{{Name: "metric", Type: ItemTypeUint8}, {Name: "gateway", Type: ItemTypeIPv4Address}}}},
+	)
+
+	var decoded OptionSet
+	require.NoError(t, json.Unmarshal([]byte(`[
+		{"name": "dhcpv4/routers", "value": ["192.0.2.1"]},
+		{"name": "dhcpv4/interface-mtu", "value": 1500},
+		{"name": "vendor/route", "value": [10, "192.0.2.1", "192.0.2.2"]}
+	]`), &decoded))
+
+	options := append(decoded,
+		Option{Name: "dhcpv4/domain-name", Value: "example.com"},
+		Option{Name: "vendor/prefixes", Value: []string{"2001:DB8::/32"}},
+		Option{Name: "dhcpv4/ip-forwarding", Value: false},
+	)
+	normalized, errs := c.ValidateOptions("dhcpv4", options)
+	require.Empty(t, errs)
+	assert.Equal(t, OptionSet{
+		{Name: "dhcpv4/routers", Value: []interface{}{"192.0.2.1"}},
+		{Name: "dhcpv4/interface-mtu", Value: 1500},
+		{Name: "vendor/route", Value: []interface{}{10, "192.0.2.1", "192.0.2.2"}},
+		{Name: "dhcpv4/domain-name", Value: "example.com"},
+		{Name: "vendor/prefixes", Value: []interface{}{"2001:db8::/32"}},
+		{Name: "dhcpv4/ip-forwarding", Value: false},
+	}, normalized)
+	assert.Equal(t, []interface{}{"192.0.2.1"}, options[0].Value, "the options are not modified")
+
+	encapsulate := "vendor"
+	_, errs = c.ValidateOptions("dhcpv4", OptionSet{
+		{Name: "routers", Value: []string{"192.0.2.1"}},
+		{Name: "dhcpv4/routers", Value: []interface{}{"192.0.2.1", "192.0.2.300"}},
+		{Name: "dhcpv4/routers", Value: []interface{}{"192.0.2.1"}},
+		{Name: "dhcpv4/interface-mtu", Value: 70000},
+		{Name: "dhcpv4/interface-mtu", Value: 1.5},
+		{Name: "dhcpv4/domain-name", Value: "bad name"},
+		{Name: "dhcpv4/nope", Value: 1},
+		{Name: "vendor/route", Value: []interface{}{10}},
+		{Name: "vendor/route", Value: []interface{}{300, "192.0.2.1"}},
+		{Name: "dhcpv4/host-name", Value: "a", Encapsulate: &encapsulate},
+		{Name: "dhcpv4/ip-forwarding", Value: "yes"},
+	})
+	msgs := []string{}
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	assert.Equal(t, []string{
+		`dhcpv4 option routers: name must be "space/name"`,
+		`dhcpv4 option dhcpv4/routers item 1: invalid ipv4_address value "192.0.2.300"`,
+		`dhcpv4 option dhcpv4/routers: option set more than once`,
+		`dhcpv4 option dhcpv4/interface-mtu: 70000 is out of uint16 range`,
+		`dhcpv4 option dhcpv4/interface-mtu: option set more than once`,
+		`dhcpv4 option dhcpv4/domain-name: invalid fqdn value "bad name"`,
+		`dhcpv4 option dhcpv4/nope: no known option definition`,
+		`dhcpv4 option vendor/route: expected 2 record fields, got 1`,
+		`dhcpv4 option vendor/route: option set more than once`,
+		`dhcpv4 option dhcpv4/host-name: option does not encapsulate an option space`,
+		`dhcpv4 option dhcpv4/ip-forwarding: expected a boolean, got string`,
+	}, msgs)
+	assert.Equal(t, 1, errs[1].Index)
+	assert.Contains(t, errs.Error(), "11 invalid options")
+}
+
+func TestOptionCatalog_ValidateScopeGroup(t *testing.T) {
+	c := NewOptionCatalog()
+	sg := &ScopeGroup{Name: "sg"}
+	sg.DHCP4.Options = OptionSet{
+		{Name: "dhcpv4/routers", Value: []interface{}{"192.0.2.1"}},
+		{Name: "dhcpv6/dns-servers", Value: []interface{}{"2001:db8::1"}},
+	}
+	sg.DHCP6.Options = OptionSet{{Name: "dhcpv6/dns-servers", Value: []interface{}{"2001:DB8::1"}}}
+
+	normalized, errs := c.ValidateScopeGroup(sg)
+	require.Len(t, errs, 1)
+	assert.Equal(t, "dhcpv4 option dhcpv6/dns-servers: option of the dhcpv6 space", errs[0].Error())
+	assert.Equal(t, OptionSet{{Name: "dhcpv6/dns-servers", Value: []interface{}{"2001:db8::1"}}}, normalized.DHCP6.Options)
+	assert.Equal(t, "2001:DB8::1", sg.DHCP6.Options[0].Value.([]interface{})[0])
+}
+
+func TestOptionDef_Validate(t *testing.T) {
+	items := string(ItemTypeUint8)
+	def := &OptionDef{FriendlyName: "x", Schema: OptionDefSchema{Type: SchemaTypeArray, Items: &items}}
+
+	v, err := def.Validate([]interface{}{json.Number("1"), 2.0})
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2}, v)
+
+	_, err = def.Validate([]interface{}{1, -1})
+	require.Error(t, err)
+	assert.Equal(t, "item 1: -1 is out of uint8 range", err.Error())
+
+	var optErr *OptionError
+	_, errs := NewOptionCatalog().ValidateOptions("", OptionSet{{Name: "dhcpv4/routers", Value: "192.0.2.1"}})
+	require.True(t, errors.As(error(errs[0]), &optErr))
+	assert.Equal(t, "option dhcpv4/routers: expected an array of ipv4_address, got string", optErr.Error())
+}
@@ -0,0 +1,563 @@
+package dhcp
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// DHCPv4 pad and end options, which carry no length.
+const (
+	padOption = 0
+	endOption = 255
+)
+
+// Encode encodes options into the type-length-value wire format of the
+// given protocol, SpaceV4 or SpaceV6: one byte codes and lengths for
+// DHCPv4 and two byte ones for DHCPv6. DHCPv4 options longer than 255
+// bytes are split into several options of the same code, per RFC 3396.
+//
+// Options of the protocol space are encoded in order. Options of other
+// spaces are encoded, in order too, within the option encapsulating their
+// space, as given by Option.Encapsulate or else OptionDef.Encapsulate,
+// after the value of that option.
+//
+// Values are checked and normalized with OptionDef.Validate first.
+// Strings, binary and tuple values are encoded as is, fqdn values in DNS
+// wire format without compression, ipv6_prefix values as the prefix
+// length followed by the 16 byte address, and psid values, given as
+// "psid/length", as the length followed by the PSID in the high bits of
+// two bytes.
+func (c *OptionCatalog) Encode(protocol string, options OptionSet) ([]byte, error) {
+	v6, err := isV6(protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	bySpace := map[string]OptionSet{}
+	for _, o := range options {
+		space := optionSpace(o.Name)
+		bySpace[space] = append(bySpace[space], o)
+	}
+
+	encoded := map[string]bool{protocol: true}
+	b, err := c.encodeSpace(v6, protocol, bySpace, encoded)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range options {
+		if space := optionSpace(o.Name); !encoded[space] {
+			return nil, fmt.Errorf("option %s: no option encapsulates the %s space", o.Name, space)
+		}
+	}
+	return b, nil
+}
+
+func (c *OptionCatalog) encodeSpace(v6 bool, space string, bySpace map[string]OptionSet, encoded map[string]bool) ([]byte, error) {
+	var b []byte
+	for _, o := range bySpace[space] {
+		def, ok := c.Lookup(o.Name)
+		if !ok {
+			return nil, fmt.Errorf("option %s: no known option definition", o.Name)
+		}
+		v, err := def.Validate(o.Value)
+		if err != nil {
+			return nil, fmt.Errorf("option %s: %w", o.Name, err)
+		}
+		data, err := encodeValue(def, v, v6)
+		if err != nil {
+			return nil, fmt.Errorf("option %s: %w", o.Name, err)
+		}
+
+		encapsulate := o.Encapsulate
+		if encapsulate == nil {
+			encapsulate = def.Encapsulate
+		}
+		if encapsulate != nil {
+			if encoded[*encapsulate] {
+				return nil, fmt.Errorf("option %s: the %s space is already encapsulated", o.Name, *encapsulate)
+			}
+			encoded[*encapsulate] = true
+			sub, err := c.encodeSpace(v6, *encapsulate, bySpace, encoded)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, sub...)
+		}
+
+		if b, err = appendTLV(b, v6, def.Code, data); err != nil {
+			return nil, fmt.Errorf("option %s: %w", o.Name, err)
+		}
+	}
+	return b, nil
+}
+
+func appendTLV(b []byte, v6 bool, code int, data []byte) ([]byte, error) {
+	if v6 {
+		if code < 1 || code > 0xffff {
+			return nil, fmt.Errorf("invalid DHCPv6 option code %d", code)
+		}
+		if len(data) > 0xffff {
+			return nil, fmt.Errorf("value of %d bytes is too long", len(data))
+		}
+		b = binary.BigEndian.AppendUint16(b, uint16(code))
+		b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+		return append(b, data...), nil
+	}
+
+	if code <= padOption || code >= endOption {
+		return nil, fmt.Errorf("invalid DHCPv4 option code %d", code)
+	}
+	for {
+		n := len(data)
+		if n > 255 {
+			n = 255
+		}
+		b = append(b, byte(code), byte(n))
+		b = append(b, data[:n]...)
+		data = data[n:]
+		if len(data) == 0 {
+			return b, nil
+		}
+	}
+}
+
+func encodeValue(def *OptionDef, v interface{}, v6 bool) ([]byte, error) {
+	var b []byte
+	var err error
+	switch def.Schema.Type {
+	case SchemaTypeArray:
+		for _, item := range v.([]interface{}) {
+			if b, err = appendItem(b, ItemType(*def.Schema.Items), item, v6); err != nil {
+				return nil, err
+			}
+		}
+	case SchemaTypeRecord:
+		fields := def.Schema.Fields
+		for i, item := range v.([]interface{}) {
+			f := fields[len(fields)-1]
+			if i < len(fields) {
+				f = fields[i]
+			}
+			if b, err = appendItem(b, f.Type, item, v6); err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+		}
+	default:
+		b, err = appendItem(b, ItemType(def.Schema.Type), v, v6)
+	}
+	return b, err
+}
+
+// appendItem encodes a value normalized by normalizeItem.
+func appendItem(b []byte, t ItemType, v interface{}, v6 bool) ([]byte, error) {
+	switch t {
+	case ItemTypeIPv4Address:
+		a := netip.MustParseAddr(v.(string)).As4()
+		return append(b, a[:]...), nil
+	case ItemTypeIPv6Address:
+		a := netip.MustParseAddr(v.(string)).As16()
+		return append(b, a[:]...), nil
+	case ItemTypeIPv6Prefix:
+		p := netip.MustParsePrefix(v.(string))
+		a := p.Addr().As16()
+		return append(append(b, byte(p.Bits())), a[:]...), nil
+	case ItemTypeFQDN:
+		name := strings.TrimSuffix(v.(string), ".")
+		if name == "" {
+			// The root is the empty label alone.
+			return append(b, 0), nil
+		}
+		for _, label := range strings.Split(name, ".") {
+			b = append(append(b, byte(len(label))), label...)
+		}
+		return append(b, 0), nil
+	case ItemTypeString:
+		return append(b, v.(string)...), nil
+	case ItemTypeTuple:
+		s := v.(string)
+		if v6 {
+			if len(s) > 0xffff {
+				return nil, fmt.Errorf("tuple of %d bytes is too long", len(s))
+			}
+			b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+		} else {
+			if len(s) > 0xff {
+				return nil, fmt.Errorf("tuple of %d bytes is too long", len(s))
+			}
+			b = append(b, byte(len(s)))
+		}
+		return append(b, s...), nil
+	case ItemTypePSID:
+		psid, bits, err := parsePSID(v.(string))
+		if err != nil {
+			return nil, err
+		}
+		return binary.BigEndian.AppendUint16(append(b, byte(bits)), psid<<(16-bits)), nil
+	case ItemTypeBoolean:
+		if v.(bool) {
+			return append(b, 1), nil
+		}
+		return append(b, 0), nil
+	case ItemTypeInt8, ItemTypeUint8:
+		return append(b, byte(v.(int))), nil
+	case ItemTypeInt16, ItemTypeUint16:
+		return binary.BigEndian.AppendUint16(b, uint16(v.(int))), nil
+	case ItemTypeInt32, ItemTypeUint32:
+		return binary.BigEndian.AppendUint32(b, uint32(v.(int))), nil
+	case ItemTypeBinary:
+		data, _ := hex.DecodeString(v.(string))
+		return append(b, data...), nil
+	case ItemTypeEmpty:
+		return b, nil
+	}
+	return nil, fmt.Errorf("unknown item type %q", t)
+}
+
+// parsePSID parses a PSID given as "psid/length".
+func parsePSID(s string) (uint16, uint, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid psid value %q", s)
+	}
+	psid, err := strconv.ParseUint(parts[0], 0, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid psid value %q", s)
+	}
+	bits, err := strconv.ParseUint(parts[1], 10, 8)
+	if err != nil || bits > 16 || psid >= 1<<bits && bits < 16 {
+		return 0, 0, fmt.Errorf("invalid psid value %q", s)
+	}
+	return uint16(psid), uint(bits), nil
+}
+
+// Decode decodes options from the wire format of the given protocol,
+// SpaceV4 or SpaceV6, as encoded by Encode. Values are decoded in the
+// normalized form of OptionDef.Validate, fqdn values without trailing dot.
+//
+// The options of an encapsulated space follow the option encapsulating
+// them, which has its Encapsulate field set; this requires the value of
+// that option to be of fixed size. DHCPv4 pad options are skipped,
+// decoding stops at the end option, and the options of the same code are
+// concatenated, per RFC 3396. Options of unknown codes are decoded as
+// binary values named after their code, e.g. "dhcpv4/option-224".
+func (c *OptionCatalog) Decode(protocol string, b []byte) (OptionSet, error) {
+	v6, err := isV6(protocol)
+	if err != nil {
+		return nil, err
+	}
+	return c.decodeSpace(v6, protocol, b, map[string]bool{protocol: true})
+}
+
+type tlv struct {
+	code int
+	data []byte
+}
+
+func (c *OptionCatalog) decodeSpace(v6 bool, space string, b []byte, decoded map[string]bool) (OptionSet, error) {
+	tlvs, err := splitTLVs(v6, b)
+	if err != nil {
+		return nil, fmt.Errorf("%s options: %w", space, err)
+	}
+
+	options := OptionSet{}
+	for _, opt := range tlvs {
+		def, ok := c.LookupCode(space, opt.code)
+		if !ok {
+			options = append(options, Option{
+				Name:  fmt.Sprintf("%s/option-%d", space, opt.code),
+				Value: hex.EncodeToString(opt.data),
+			})
+			continue
+		}
+
+		if def.Encapsulate == nil {
+			v, err := decodeValue(def, opt.data, v6)
+			if err != nil {
+				return nil, fmt.Errorf("option %s: %w", def.OptionName(), err)
+			}
+			options = append(options, Option{Name: def.OptionName(), Value: v})
+			continue
+		}
+
+		encapsulate := *def.Encapsulate
+		if decoded[encapsulate] {
+			return nil, fmt.Errorf("option %s: the %s space is already encapsulated", def.OptionName(), encapsulate)
+		}
+		decoded[encapsulate] = true
+		n, ok := fixedSize(def)
+		if !ok {
+			return nil, fmt.Errorf("option %s: cannot tell its value from the encapsulated options", def.OptionName())
+		}
+		if len(opt.data) < n {
+			return nil, fmt.Errorf("option %s: truncated value", def.OptionName())
+		}
+		v, err := decodeValue(def, opt.data[:n], v6)
+		if err != nil {
+			return nil, fmt.Errorf("option %s: %w", def.OptionName(), err)
+		}
+		sub, err := c.decodeSpace(v6, encapsulate, opt.data[n:], decoded)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, Option{Name: def.OptionName(), Value: v, Encapsulate: &encapsulate})
+		options = append(options, sub...)
+	}
+	return options, nil
+}
+
+func splitTLVs(v6 bool, b []byte) ([]tlv, error) {
+	var tlvs []tlv
+	if v6 {
+		for len(b) > 0 {
+			if len(b) < 4 {
+				return nil, fmt.Errorf("truncated option header")
+			}
+			code, n := int(binary.BigEndian.Uint16(b)), int(binary.BigEndian.Uint16(b[2:]))
+			if len(b) < 4+n {
+				return nil, fmt.Errorf("option %d: truncated value", code)
+			}
+			tlvs = append(tlvs, tlv{code: code, data: b[4 : 4+n]})
+			b = b[4+n:]
+		}
+		return tlvs, nil
+	}
+
+	index := map[int]int{}
+	for len(b) > 0 && b[0] != endOption {
+		if b[0] == padOption {
+			b = b[1:]
+			continue
+		}
+		if len(b) < 2 {
+			return nil, fmt.Errorf("truncated option header")
+		}
+		code, n := int(b[0]), int(b[1])
+		if len(b) < 2+n {
+			return nil, fmt.Errorf("option %d: truncated value", code)
+		}
+		if i, ok := index[code]; ok {
+			tlvs[i].data = append(tlvs[i].data, b[2:2+n]...)
+		} else {
+			index[code] = len(tlvs)
+			tlvs = append(tlvs, tlv{code: code, data: append([]byte{}, b[2:2+n]...)})
+		}
+		b = b[2+n:]
+	}
+	return tlvs, nil
+}
+
+func decodeValue(def *OptionDef, b []byte, v6 bool) (interface{}, error) {
+	switch def.Schema.Type {
+	case SchemaTypeArray:
+		if def.Schema.Items == nil {
+			return nil, fmt.Errorf("array definition lacks an item type")
+		}
+		values := []interface{}{}
+		for i := 0; len(b) > 0; i++ {
+			v, n, err := decodeItem(ItemType(*def.Schema.Items), b, v6)
+			if err != nil {
+				return nil, fmt.Errorf("item %d: %w", i, err)
+			}
+			if n == 0 {
+				return nil, fmt.Errorf("array of zero-width %s items", *def.Schema.Items)
+			}
+			values, b = append(values, v), b[n:]
+		}
+		return values, nil
+	case SchemaTypeRecord:
+		fields := def.Schema.Fields
+		multiple := def.Schema.MultipleFinalValue != nil && *def.Schema.MultipleFinalValue
+		if multiple && len(fields) == 0 {
+			return nil, fmt.Errorf("record definition with multiple final values lacks fields")
+		}
+		values := []interface{}{}
+		for i := 0; i < len(fields) || multiple && len(b) > 0; i++ {
+			f := fields[len(fields)-1]
+			if i < len(fields) {
+				f = fields[i]
+			}
+			v, n, err := decodeItem(f.Type, b, v6)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", f.Name, err)
+			}
+			if n == 0 && len(b) > 0 && multiple && i >= len(fields)-1 {
+				return nil, fmt.Errorf("field %s: multiple zero-width final values", f.Name)
+			}
+			values, b = append(values, v), b[n:]
+		}
+		if len(b) > 0 {
+			return nil, fmt.Errorf("%d trailing bytes", len(b))
+		}
+		return values, nil
+	default:
+		v, n, err := decodeItem(ItemType(def.Schema.Type), b, v6)
+		if err == nil && n < len(b) {
+			err = fmt.Errorf("%d trailing bytes", len(b)-n)
+		}
+		return v, err
+	}
+}
+
+// decodeItem decodes a value at the start of b, returning the number of
+// bytes read. String and binary values take the rest of b.
+func decodeItem(t ItemType, b []byte, v6 bool) (interface{}, int, error) {
+	if size, ok := itemSize(t); ok && len(b) < size {
+		return nil, 0, fmt.Errorf("truncated %s value", t)
+	}
+
+	switch t {
+	case ItemTypeIPv4Address:
+		return netip.AddrFrom4([4]byte(b[:4])).String(), 4, nil
+	case ItemTypeIPv6Address:
+		return netip.AddrFrom16([16]byte(b[:16])).String(), 16, nil
+	case ItemTypeIPv6Prefix:
+		p := netip.PrefixFrom(netip.AddrFrom16([16]byte(b[1:17])), int(b[0]))
+		if !p.IsValid() {
+			return nil, 0, fmt.Errorf("invalid prefix length %d", b[0])
+		}
+		return p.String(), 17, nil
+	case ItemTypeFQDN:
+		labels := []string{}
+		for i := 0; ; {
+			if i >= len(b) {
+				return nil, 0, fmt.Errorf("truncated fqdn value")
+			}
+			n := int(b[i])
+			if n == 0 {
+				if len(labels) == 0 {
+					return ".", i + 1, nil
+				}
+				return strings.Join(labels, "."), i + 1, nil
+			}
+			if n > 63 || i+1+n > len(b) {
+				return nil, 0, fmt.Errorf("invalid fqdn value")
+			}
+			labels = append(labels, string(b[i+1:i+1+n]))
+			i += 1 + n
+		}
+	case ItemTypeString:
+		return string(b), len(b), nil
+	case ItemTypeBinary:
+		return hex.EncodeToString(b), len(b), nil
+	case ItemTypeTuple:
+		size, n := 1, 0
+		if v6 {
+			size = 2
+		}
+		if len(b) < size {
+			return nil, 0, fmt.Errorf("truncated tuple value")
+		}
+		if v6 {
+			n = int(binary.BigEndian.Uint16(b))
+		} else {
+			n = int(b[0])
+		}
+		if len(b) < size+n {
+			return nil, 0, fmt.Errorf("truncated tuple value")
+		}
+		return string(b[size : size+n]), size + n, nil
+	case ItemTypePSID:
+		bits := uint(b[0])
+		if bits > 16 {
+			return nil, 0, fmt.Errorf("invalid psid length %d", bits)
+		}
+		psid := binary.BigEndian.Uint16(b[1:])
+		if bits < 16 {
+			psid >>= 16 - bits
+		}
+		if bits == 0 {
+			psid = 0
+		}
+		return fmt.Sprintf("%d/%d", psid, bits), 3, nil
+	case ItemTypeBoolean:
+		switch b[0] {
+		case 0:
+			return false, 1, nil
+		case 1:
+			return true, 1, nil
+		}
+		return nil, 0, fmt.Errorf("invalid boolean value %d", b[0])
+	case ItemTypeInt8:
+		return int(int8(b[0])), 1, nil
+	case ItemTypeUint8:
+		return int(b[0]), 1, nil
+	case ItemTypeInt16:
+		return int(int16(binary.BigEndian.Uint16(b))), 2, nil
+	case ItemTypeUint16:
+		return int(binary.BigEndian.Uint16(b)), 2, nil
+	case ItemTypeInt32:
+		return int(int32(binary.BigEndian.Uint32(b))), 4, nil
+	case ItemTypeUint32:
+		return int(binary.BigEndian.Uint32(b)), 4, nil
+	case ItemTypeEmpty:
+		return nil, 0, nil
+	}
+	return nil, 0, fmt.Errorf("unknown item type %q", t)
+}
+
+// itemSize returns the wire size of fixed size item types.
+func itemSize(t ItemType) (int, bool) {
+	switch t {
+	case ItemTypeIPv4Address, ItemTypeInt32, ItemTypeUint32:
+		return 4, true
+	case ItemTypeIPv6Address:
+		return 16, true
+	case ItemTypeIPv6Prefix:
+		return 17, true
+	case ItemTypePSID:
+		return 3, true
+	case ItemTypeInt16, ItemTypeUint16:
+		return 2, true
+	case ItemTypeBoolean, ItemTypeInt8, ItemTypeUint8:
+		return 1, true
+	case ItemTypeEmpty:
+		return 0, true
+	}
+	return 0, false
+}
+
+// fixedSize returns the wire size of the values of a definition, if fixed.
+func fixedSize(def *OptionDef) (int, bool) {
+	switch def.Schema.Type {
+	case SchemaTypeArray:
+		return 0, false
+	case SchemaTypeRecord:
+		if def.Schema.MultipleFinalValue != nil && *def.Schema.MultipleFinalValue {
+			return 0, false
+		}
+		total := 0
+		for _, f := range def.Schema.Fields {
+			n, ok := itemSize(f.Type)
+			if !ok {
+				return 0, false
+			}
+			total += n
+		}
+		return total, true
+	default:
+		return itemSize(ItemType(def.Schema.Type))
+	}
+}
+
+func isV6(protocol string) (bool, error) {
+	switch protocol {
+	case SpaceV4:
+		return false, nil
+	case SpaceV6:
+		return true, nil
+	}
+	return false, fmt.Errorf("unknown protocol %q, expected %s or %s", protocol, SpaceV4, SpaceV6)
+}
+
+// optionSpace returns the space of an option name, e.g. "dhcpv4" for
+// "dhcpv4/routers".
+func optionSpace(name string) string {
+	if i := strings.Index(name, "/"); i >= 0 {
+		return name[:i]
+	}
+	return SpaceV4
+}
@@ -0,0 +1,189 @@
+package dhcp
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionCatalog_EncodeDecodeV4(t *testing.T) {
+	vendor, space := "vendor", SpaceV4
+	items := string(ItemTypeIPv4Address)
+	c := NewOptionCatalog(
+		OptionDef{Space: &space, FriendlyName: "vendor-encapsulated-options", Code: 43, Encapsulate: &vendor,
+			Schema: OptionDefSchema{Type: SchemaTypeEmpty}},
+		OptionDef{Space: &vendor, FriendlyName: "server", Code: 1, Schema: OptionDefSchema{Type: SchemaTypeArray, Items: &items}},
+		OptionDef{Space: &vendor, FriendlyName: "mode", Code: 2, Schema: OptionDefSchema{Type: SchemaTypeUint16}},
+	)
+
+	options := OptionSet{
+		{Name: "dhcpv4/routers", Value: []interface{}{"192.0.2.1", "192.0.2.2"}},
+		{Name: "vendor/server", Value: []string{"198.51.100.1"}},
+		{Name: "dhcpv4/domain-search", Value: []interface{}{"example.com", "corp.example.com."}},
+		{Name: "dhcpv4/vendor-encapsulated-options", Value: nil},
+		{Name: "vendor/mode", Value: 258},
+		{Name: "dhcpv4/ip-forwarding", Value: true},
+		{Name: "dhcpv4/time-offset", Value: -3600},
+		{Name: "dhcpv4/boot-file-name", Value: "pxe.efi"},
+	}
+	b, err := c.Encode(SpaceV4, options)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"0308c0000201c0000202",
+		"771f076578616d706c6503636f6d0004636f7270076578616d706c6503636f6d00",
+		"2b0a0104c6336401020201" + "02",
+		"130101",
+		"0204fffff1f0",
+		"43077078652e656669",
+	}, ""), hex.EncodeToString(b))
+
+	// Pad and end options are skipped.
+	decoded, err := c.Decode(SpaceV4, append(append([]byte{0, 0}, b...), 255, 3, 4))
+	require.NoError(t, err)
+	assert.Equal(t, OptionSet{
+		{Name: "dhcpv4/routers", Value: []interface{}{"192.0.2.1", "192.0.2.2"}},
+		{Name: "dhcpv4/domain-search", Value: []interface{}{"example.com", "corp.example.com"}},
+		{Name: "dhcpv4/vendor-encapsulated-options", Encapsulate: &vendor},
+		{Name: "vendor/server", Value: []interface{}{"198.51.100.1"}},
+		{Name: "vendor/mode", Value: 258},
+		{Name: "dhcpv4/ip-forwarding", Value: true},
+		{Name: "dhcpv4/time-offset", Value: -3600},
+		{Name: "dhcpv4/boot-file-name", Value: "pxe.efi"},
+	}, decoded)
+}
+
+func TestOptionCatalog_EncodeDecodeLongV4(t *testing.T) {
+	c := NewOptionCatalog()
+	routers := []interface{}{}
+	for i := 0; i < 70; i++ {
+		routers = append(routers, "192.0.2.1")
+	}
+
+	b, err := c.Encode(SpaceV4, OptionSet{{Name: "dhcpv4/routers", Value: routers}})
+	require.NoError(t, err)
+	require.Len(t, b, 2+255+2+25)
+	assert.Equal(t, []byte{3, 255}, b[:2])
+	assert.Equal(t, []byte{3, 25}, b[257:259])
+
+	decoded, err := c.Decode(SpaceV4, b)
+	require.NoError(t, err)
+	assert.Equal(t, OptionSet{{Name: "dhcpv4/routers", Value: routers}}, decoded)
+}
+
+func TestOptionCatalog_EncodeDecodeV6(t *testing.T) {
+	vendor, space := "vendor-4491", SpaceV6
+	multiple := true
+	c := NewOptionCatalog(
+		OptionDef{Space: &space, FriendlyName: "vendor-opts", Code: 17, Encapsulate: &vendor,
+			Schema: OptionDefSchema{Type: SchemaTypeRecord, Fields: []OptionDefSchemaItems{{Name: "enterprise-number", Type: ItemTypeUint32}}}},
+		OptionDef{Space: &vendor, FriendlyName: "tftp", Code: 32, Schema: OptionDefSchema{Type: SchemaTypeRecord, MultipleFinalValue: &multiple,
+			Fields: []OptionDefSchemaItems{{Name: "name", Type: ItemTypeTuple}, {Name: "prefix", Type: ItemTypeIPv6Prefix}}}},
+		OptionDef{Space: &space, FriendlyName: "s46-portparams", Code: 93, Schema: OptionDefSchema{Type: SchemaTypeRecord,
+			Fields: []OptionDefSchemaItems{{Name: "offset", Type: ItemTypeUint8}, {Name: "psid", Type: ItemTypePSID}}}},
+	)
+
+	options := OptionSet{
+		{Name: "dhcpv6/dns-servers", Value: []interface{}{"2001:db8::53"}},
+		{Name: "dhcpv6/vendor-opts", Value: []interface{}{4491}},
+		{Name: "vendor-4491/tftp", Value: []interface{}{"tftp", "2001:db8::/32", "2001:db8:1::/48"}},
+		{Name: "dhcpv6/s46-portparams", Value: []interface{}{6, "5/4"}},
+	}
+	b, err := c.Encode(SpaceV6, options)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Join([]string{
+		"0017001020010db8000000000000000000000053",
+		"001100300000118b" + "00200028" + "000474667470" + "2020010db8000000000000000000000000" + "3020010db8000100000000000000000000",
+		"005d0004060450" + "00",
+	}, ""), hex.EncodeToString(b))
+
+	decoded, err := c.Decode(SpaceV6, b)
+	require.NoError(t, err)
+	options[1].Encapsulate = &vendor
+	assert.Equal(t, options, decoded)
+}
+
+func TestOptionCatalog_EncodeErrors(t *testing.T) {
+	c := NewOptionCatalog()
+	encapsulate := "vendor"
+	for name, options := range map[string]OptionSet{
+		"unknown option":    {{Name: "dhcpv4/nope", Value: 1}},
+		"invalid value":     {{Name: "dhcpv4/routers", Value: []interface{}{"2001:db8::1"}}},
+		"not encapsulated":  {{Name: "vendor/x", Value: 1}},
+		"encapsulated once": {{Name: "dhcpv4/vendor-encapsulated-options", Encapsulate: &encapsulate}, {Name: "dhcpv4/vendor-class-identifier", Value: "x", Encapsulate: &encapsulate}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := c.Encode(SpaceV4, options)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := c.Encode("dhcpv5", nil)
+	assert.Error(t, err)
+}
+
+func TestOptionCatalog_DecodeErrors(t *testing.T) {
+	c := NewOptionCatalog()
+
+	decoded, err := c.Decode(SpaceV4, []byte{224, 2, 0xab, 0xcd})
+	require.NoError(t, err)
+	assert.Equal(t, OptionSet{{Name: "dhcpv4/option-224", Value: "abcd"}}, decoded)
+
+	for name, tc := range map[string]struct {
+		protocol string
+		hex      string
+	}{
+		"truncated header":   {SpaceV4, "03"},
+		"truncated value":    {SpaceV4, "0308c0000201"},
+		"partial address":    {SpaceV4, "0303c00002"},
+		"trailing bytes":     {SpaceV4, "1a03000100"},
+		"invalid boolean":    {SpaceV4, "130102"},
+		"truncated fqdn":     {SpaceV4, "7705076578616d"},
+		"truncated v6":       {SpaceV6, "001700"},
+		"truncated v6 value": {SpaceV6, "0017001020010db8"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			b, _ := hex.DecodeString(tc.hex)
+			_, err := c.Decode(tc.protocol, b)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestOptionCatalog_DecodeZeroWidth(t *testing.T) {
+	space, multiple := SpaceV4, true
+	empty := string(ItemTypeEmpty)
+	c := NewOptionCatalog(
+		OptionDef{Space: &space, FriendlyName: "empties", Code: 200,
+			Schema: OptionDefSchema{Type: SchemaTypeArray, Items: &empty}},
+		OptionDef{Space: &space, FriendlyName: "fieldless", Code: 201,
+			Schema: OptionDefSchema{Type: SchemaTypeRecord, MultipleFinalValue: &multiple}},
+		OptionDef{Space: &space, FriendlyName: "empty-tail", Code: 202,
+			Schema: OptionDefSchema{Type: SchemaTypeRecord, MultipleFinalValue: &multiple,
+				Fields: []OptionDefSchemaItems{{Name: "flag", Type: ItemTypeUint8}, {Name: "rest", Type: ItemTypeEmpty}}}},
+	)
+
+	for _, hexOption := range []string{"c80101", "c90101", "c9020102", "ca020102"} {
+		b, _ := hex.DecodeString(hexOption)
+		_, err := c.Decode(SpaceV4, b)
+		assert.Error(t, err, hexOption)
+	}
+}
+
+func TestOptionCatalog_EncodeDecodeRootFQDN(t *testing.T) {
+	c := NewOptionCatalog()
+	options := OptionSet{{Name: "dhcpv4/domain-search", Value: []interface{}{".", "example.com"}}}
+	b, err := c.Encode(SpaceV4, options)
+	require.NoError(t, err)
+	assert.Equal(t, "770e00076578616d706c6503636f6d00", hex.EncodeToString(b))
+
+	decoded, err := c.Decode(SpaceV4, b)
+	require.NoError(t, err)
+	assert.Equal(t, options, decoded)
+
+	b, err = appendItem(nil, ItemTypeFQDN, "", false)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0}, b)
+}
@@ -0,0 +1,349 @@
+package dns
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// DNSKEY flags (RFC 4034 section 2.1.1 and RFC 5011).
+const (
+	DNSKEYFlagZone   = 0x0100
+	DNSKEYFlagRevoke = 0x0080
+	DNSKEYFlagSEP    = 0x0001
+)
+
+// DNSSEC algorithm numbers.
+const (
+	AlgorithmRSAMD5           = 1
+	AlgorithmRSASHA1          = 5
+	AlgorithmRSASHA1NSEC3SHA1 = 7
+	AlgorithmRSASHA256        = 8
+	AlgorithmRSASHA512        = 10
+	AlgorithmECDSAP256SHA256  = 13
+	AlgorithmECDSAP384SHA384  = 14
+	AlgorithmED25519          = 15
+	AlgorithmED448            = 16
+)
+
+// DS digest types.
+const (
+	DigestTypeSHA1   = 1
+	DigestTypeSHA256 = 2
+	DigestTypeSHA384 = 4
+)
+
+var algorithmNames = map[string]uint8{
+	"RSAMD5":             AlgorithmRSAMD5,
+	"RSASHA1":            AlgorithmRSASHA1,
+	"RSASHA1-NSEC3-SHA1": AlgorithmRSASHA1NSEC3SHA1,
+	"RSASHA256":          AlgorithmRSASHA256,
+	"RSASHA512":          AlgorithmRSASHA512,
+	"ECDSAP256SHA256":    AlgorithmECDSAP256SHA256,
+	"ECDSAP384SHA384":    AlgorithmECDSAP384SHA384,
+	"ED25519":            AlgorithmED25519,
+	"ED448":              AlgorithmED448,
+}
+
+// DNSKEY is a parsed DNSKEY record.
+type DNSKEY struct {
+	Flags     uint16
+	Protocol  uint8
+	Algorithm uint8
+	PublicKey []byte
+}
+
+// DS is a delegation signer record.
+type DS struct {
+	KeyTag     uint16
+	Algorithm  uint8
+	DigestType uint8
+	Digest     []byte
+}
+
+// DNSKEY parses the key. Flags and protocol must be numbers; the algorithm
+// may be a number or a mnemonic such as "ECDSAP256SHA256"; the public key is
+// base64, and may be split by whitespace.
+func (k *Key) DNSKEY() (*DNSKEY, error) {
+	flags, err := strconv.ParseUint(strings.TrimSpace(k.Flags), 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNSKEY flags %q", k.Flags)
+	}
+	protocol, err := strconv.ParseUint(strings.TrimSpace(k.Protocol), 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNSKEY protocol %q", k.Protocol)
+	}
+	if protocol != 3 {
+		return nil, fmt.Errorf("DNSKEY protocol must be 3, got %d", protocol)
+	}
+	alg, err := parseAlgorithm(k.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(k.PublicKey), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DNSKEY public key: %v", err)
+	}
+	if len(pub) == 0 {
+		return nil, errors.New("DNSKEY public key is empty")
+	}
+
+	return &DNSKEY{
+		Flags:     uint16(flags),
+		Protocol:  uint8(protocol),
+		Algorithm: alg,
+		PublicKey: pub,
+	}, nil
+}
+
+// DS parses a key holding DS fields, as found in Delegation.DS: key tag,
+// algorithm, digest type and hex digest in place of flags, protocol,
+// algorithm and public key.
+func (k *Key) DS() (*DS, error) {
+	tag, err := strconv.ParseUint(strings.TrimSpace(k.Flags), 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DS key tag %q", k.Flags)
+	}
+	alg, err := parseAlgorithm(k.Protocol)
+	if err != nil {
+		return nil, err
+	}
+	digestType, err := strconv.ParseUint(strings.TrimSpace(k.Algorithm), 10, 8)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DS digest type %q", k.Algorithm)
+	}
+	digest, err := hex.DecodeString(strings.Join(strings.Fields(k.PublicKey), ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid DS digest: %v", err)
+	}
+
+	return &DS{KeyTag: uint16(tag), Algorithm: alg, DigestType: uint8(digestType), Digest: digest}, nil
+}
+
+func parseAlgorithm(s string) (uint8, error) {
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseUint(s, 10, 8); err == nil {
+		return uint8(n), nil
+	}
+	if n, ok := algorithmNames[strings.ToUpper(s)]; ok {
+		return n, nil
+	}
+	return 0, fmt.Errorf("invalid DNSSEC algorithm %q", s)
+}
+
+// IsZoneKey reports whether the key has the zone key flag, which is
+// required for signing zone data.
+func (k *DNSKEY) IsZoneKey() bool {
+	return k.Flags&DNSKEYFlagZone != 0
+}
+
+// IsKSK reports whether the key is a key signing key: a zone key with the
+// secure entry point flag (flags 257).
+func (k *DNSKEY) IsKSK() bool {
+	return k.IsZoneKey() && k.Flags&DNSKEYFlagSEP != 0
+}
+
+// IsZSK reports whether the key is a zone signing key: a zone key without
+// the secure entry point flag (flags 256).
+func (k *DNSKEY) IsZSK() bool {
+	return k.IsZoneKey() && k.Flags&DNSKEYFlagSEP == 0
+}
+
+// IsRevoked reports whether the key has the revoke flag.
+func (k *DNSKEY) IsRevoked() bool {
+	return k.Flags&DNSKEYFlagRevoke != 0
+}
+
+// RData returns the key in wire format.
+func (k *DNSKEY) RData() []byte {
+	b := make([]byte, 4, 4+len(k.PublicKey))
+	binary.BigEndian.PutUint16(b, k.Flags)
+	b[2] = k.Protocol
+	b[3] = k.Algorithm
+	return append(b, k.PublicKey...)
+}
+
+// KeyTag computes the key tag of the key (RFC 4034 appendix B).
+func (k *DNSKEY) KeyTag() uint16 {
+	if k.Algorithm == AlgorithmRSAMD5 {
+		// The key tag of RSA/MD5 keys is taken from the modulus.
+		if n := len(k.PublicKey); n >= 3 {
+			return binary.BigEndian.Uint16(k.PublicKey[n-3 : n-1])
+		}
+		return 0
+	}
+
+	var ac uint32
+	for i, b := range k.RData() {
+		if i&1 == 0 {
+			ac += uint32(b) << 8
+		} else {
+			ac += uint32(b)
+		}
+	}
+	ac += ac >> 16 & 0xFFFF
+	return uint16(ac & 0xFFFF)
+}
+
+// DS computes the DS record of the key for the given owner name (the zone)
+// and digest type.
+func (k *DNSKEY) DS(owner string, digestType uint8) (*DS, error) {
+	if !k.IsZoneKey() {
+		return nil, errors.New("DS records can only be computed for zone keys")
+	}
+
+	var h hash.Hash
+	switch digestType {
+	case DigestTypeSHA1:
+		h = sha1.New()
+	case DigestTypeSHA256:
+		h = sha256.New()
+	case DigestTypeSHA384:
+		h = sha512.New384()
+	default:
+		return nil, fmt.Errorf("unsupported DS digest type %d", digestType)
+	}
+
+	name, err := wireName(owner)
+	if err != nil {
+		return nil, err
+	}
+	h.Write(name)
+	h.Write(k.RData())
+
+	return &DS{
+		KeyTag:     k.KeyTag(),
+		Algorithm:  k.Algorithm,
+		DigestType: digestType,
+		Digest:     h.Sum(nil),
+	}, nil
+}
+
+// Matches reports whether the DS record was computed from the key for the
+// given owner name.
+func (d *DS) Matches(owner string, k *DNSKEY) bool {
+	if d.KeyTag != k.KeyTag() || d.Algorithm != k.Algorithm {
+		return false
+	}
+	computed, err := k.DS(owner, d.DigestType)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(computed.Digest, d.Digest)
+}
+
+// String renders the key's rdata in presentation format, e.g.
+// "257 3 13 mdsswUyr3DPW...".
+func (k *DNSKEY) String() string {
+	return fmt.Sprintf("%d %d %d %s", k.Flags, k.Protocol, k.Algorithm, base64.StdEncoding.EncodeToString(k.PublicKey))
+}
+
+// RR renders the key as a complete DNSKEY record in presentation format.
+func (k *DNSKEY) RR(owner string, ttl int) string {
+	return fmt.Sprintf("%s %d IN DNSKEY %s", fqdn(owner), ttl, k)
+}
+
+// String renders the DS rdata in presentation format, as registrars
+// usually expect it, e.g. "2371 13 2 1F987CC6...".
+func (d *DS) String() string {
+	return fmt.Sprintf("%d %d %d %s", d.KeyTag, d.Algorithm, d.DigestType, strings.ToUpper(hex.EncodeToString(d.Digest)))
+}
+
+// RR renders a complete DS record in presentation format.
+func (d *DS) RR(owner string, ttl int) string {
+	return fmt.Sprintf("%s %d IN DS %s", fqdn(owner), ttl, d)
+}
+
+// DNSKEYs parses the zone's DNSKEY records.
+func (d *ZoneDNSSEC) DNSKEYs() ([]*DNSKEY, error) {
+	if d.Keys == nil {
+		return []*DNSKEY{}, nil
+	}
+	keys := make([]*DNSKEY, 0, len(d.Keys.DNSKey))
+	for i, k := range d.Keys.DNSKey {
+		key, err := k.DNSKEY()
+		if err != nil {
+			return nil, fmt.Errorf("dnskey %d: %w", i, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// KSKs returns the zone's key signing keys, which are the ones a parent
+// zone's DS records must point at.
+func (d *ZoneDNSSEC) KSKs() ([]*DNSKEY, error) {
+	keys, err := d.DNSKEYs()
+	if err != nil {
+		return nil, err
+	}
+	ksks := []*DNSKEY{}
+	for _, k := range keys {
+		if k.IsKSK() && !k.IsRevoked() {
+			ksks = append(ksks, k)
+		}
+	}
+	return ksks, nil
+}
+
+// DSRecords computes the DS records of the zone's key signing keys, for
+// submission to the registrar, in each of the given digest types. SHA-256
+// is used if no digest type is given.
+func (d *ZoneDNSSEC) DSRecords(digestTypes ...uint8) ([]*DS, error) {
+	if len(digestTypes) == 0 {
+		digestTypes = []uint8{DigestTypeSHA256}
+	}
+	ksks, err := d.KSKs()
+	if err != nil {
+		return nil, err
+	}
+
+	ds := []*DS{}
+	for _, k := range ksks {
+		for _, t := range digestTypes {
+			r, err := k.DS(d.Zone, t)
+			if err != nil {
+				return nil, err
+			}
+			ds = append(ds, r)
+		}
+	}
+	return ds, nil
+}
+
+// wireName returns the canonical (lower cased) wire format of a domain name.
+func wireName(name string) ([]byte, error) {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "" {
+		return []byte{0}, nil
+	}
+
+	b := make([]byte, 0, len(name)+2)
+	for _, label := range strings.Split(name, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("invalid domain name %q", name)
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	if len(b)+1 > 255 {
+		return nil, fmt.Errorf("domain name too long: %q", name)
+	}
+	return append(b, 0), nil
+}
+
+func fqdn(name string) string {
+	if strings.HasSuffix(name, ".") {
+		return name
+	}
+	return name + "."
+}
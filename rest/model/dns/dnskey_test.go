@@ -0,0 +1,107 @@
+package dns
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The key and digests of RFC 4034 section 5.4 and RFC 4509 section 2.2.1.
+var rfcKey = &Key{
+	Flags:     "256",
+	Protocol:  "3",
+	Algorithm: "5",
+	PublicKey: "AQOeiiR0GOMYkDshWoSKz9XzfwJr1AYtsmx3TGkJaNXVbfi/ 2pHm822aJ5iI9BMzNXxeYCmZDRD99WYwYqUSdjMmmAphXdvx egXd/M5+X7OrzKBaMbCVdFLUUh6DhweJBjEVv5f2wwjM9Xzc nOf+EPbtG9DMBmADjFDc2w/rljwvFw==",
+}
+
+func TestKeyDNSKEY(t *testing.T) {
+	k, err := rfcKey.DNSKEY()
+	require.NoError(t, err)
+
+	assert.Equal(t, uint16(256), k.Flags)
+	assert.Equal(t, uint8(AlgorithmRSASHA1), k.Algorithm)
+	assert.True(t, k.IsZSK())
+	assert.False(t, k.IsKSK())
+	assert.Equal(t, uint16(60485), k.KeyTag())
+
+	sha1DS, err := k.DS("dskey.example.com.", DigestTypeSHA1)
+	require.NoError(t, err)
+	assert.Equal(t, "60485 5 1 2BB183AF5F22588179A53B0A98631FAD1A292118", sha1DS.String())
+
+	sha256DS, err := k.DS("DSKEY.example.com", DigestTypeSHA256)
+	require.NoError(t, err)
+	assert.Equal(t, "dskey.example.com. 86400 IN DS 60485 5 2 D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A",
+		sha256DS.RR("dskey.example.com", 86400))
+	assert.True(t, sha256DS.Matches("dskey.example.com", k))
+	assert.False(t, sha256DS.Matches("other.example.com", k))
+
+	sha384DS, err := k.DS("dskey.example.com", DigestTypeSHA384)
+	require.NoError(t, err)
+	assert.Len(t, sha384DS.Digest, 48)
+
+	_, err = k.DS("dskey.example.com", 3)
+	assert.Error(t, err)
+}
+
+func TestKeyDNSKEYInvalid(t *testing.T) {
+	for name, key := range map[string]*Key{
+		"flags":     {Flags: "x", Protocol: "3", Algorithm: "13", PublicKey: "AQID"},
+		"protocol":  {Flags: "257", Protocol: "2", Algorithm: "13", PublicKey: "AQID"},
+		"algorithm": {Flags: "257", Protocol: "3", Algorithm: "NOPE", PublicKey: "AQID"},
+		"key":       {Flags: "257", Protocol: "3", Algorithm: "13", PublicKey: "!!"},
+		"empty":     {Flags: "257", Protocol: "3", Algorithm: "13", PublicKey: ""},
+	} {
+		_, err := key.DNSKEY()
+		assert.Error(t, err, name)
+	}
+}
+
+func TestKeyDS(t *testing.T) {
+	key := &Key{Flags: "60485", Protocol: "RSASHA1", Algorithm: "1", PublicKey: "2BB183AF5F22588179A53B0A98631FAD1A292118"}
+	ds, err := key.DS()
+	require.NoError(t, err)
+	assert.Equal(t, uint16(60485), ds.KeyTag)
+	assert.Equal(t, uint8(AlgorithmRSASHA1), ds.Algorithm)
+	assert.Equal(t, "2bb183af5f22588179a53b0a98631fad1a292118", hex.EncodeToString(ds.Digest))
+
+	k, err := rfcKey.DNSKEY()
+	require.NoError(t, err)
+	assert.True(t, ds.Matches("dskey.example.com", k))
+}
+
+func TestZoneDNSSECDSRecords(t *testing.T) {
+	ksk := *rfcKey
+	ksk.Flags = "257"
+	revoked := *rfcKey
+	revoked.Flags = "385"
+	d := &ZoneDNSSEC{
+		Zone: "dskey.example.com",
+		Keys: &Keys{DNSKey: []*Key{&ksk, rfcKey, &revoked}, TTL: 3600},
+	}
+
+	keys, err := d.DNSKEYs()
+	require.NoError(t, err)
+	require.Len(t, keys, 3)
+	assert.True(t, strings.HasPrefix(keys[0].RR(d.Zone, d.Keys.TTL), "dskey.example.com. 3600 IN DNSKEY 257 3 5 AQOeiiR0"))
+
+	ksks, err := d.KSKs()
+	require.NoError(t, err)
+	require.Len(t, ksks, 1)
+	assert.Equal(t, uint16(257), ksks[0].Flags)
+
+	ds, err := d.DSRecords()
+	require.NoError(t, err)
+	require.Len(t, ds, 1)
+	assert.Equal(t, uint8(DigestTypeSHA256), ds[0].DigestType)
+	assert.Equal(t, ksks[0].KeyTag(), ds[0].KeyTag)
+
+	ds, err = d.DSRecords(DigestTypeSHA1, DigestTypeSHA384)
+	require.NoError(t, err)
+	assert.Len(t, ds, 2)
+
+	_, err = (&ZoneDNSSEC{Keys: &Keys{DNSKey: []*Key{{Flags: "x"}}}}).DNSKEYs()
+	assert.Error(t, err)
+}
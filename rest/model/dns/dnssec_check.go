@@ -0,0 +1,301 @@
+package dns
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxDSTTL is the parent DS TTL beyond which key rollovers become slow to
+// take effect.
+const maxDSTTL = 86400
+
+// ParentDS is a DS record published in the parent zone.
+type ParentDS struct {
+	// Owner and TTL are empty when the record was given as bare rdata.
+	Owner string
+	TTL   int
+
+	*DS
+}
+
+// ParseDS parses DS records in presentation format, one per line, as
+// printed by e.g. "dig +noall +answer DS example.com". Lines may be complete
+// records ("example.com. 3600 IN DS 2371 13 2 1F98...") or bare rdata
+// ("2371 13 2 1F98..."). Digests may be split by whitespace; blank lines
+// and ';' comments are ignored.
+func ParseDS(text string) ([]*ParentDS, error) {
+	records := []*ParentDS{}
+
+	s := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; s.Scan(); line++ {
+		fields := strings.Fields(strings.SplitN(s.Text(), ";", 2)[0])
+		if len(fields) == 0 {
+			continue
+		}
+
+		r := &ParentDS{}
+		for i, f := range fields {
+			if strings.EqualFold(f, "DS") {
+				// The owner is optional; a leading number is the TTL.
+				head := fields[:i]
+				if _, err := strconv.Atoi(fields[0]); i > 0 && err != nil {
+					r.Owner = fields[0]
+					head = head[1:]
+				}
+				for _, g := range head {
+					if ttl, err := strconv.Atoi(g); err == nil {
+						r.TTL = ttl
+					}
+				}
+				fields = fields[i+1:]
+				break
+			}
+		}
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("line %d: DS record needs key tag, algorithm, digest type and digest", line)
+		}
+
+		ds, err := (&Key{
+			Flags:     fields[0],
+			Protocol:  fields[1],
+			Algorithm: fields[2],
+			PublicKey: strings.Join(fields[3:], ""),
+		}).DS()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		r.DS = ds
+		records = append(records, r)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+// DelegationIssueType identifies a delegation problem.
+type DelegationIssueType string
+
+const (
+	// IssueNoDS is DNSSEC enabled on the zone while the parent publishes
+	// no DS.
+	IssueNoDS = DelegationIssueType("no_ds")
+	// IssueUnsignedZone is DS records published by the parent while DNSSEC
+	// is disabled on the zone.
+	IssueUnsignedZone = DelegationIssueType("unsigned_zone")
+	// IssueMissingDS is a key signing key without DS at the parent.
+	IssueMissingDS = DelegationIssueType("missing_ds")
+	// IssueStaleDS is a DS matching none of the zone's keys, e.g. that of a
+	// retired key.
+	IssueStaleDS = DelegationIssueType("stale_ds")
+	// IssueDSOnZSK is a DS validly matching a zone signing key rather than a
+	// key signing key. It works, but breaks on the next ZSK rollover.
+	IssueDSOnZSK = DelegationIssueType("ds_on_zsk")
+	// IssueAlgorithmMismatch is a DS with the key tag of a zone key but
+	// another algorithm.
+	IssueAlgorithmMismatch = DelegationIssueType("algorithm_mismatch")
+	// IssueDigestMismatch is a DS with the key tag and algorithm of a zone
+	// key but a digest that does not match.
+	IssueDigestMismatch = DelegationIssueType("digest_mismatch")
+	// IssueWeakDigest is a DS using the deprecated SHA-1 digest.
+	IssueWeakDigest = DelegationIssueType("weak_digest")
+	// IssueTTL is parent DS TTLs that are inconsistent, differ from the
+	// zone's delegation TTL or are too long.
+	IssueTTL = DelegationIssueType("ttl")
+)
+
+// Severity is how serious a delegation issue is.
+type Severity string
+
+const (
+	// SeverityError issues break or risk breaking validation of the zone.
+	SeverityError = Severity("error")
+	// SeverityWarning issues should be fixed but do not break validation.
+	SeverityWarning = Severity("warning")
+)
+
+// DelegationIssue is a problem found by CheckDelegation.
+type DelegationIssue struct {
+	Type     DelegationIssueType
+	Severity Severity
+
+	// KeyTag is the tag of the key or DS the issue is about, if any.
+	KeyTag uint16
+
+	Message string
+}
+
+func (i *DelegationIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Severity, i.Message)
+}
+
+// DelegationReport is the result of CheckDelegation.
+type DelegationReport struct {
+	Zone string
+
+	// Valid holds the parent DS records matching a key of the zone.
+	Valid  []*ParentDS
+	Issues []*DelegationIssue
+}
+
+// OK reports whether no error was found. Warnings are allowed.
+func (r *DelegationReport) OK() bool {
+	return len(r.Errors()) == 0
+}
+
+// Errors returns the issues of error severity.
+func (r *DelegationReport) Errors() []*DelegationIssue {
+	errs := []*DelegationIssue{}
+	for _, i := range r.Issues {
+		if i.Severity == SeverityError {
+			errs = append(errs, i)
+		}
+	}
+	return errs
+}
+
+func (r *DelegationReport) add(t DelegationIssueType, sev Severity, tag uint16, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, &DelegationIssue{
+		Type:     t,
+		Severity: sev,
+		KeyTag:   tag,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// CheckDelegation checks the DS records published at the parent against
+// the zone's DNSSEC keys, as returned by DNSSECService.Get. z is used to
+// know whether DNSSEC is enabled on the zone; d may be nil if it is not.
+//
+// Problems that leave no valid chain of trust, such as a DS set with no DS
+// matching a current key signing key, are errors; problems that leave at
+// least one valid DS in place, e.g. a leftover DS from a key rollover, are
+// warnings.
+func CheckDelegation(z *Zone, d *ZoneDNSSEC, parent []*ParentDS) (*DelegationReport, error) {
+	r := &DelegationReport{Zone: z.Zone, Valid: []*ParentDS{}, Issues: []*DelegationIssue{}}
+
+	enabled := z.DNSSEC != nil && *z.DNSSEC
+	if !enabled {
+		if len(parent) > 0 {
+			r.add(IssueUnsignedZone, SeverityError, 0,
+				"parent publishes %d DS record(s) but DNSSEC is disabled on %s; validating resolvers will fail to resolve it",
+				len(parent), z.Zone)
+		}
+		return r, nil
+	}
+	if len(parent) == 0 {
+		r.add(IssueNoDS, SeverityError, 0,
+			"DNSSEC is enabled on %s but the parent publishes no DS record; the zone is not validated", z.Zone)
+		return r, nil
+	}
+
+	var keys []*DNSKEY
+	if d != nil {
+		var err error
+		if keys, err = d.DNSKEYs(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sort out the parent DS set first, to know whether any DS is valid.
+	type finding struct {
+		t   DelegationIssueType
+		ds  *ParentDS
+		msg string
+	}
+	findings := []finding{}
+	covered := map[*DNSKEY]bool{}
+	for _, ds := range parent {
+		var sameTag, sameAlg []*DNSKEY
+		for _, k := range keys {
+			if k.KeyTag() == ds.KeyTag {
+				sameTag = append(sameTag, k)
+				if k.Algorithm == ds.Algorithm {
+					sameAlg = append(sameAlg, k)
+				}
+			}
+		}
+
+		var match *DNSKEY
+		for _, k := range sameAlg {
+			if ds.Matches(z.Zone, k) {
+				match = k
+				break
+			}
+		}
+
+		switch {
+		case match != nil:
+			covered[match] = true
+			r.Valid = append(r.Valid, ds)
+			if !match.IsKSK() {
+				r.add(IssueDSOnZSK, SeverityWarning, ds.KeyTag,
+					"DS %d points at a zone signing key, which is replaced more often than key signing keys", ds.KeyTag)
+			}
+			if ds.DigestType == DigestTypeSHA1 {
+				r.add(IssueWeakDigest, SeverityWarning, ds.KeyTag,
+					"DS %d uses the deprecated SHA-1 digest; publish a SHA-256 DS instead", ds.KeyTag)
+			}
+		case len(sameAlg) > 0:
+			findings = append(findings, finding{IssueDigestMismatch, ds,
+				fmt.Sprintf("DS %d digest does not match the zone key with that tag", ds.KeyTag)})
+		case len(sameTag) > 0:
+			findings = append(findings, finding{IssueAlgorithmMismatch, ds,
+				fmt.Sprintf("DS %d has algorithm %d but the zone key with that tag uses algorithm %d",
+					ds.KeyTag, ds.Algorithm, sameTag[0].Algorithm)})
+		default:
+			findings = append(findings, finding{IssueStaleDS, ds,
+				fmt.Sprintf("DS %d matches no key of the zone, e.g. a retired key", ds.KeyTag)})
+		}
+	}
+
+	sev := SeverityWarning
+	if len(r.Valid) == 0 {
+		sev = SeverityError
+	}
+	for _, f := range findings {
+		r.add(f.t, sev, f.ds.KeyTag, "%s", f.msg)
+	}
+	for _, k := range keys {
+		if k.IsKSK() && !k.IsRevoked() && !covered[k] {
+			r.add(IssueMissingDS, sev, k.KeyTag(),
+				"key signing key %d (algorithm %d) has no DS at the parent", k.KeyTag(), k.Algorithm)
+		}
+	}
+	if len(r.Valid) == 0 && len(keys) == 0 {
+		r.add(IssueMissingDS, SeverityError, 0, "%s has no DNSKEY to match the parent DS records against", z.Zone)
+	}
+
+	checkDSTTLs(r, d, parent)
+	return r, nil
+}
+
+func checkDSTTLs(r *DelegationReport, d *ZoneDNSSEC, parent []*ParentDS) {
+	seen := map[int]bool{}
+	ttls := []int{}
+	for _, ds := range parent {
+		if ds.TTL > 0 && !seen[ds.TTL] {
+			seen[ds.TTL] = true
+			ttls = append(ttls, ds.TTL)
+		}
+	}
+	sort.Ints(ttls)
+	if len(ttls) > 1 {
+		r.add(IssueTTL, SeverityWarning, 0, "parent DS records have %d different TTLs", len(ttls))
+	}
+
+	for _, ttl := range ttls {
+		if ttl > maxDSTTL {
+			r.add(IssueTTL, SeverityWarning, 0,
+				"parent DS TTL %d exceeds %d, which slows down key rollovers", ttl, maxDSTTL)
+		}
+		if d != nil && d.Delegation != nil && d.Delegation.TTL > 0 && ttl != d.Delegation.TTL {
+			r.add(IssueTTL, SeverityWarning, 0,
+				"parent DS TTL %d differs from the delegation TTL %d", ttl, d.Delegation.TTL)
+		}
+	}
+}
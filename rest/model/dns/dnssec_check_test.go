@@ -0,0 +1,134 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func issueTypes(r *DelegationReport) []DelegationIssueType {
+	types := []DelegationIssueType{}
+	for _, i := range r.Issues {
+		types = append(types, i.Type)
+	}
+	return types
+}
+
+func TestParseDS(t *testing.T) {
+	ds, err := ParseDS(`
+; parent DS set
+dskey.example.com. 3600 IN DS 60485 5 2 D4B7D520E7BB5F0F67674A0CCEB1E3E0614B93C4F9E99B8383F6A1E4469DA50A
+60485 RSASHA1 1 2BB183AF5F22588179A5 3B0A98631FAD1A292118 ; split digest
+`)
+	require.NoError(t, err)
+	require.Len(t, ds, 2)
+	assert.Equal(t, "dskey.example.com.", ds[0].Owner)
+	assert.Equal(t, 3600, ds[0].TTL)
+	assert.Equal(t, uint8(DigestTypeSHA256), ds[0].DigestType)
+	assert.Equal(t, "", ds[1].Owner)
+	assert.Equal(t, "60485 5 1 2BB183AF5F22588179A53B0A98631FAD1A292118", ds[1].DS.String())
+
+	ds, err = ParseDS("7200 IN DS 60485 5 1 2BB183AF5F22588179A53B0A98631FAD1A292118")
+	require.NoError(t, err)
+	require.Len(t, ds, 1)
+	assert.Equal(t, "", ds[0].Owner)
+	assert.Equal(t, 7200, ds[0].TTL)
+	assert.Equal(t, uint16(60485), ds[0].KeyTag)
+
+	_, err = ParseDS("example.com. IN DS 60485 5")
+	assert.Error(t, err)
+	_, err = ParseDS("60485 5 2 XYZ")
+	assert.Error(t, err)
+}
+
+func TestCheckDelegation(t *testing.T) {
+	enabled, disabled := true, false
+	kskKey := *rfcKey
+	kskKey.Flags = "257"
+	ksk, err := kskKey.DNSKEY()
+	require.NoError(t, err)
+
+	zone := &Zone{Zone: "dskey.example.com", DNSSEC: &enabled}
+	d := &ZoneDNSSEC{
+		Zone:       "dskey.example.com",
+		Keys:       &Keys{DNSKey: []*Key{&kskKey, rfcKey}, TTL: 3600},
+		Delegation: &Delegation{TTL: 3600},
+	}
+	good, err := ksk.DS(zone.Zone, DigestTypeSHA256)
+	require.NoError(t, err)
+
+	t.Run("valid", func(t *testing.T) {
+		r, err := CheckDelegation(zone, d, []*ParentDS{{TTL: 3600, DS: good}})
+		require.NoError(t, err)
+		assert.True(t, r.OK())
+		assert.Empty(t, r.Issues)
+		assert.Len(t, r.Valid, 1)
+	})
+
+	t.Run("no ds", func(t *testing.T) {
+		r, err := CheckDelegation(zone, d, nil)
+		require.NoError(t, err)
+		assert.False(t, r.OK())
+		assert.Equal(t, []DelegationIssueType{IssueNoDS}, issueTypes(r))
+	})
+
+	t.Run("unsigned zone", func(t *testing.T) {
+		r, err := CheckDelegation(&Zone{Zone: "dskey.example.com", DNSSEC: &disabled}, nil, []*ParentDS{{DS: good}})
+		require.NoError(t, err)
+		assert.False(t, r.OK())
+		assert.Equal(t, []DelegationIssueType{IssueUnsignedZone}, issueTypes(r))
+	})
+
+	t.Run("stale ds after rollover", func(t *testing.T) {
+		stale := &DS{KeyTag: 1234, Algorithm: AlgorithmECDSAP256SHA256, DigestType: DigestTypeSHA256, Digest: []byte{1}}
+		r, err := CheckDelegation(zone, d, []*ParentDS{{TTL: 3600, DS: good}, {TTL: 3600, DS: stale}})
+		require.NoError(t, err)
+		assert.True(t, r.OK())
+		assert.Equal(t, []DelegationIssueType{IssueStaleDS}, issueTypes(r))
+		assert.Equal(t, SeverityWarning, r.Issues[0].Severity)
+		assert.Equal(t, uint16(1234), r.Issues[0].KeyTag)
+	})
+
+	t.Run("ds on zsk", func(t *testing.T) {
+		zsk, err := rfcKey.DNSKEY()
+		require.NoError(t, err)
+		onZSK, err := zsk.DS(zone.Zone, DigestTypeSHA256)
+		require.NoError(t, err)
+
+		r, err := CheckDelegation(zone, d, []*ParentDS{{TTL: 3600, DS: good}, {TTL: 3600, DS: onZSK}})
+		require.NoError(t, err)
+		assert.True(t, r.OK())
+		assert.Len(t, r.Valid, 2)
+		assert.Equal(t, []DelegationIssueType{IssueDSOnZSK}, issueTypes(r))
+	})
+
+	t.Run("only stale ds", func(t *testing.T) {
+		stale := &DS{KeyTag: 1234, Algorithm: AlgorithmRSASHA1, DigestType: DigestTypeSHA256, Digest: []byte{1}}
+		r, err := CheckDelegation(zone, d, []*ParentDS{{DS: stale}})
+		require.NoError(t, err)
+		assert.False(t, r.OK())
+		assert.Equal(t, []DelegationIssueType{IssueStaleDS, IssueMissingDS}, issueTypes(r))
+		assert.Len(t, r.Errors(), 2)
+	})
+
+	t.Run("algorithm and digest mismatch", func(t *testing.T) {
+		wrongAlg := *good
+		wrongAlg.Algorithm = AlgorithmRSASHA256
+		wrongDigest := *good
+		wrongDigest.Digest = []byte{1, 2, 3}
+		r, err := CheckDelegation(zone, d, []*ParentDS{{DS: &wrongAlg}, {DS: &wrongDigest}})
+		require.NoError(t, err)
+		assert.False(t, r.OK())
+		assert.Equal(t, []DelegationIssueType{IssueAlgorithmMismatch, IssueDigestMismatch, IssueMissingDS}, issueTypes(r))
+	})
+
+	t.Run("ttl and weak digest", func(t *testing.T) {
+		sha1DS, err := ksk.DS(zone.Zone, DigestTypeSHA1)
+		require.NoError(t, err)
+		r, err := CheckDelegation(zone, d, []*ParentDS{{TTL: 172800, DS: good}, {TTL: 3600, DS: sha1DS}})
+		require.NoError(t, err)
+		assert.True(t, r.OK())
+		assert.Equal(t, []DelegationIssueType{IssueWeakDigest, IssueTTL, IssueTTL, IssueTTL}, issueTypes(r))
+	})
+}
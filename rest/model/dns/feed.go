@@ -0,0 +1,84 @@
+package dns
+
+import (
+	"sort"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/data"
+)
+
+// MetaFeedPtr locates a metadata field of a record, region or answer whose
+// value is supplied by a data feed.
+type MetaFeedPtr struct {
+	// Level is one of the data.DestinationType* values.
+	Level string
+
+	// Region is set for region level pointers.
+	Region string
+
+	// AnswerID and Answer are set for answer level pointers. Answer is the
+	// answer's rdata in string form.
+	AnswerID string
+	Answer   string
+
+	// Field is the json name of the meta field, e.g. "up".
+	Field  string
+	FeedID string
+}
+
+// FeedPtrs returns every metadata field of the record, its regions and its
+// answers that points at a data feed.
+func (r *Record) FeedPtrs() []*MetaFeedPtr {
+	ptrs := []*MetaFeedPtr{}
+
+	if r.Meta != nil {
+		for field, id := range r.Meta.FeedPtrs() {
+			ptrs = append(ptrs, &MetaFeedPtr{
+				Level:  data.DestinationTypeRecord,
+				Field:  field,
+				FeedID: id,
+			})
+		}
+	}
+
+	for name, region := range r.Regions {
+		for field, id := range region.Meta.FeedPtrs() {
+			ptrs = append(ptrs, &MetaFeedPtr{
+				Level:  data.DestinationTypeRegion,
+				Region: name,
+				Field:  field,
+				FeedID: id,
+			})
+		}
+	}
+
+	for _, a := range r.Answers {
+		if a.Meta == nil {
+			continue
+		}
+		for field, id := range a.Meta.FeedPtrs() {
+			ptrs = append(ptrs, &MetaFeedPtr{
+				Level:    data.DestinationTypeAnswer,
+				AnswerID: a.ID,
+				Answer:   a.String(),
+				Field:    field,
+				FeedID:   id,
+			})
+		}
+	}
+
+	sort.SliceStable(ptrs, func(i, j int) bool {
+		a, b := ptrs[i], ptrs[j]
+		if a.Level != b.Level {
+			return a.Level < b.Level
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.Answer != b.Answer {
+			return a.Answer < b.Answer
+		}
+		return a.Field < b.Field
+	})
+
+	return ptrs
+}
@@ -1,5 +1,13 @@
 package dns
 
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
 // TSIGKey wraps an NS1 /tsig resource
 type TSIGKey struct {
 	Name      string `json:"name,omitempty"`
@@ -16,3 +24,111 @@ func NewTsigKey(name string, algorithm string, secret string) *TSIGKey {
 	}
 	return &tsigKey
 }
+
+// TSIG algorithm names.
+const (
+	TSIGAlgorithmHMACMD5    = "hmac-md5"
+	TSIGAlgorithmHMACSHA1   = "hmac-sha1"
+	TSIGAlgorithmHMACSHA224 = "hmac-sha224"
+	TSIGAlgorithmHMACSHA256 = "hmac-sha256"
+	TSIGAlgorithmHMACSHA384 = "hmac-sha384"
+	TSIGAlgorithmHMACSHA512 = "hmac-sha512"
+)
+
+// tsigKeySizes maps algorithms to their hash output size in bytes, which is
+// the recommended secret length (RFC 4635 section 2).
+var tsigKeySizes = map[string]int{
+	TSIGAlgorithmHMACMD5:    16,
+	TSIGAlgorithmHMACSHA1:   20,
+	TSIGAlgorithmHMACSHA224: 28,
+	TSIGAlgorithmHMACSHA256: 32,
+	TSIGAlgorithmHMACSHA384: 48,
+	TSIGAlgorithmHMACSHA512: 64,
+}
+
+// NormalizeTsigAlgorithm returns the canonical name of a TSIG algorithm,
+// accepting any case, a trailing dot and the "hmac-md5.sig-alg.reg.int"
+// form of HMAC-MD5.
+func NormalizeTsigAlgorithm(algorithm string) (string, error) {
+	a := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(algorithm)), ".")
+	a = strings.TrimSuffix(a, ".sig-alg.reg.int")
+	if _, ok := tsigKeySizes[a]; !ok {
+		return "", fmt.Errorf("unsupported TSIG algorithm %q", algorithm)
+	}
+	return a, nil
+}
+
+// GenerateTsigSecret returns a random base64 secret of the recommended
+// length for the algorithm.
+func GenerateTsigSecret(algorithm string) (string, error) {
+	a, err := NormalizeTsigAlgorithm(algorithm)
+	if err != nil {
+		return "", err
+	}
+
+	b := make([]byte, tsigKeySizes[a])
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
+
+// GenerateTsigKey creates a new TSIG key with a random secret of the
+// recommended length for the algorithm.
+func GenerateTsigKey(name string, algorithm string) (*TSIGKey, error) {
+	a, err := NormalizeTsigAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := GenerateTsigSecret(a)
+	if err != nil {
+		return nil, err
+	}
+
+	tk := NewTsigKey(name, a, secret)
+	if err := tk.Validate(); err != nil {
+		return nil, err
+	}
+	return tk, nil
+}
+
+// Validate checks the key's name, that its algorithm is supported, and that
+// its secret is valid base64 at least half as long as the algorithm's hash
+// output, the minimum allowed by RFC 4635.
+func (tk *TSIGKey) Validate() error {
+	if err := validateTsigName(tk.Name); err != nil {
+		return err
+	}
+
+	a, err := NormalizeTsigAlgorithm(tk.Algorithm)
+	if err != nil {
+		return err
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(tk.Secret)
+	if err != nil {
+		return fmt.Errorf("TSIG secret is not valid base64: %v", err)
+	}
+	if min := tsigKeySizes[a] / 2; len(secret) < min {
+		return fmt.Errorf("TSIG secret of %d bytes is too short for %s, need at least %d", len(secret), a, min)
+	}
+	return nil
+}
+
+// validateTsigName checks that a key name is a valid domain name, as TSIG
+// key names are sent in DNS messages.
+func validateTsigName(name string) error {
+	n := strings.TrimSuffix(name, ".")
+	if n == "" {
+		return errors.New("TSIG key name must not be empty")
+	}
+	if len(n) > 253 {
+		return fmt.Errorf("TSIG key name too long: %q", name)
+	}
+	for _, label := range strings.Split(n, ".") {
+		if label == "" || len(label) > 63 || strings.ContainsAny(label, " \t\\\"") {
+			return fmt.Errorf("invalid TSIG key name %q", name)
+		}
+	}
+	return nil
+}
@@ -1,9 +1,12 @@
 package dns
 
 import (
+	"encoding/base64"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewTsigKey(t *testing.T) {
@@ -13,3 +16,53 @@ func TestNewTsigKey(t *testing.T) {
 	assert.Equal(t, "myAlgorithm", tsigKey.Algorithm)
 	assert.Equal(t, "mySecret", tsigKey.Secret)
 }
+
+func TestGenerateTsigKey(t *testing.T) {
+	for alg, size := range map[string]int{
+		TSIGAlgorithmHMACMD5:    16,
+		TSIGAlgorithmHMACSHA1:   20,
+		TSIGAlgorithmHMACSHA224: 28,
+		TSIGAlgorithmHMACSHA256: 32,
+		TSIGAlgorithmHMACSHA384: 48,
+		TSIGAlgorithmHMACSHA512: 64,
+	} {
+		tk, err := GenerateTsigKey("xfr.example.com", strings.ToUpper(alg))
+		require.NoError(t, err, alg)
+		assert.Equal(t, alg, tk.Algorithm)
+
+		secret, err := base64.StdEncoding.DecodeString(tk.Secret)
+		require.NoError(t, err)
+		assert.Len(t, secret, size, alg)
+	}
+
+	a, _ := GenerateTsigSecret(TSIGAlgorithmHMACSHA256)
+	b, _ := GenerateTsigSecret(TSIGAlgorithmHMACSHA256)
+	assert.NotEqual(t, a, b)
+
+	_, err := GenerateTsigKey("xfr.example.com", "hmac-sha3")
+	assert.Error(t, err)
+}
+
+func TestNormalizeTsigAlgorithm(t *testing.T) {
+	a, err := NormalizeTsigAlgorithm("HMAC-MD5.SIG-ALG.REG.INT.")
+	require.NoError(t, err)
+	assert.Equal(t, TSIGAlgorithmHMACMD5, a)
+
+	_, err = NormalizeTsigAlgorithm("gss-tsig")
+	assert.Error(t, err)
+}
+
+func TestTsigKeyValidate(t *testing.T) {
+	valid := NewTsigKey("xfr-key", "hmac-sha256", "Ok1qR5IW1ajVka5cHPEJQIXfLyx5V3PSkFBROAzOn21JumDq6nIpoj6H8rfj5Uo+Ok55ZWQ0Wgrf302fDscHLA==")
+	assert.NoError(t, valid.Validate())
+
+	for name, tk := range map[string]*TSIGKey{
+		"empty name":    NewTsigKey("", "hmac-sha256", valid.Secret),
+		"bad name":      NewTsigKey("a..b", "hmac-sha256", valid.Secret),
+		"bad algorithm": NewTsigKey("xfr-key", "sha256", valid.Secret),
+		"bad secret":    NewTsigKey("xfr-key", "hmac-sha256", "not base64!"),
+		"short secret":  NewTsigKey("xfr-key", "hmac-sha256", "c2hvcnQ="),
+	} {
+		assert.Error(t, tk.Validate(), name)
+	}
+}
@@ -0,0 +1,184 @@
+package dns
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ChangeType is the kind of change made to a record between two zones.
+type ChangeType string
+
+const (
+	ChangeAdded    = ChangeType("added")
+	ChangeRemoved  = ChangeType("removed")
+	ChangeModified = ChangeType("modified")
+)
+
+// RecordChange is a record that differs between two zones. From is nil for
+// added records and To is nil for removed ones.
+type RecordChange struct {
+	Change ChangeType
+	Domain string
+	Type   string
+	From   *ZoneRecord
+	To     *ZoneRecord
+
+	// Fields holds the json names of the fields that differ, for modified
+	// records.
+	Fields []string
+}
+
+// ZoneDiff is the difference between two versions of a zone.
+type ZoneDiff struct {
+	Zone string
+
+	// Fields holds the json names of the zone settings that differ, e.g.
+	// "ttl" or "hostmaster". The serial is not compared.
+	Fields []string
+
+	// Records holds the record changes, sorted by domain and type.
+	Records []*RecordChange
+}
+
+// DiffZones compares the records and settings of two snapshots of a zone,
+// e.g. a version and the live zone, and returns the changes going from
+// from to to. Answers are compared regardless of order.
+func DiffZones(from, to *Zone) *ZoneDiff {
+	d := &ZoneDiff{Zone: to.Zone, Fields: []string{}, Records: []*RecordChange{}}
+	if d.Zone == "" {
+		d.Zone = from.Zone
+	}
+
+	for _, f := range []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"ttl", from.TTL, to.TTL},
+		{"nx_ttl", from.NxTTL, to.NxTTL},
+		{"retry", from.Retry, to.Retry},
+		{"refresh", from.Refresh, to.Refresh},
+		{"expiry", from.Expiry, to.Expiry},
+		{"hostmaster", from.Hostmaster, to.Hostmaster},
+		{"primary_master", from.PrimaryMaster, to.PrimaryMaster},
+	} {
+		if f.from != f.to {
+			d.Fields = append(d.Fields, f.name)
+		}
+	}
+	if !reflect.DeepEqual(from.DNSSEC, to.DNSSEC) {
+		d.Fields = append(d.Fields, "dnssec")
+	}
+
+	fromRecords := zoneRecordsByKey(from.Records)
+	toRecords := zoneRecordsByKey(to.Records)
+
+	for key, fr := range fromRecords {
+		tr, ok := toRecords[key]
+		if !ok {
+			d.Records = append(d.Records, &RecordChange{
+				Change: ChangeRemoved, Domain: fr.Domain, Type: fr.Type, From: fr,
+			})
+			continue
+		}
+		if fields := diffZoneRecords(fr, tr); len(fields) > 0 {
+			d.Records = append(d.Records, &RecordChange{
+				Change: ChangeModified, Domain: tr.Domain, Type: tr.Type, From: fr, To: tr, Fields: fields,
+			})
+		}
+	}
+	for key, tr := range toRecords {
+		if _, ok := fromRecords[key]; !ok {
+			d.Records = append(d.Records, &RecordChange{
+				Change: ChangeAdded, Domain: tr.Domain, Type: tr.Type, To: tr,
+			})
+		}
+	}
+
+	sort.Slice(d.Records, func(i, j int) bool {
+		a, b := d.Records[i], d.Records[j]
+		if a.Domain != b.Domain {
+			return a.Domain < b.Domain
+		}
+		return a.Type < b.Type
+	})
+
+	return d
+}
+
+// Empty reports whether the two zones have the same settings and records.
+func (d *ZoneDiff) Empty() bool {
+	return len(d.Fields) == 0 && len(d.Records) == 0
+}
+
+// String renders the diff for review, one change per line:
+//
+//	~ zone: ttl, hostmaster
+//	+ www.example.com A [1.2.3.4]
+//	- old.example.com CNAME [www.example.com]
+//	~ mail.example.com MX short_answers: [10 mx1.example.com] -> [10 mx2.example.com]
+func (d *ZoneDiff) String() string {
+	var b strings.Builder
+	if len(d.Fields) > 0 {
+		fmt.Fprintf(&b, "~ zone: %s\n", strings.Join(d.Fields, ", "))
+	}
+	for _, c := range d.Records {
+		switch c.Change {
+		case ChangeAdded:
+			fmt.Fprintf(&b, "+ %s %s %v\n", c.Domain, c.Type, c.To.ShortAns)
+		case ChangeRemoved:
+			fmt.Fprintf(&b, "- %s %s %v\n", c.Domain, c.Type, c.From.ShortAns)
+		case ChangeModified:
+			fmt.Fprintf(&b, "~ %s %s %s: %v -> %v\n",
+				c.Domain, c.Type, strings.Join(c.Fields, ", "), c.From.ShortAns, c.To.ShortAns)
+		}
+	}
+	return b.String()
+}
+
+// zoneRecordsByKey indexes records by their lower cased domain and type.
+func zoneRecordsByKey(records []*ZoneRecord) map[string]*ZoneRecord {
+	m := make(map[string]*ZoneRecord, len(records))
+	for _, r := range records {
+		key := strings.ToLower(strings.TrimSuffix(r.Domain, ".")) + " " + strings.ToUpper(r.Type)
+		m[key] = r
+	}
+	return m
+}
+
+func diffZoneRecords(a, b *ZoneRecord) []string {
+	fields := []string{}
+	if a.TTL != b.TTL {
+		fields = append(fields, "ttl")
+	}
+	if !sameAnswers(a.ShortAns, b.ShortAns) {
+		fields = append(fields, "short_answers")
+	}
+	if a.Tier != b.Tier {
+		fields = append(fields, "tier")
+	}
+	if a.Link != b.Link {
+		fields = append(fields, "link")
+	}
+	if len(a.Tags)+len(b.Tags) > 0 && !reflect.DeepEqual(a.Tags, b.Tags) {
+		fields = append(fields, "tags")
+	}
+	return fields
+}
+
+func sameAnswers(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	as := append([]string{}, a...)
+	bs := append([]string{}, b...)
+	sort.Strings(as)
+	sort.Strings(bs)
+	for i := range as {
+		if as[i] != bs[i] {
+			return false
+		}
+	}
+	return true
+}
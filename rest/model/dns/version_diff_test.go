@@ -0,0 +1,59 @@
+package dns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffZones(t *testing.T) {
+	enabled := true
+	from := &Zone{
+		Zone:       "example.com",
+		TTL:        3600,
+		Serial:     1,
+		Hostmaster: "hostmaster@example.com",
+		Records: []*ZoneRecord{
+			{Domain: "www.example.com", Type: "A", TTL: 300, ShortAns: []string{"1.2.3.4"}},
+			{Domain: "mail.example.com", Type: "MX", TTL: 300, ShortAns: []string{"10 mx1.example.com"}},
+			{Domain: "old.example.com", Type: "CNAME", TTL: 300, ShortAns: []string{"www.example.com"}},
+			{Domain: "api.example.com", Type: "A", TTL: 60, ShortAns: []string{"10.0.0.1", "10.0.0.2"}},
+		},
+	}
+	to := &Zone{
+		Zone:       "example.com",
+		TTL:        3600,
+		Serial:     2,
+		Hostmaster: "dns@example.com",
+		DNSSEC:     &enabled,
+		Records: []*ZoneRecord{
+			{Domain: "WWW.example.com.", Type: "a", TTL: 300, ShortAns: []string{"1.2.3.4"}},
+			{Domain: "mail.example.com", Type: "MX", TTL: 600, ShortAns: []string{"10 mx2.example.com"}},
+			{Domain: "api.example.com", Type: "A", TTL: 60, ShortAns: []string{"10.0.0.2", "10.0.0.1"}},
+			{Domain: "new.example.com", Type: "AAAA", TTL: 300, ShortAns: []string{"::1"}},
+		},
+	}
+
+	d := DiffZones(from, to)
+	assert.Equal(t, "example.com", d.Zone)
+	assert.Equal(t, []string{"hostmaster", "dnssec"}, d.Fields)
+	require.Len(t, d.Records, 3)
+
+	assert.Equal(t, ChangeModified, d.Records[0].Change)
+	assert.Equal(t, "mail.example.com", d.Records[0].Domain)
+	assert.Equal(t, []string{"ttl", "short_answers"}, d.Records[0].Fields)
+
+	assert.Equal(t, ChangeAdded, d.Records[1].Change)
+	assert.Nil(t, d.Records[1].From)
+	assert.Equal(t, ChangeRemoved, d.Records[2].Change)
+	assert.Nil(t, d.Records[2].To)
+
+	assert.Equal(t, "~ zone: hostmaster, dnssec\n"+
+		"~ mail.example.com MX ttl, short_answers: [10 mx1.example.com] -> [10 mx2.example.com]\n"+
+		"+ new.example.com AAAA [::1]\n"+
+		"- old.example.com CNAME [www.example.com]\n", d.String())
+	assert.False(t, d.Empty())
+
+	assert.True(t, DiffZones(from, from).Empty())
+}
@@ -0,0 +1,270 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// ACL is an access control list referenced by name from View.ReadACLs and
+// View.UpdateACLs. A client matches the ACL if its address falls within
+// one of the IP prefixes or if it signs its query with one of the TSIG keys.
+type ACL struct {
+	Name       string   `json:"acl_name"`
+	IPPrefixes []string `json:"ip_prefixes,omitempty"`
+	TSIGKeys   []string `json:"tsig_keys,omitempty"`
+}
+
+// ViewQuery is a query to run through ResolveView.
+type ViewQuery struct {
+	// Name is the query name, e.g. "www.example.com".
+	Name string
+
+	// ClientIP and TSIGKey identify the client; either may be empty.
+	ClientIP net.IP
+	TSIGKey  string
+
+	// Network is the NS1 network the query arrives on. Networks of the
+	// views are not considered when nil.
+	Network *int
+}
+
+// ViewStep is one step of the decision made by ResolveView.
+type ViewStep struct {
+	// View is the view the step is about, if any.
+	View    string
+	Matched bool
+	Reason  string
+}
+
+func (s *ViewStep) String() string {
+	if s.View == "" {
+		return s.Reason
+	}
+	verdict := "skip"
+	if s.Matched {
+		verdict = "match"
+	}
+	return fmt.Sprintf("view %s: %s: %s", s.View, verdict, s.Reason)
+}
+
+// ViewResolution is the result of ResolveView.
+type ViewResolution struct {
+	Query *ViewQuery
+
+	// View is the view answering the query, or nil if none matches.
+	View *View
+
+	// Zone is the zone of View authoritative for the query name, or empty
+	// if the view holds none and the query is refused.
+	Zone string
+
+	// Steps explains the decision, in the order it was made.
+	Steps []*ViewStep
+}
+
+// Explain renders the steps of the decision, one per line.
+func (r *ViewResolution) Explain() string {
+	var b strings.Builder
+	for i, s := range r.Steps {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, s)
+	}
+	return b.String()
+}
+
+func (r *ViewResolution) step(view string, matched bool, format string, args ...interface{}) {
+	r.Steps = append(r.Steps, &ViewStep{View: view, Matched: matched, Reason: fmt.Sprintf(format, args...)})
+}
+
+// ResolveView works out offline which of the views answers q and which of
+// its zones is authoritative for the query name.
+//
+// Views are tried by ascending preference, as returned by
+// DNSViewService.GetPreferences; preferences missing from prefs are taken
+// from View.Preference, and ties are broken by name. The first view whose
+// networks include q.Network and whose read ACLs match the client answers
+// the query: a view without read ACLs matches any client, and ACLs
+// missing from acls match none. Like other split-horizon servers, the
+// answering view is not left for a later one when it holds no zone for
+// the query name; the query is then refused, and the views that would
+// have answered are noted in the steps.
+func ResolveView(views []*View, prefs map[string]int, acls []*ACL, q *ViewQuery) (*ViewResolution, error) {
+	aclsByName := make(map[string]*ACL, len(acls))
+	for _, a := range acls {
+		for _, p := range a.IPPrefixes {
+			if _, err := parsePrefix(p); err != nil {
+				return nil, fmt.Errorf("acl %s: %w", a.Name, err)
+			}
+		}
+		aclsByName[a.Name] = a
+	}
+
+	ordered := make([]*View, len(views))
+	copy(ordered, views)
+	preference := func(v *View) int {
+		if p, ok := prefs[v.Name]; ok {
+			return p
+		}
+		return v.Preference
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		pi, pj := preference(ordered[i]), preference(ordered[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+
+	r := &ViewResolution{Query: q, Steps: []*ViewStep{}}
+	r.step("", false, "query %s from %s; views tried in order %s", q.Name, describeClient(q), viewOrder(ordered, preference))
+
+	for _, v := range ordered {
+		if r.View != nil {
+			if zone := authoritativeZone(v.Zones, q.Name); zone != "" && viewMatches(v, aclsByName, q) {
+				r.step(v.Name, false, "would have answered from zone %s but has lower preference than view %s", zone, r.View.Name)
+			}
+			continue
+		}
+
+		if q.Network != nil && len(v.Networks) > 0 && !containsInt(v.Networks, *q.Network) {
+			r.step(v.Name, false, "network %d is not among the view networks %v", *q.Network, v.Networks)
+			continue
+		}
+		matched, reason := matchACLs(v, aclsByName, q)
+		r.step(v.Name, matched, "%s", reason)
+		if !matched {
+			continue
+		}
+
+		r.View = v
+		r.Zone = authoritativeZone(v.Zones, q.Name)
+		if r.Zone == "" {
+			r.step(v.Name, true, "holds no zone for %s (zones %v); the query is refused", q.Name, v.Zones)
+		} else {
+			r.step(v.Name, true, "zone %s is the closest enclosing zone of %s", r.Zone, q.Name)
+		}
+	}
+
+	if r.View == nil {
+		r.step("", false, "no view matches the client; the query is refused")
+	}
+
+	return r, nil
+}
+
+// matchACLs checks the read ACLs of a view against the client, explaining
+// the outcome.
+func matchACLs(v *View, acls map[string]*ACL, q *ViewQuery) (bool, string) {
+	if len(v.ReadACLs) == 0 {
+		return true, "no read ACLs, so any client matches"
+	}
+
+	misses := []string{}
+	for _, name := range v.ReadACLs {
+		a, ok := acls[name]
+		if !ok {
+			misses = append(misses, fmt.Sprintf("acl %s is unknown", name))
+			continue
+		}
+		if ok, why := aclMatches(a, q); ok {
+			return true, fmt.Sprintf("read acl %s matches: %s", name, why)
+		}
+		misses = append(misses, fmt.Sprintf("acl %s does not match", name))
+	}
+	return false, "no read ACL matches (" + strings.Join(misses, "; ") + ")"
+}
+
+func viewMatches(v *View, acls map[string]*ACL, q *ViewQuery) bool {
+	if q.Network != nil && len(v.Networks) > 0 && !containsInt(v.Networks, *q.Network) {
+		return false
+	}
+	ok, _ := matchACLs(v, acls, q)
+	return ok
+}
+
+func aclMatches(a *ACL, q *ViewQuery) (bool, string) {
+	if q.ClientIP != nil {
+		for _, p := range a.IPPrefixes {
+			n, _ := parsePrefix(p)
+			if n.Contains(q.ClientIP) {
+				return true, fmt.Sprintf("client %s is within %s", q.ClientIP, p)
+			}
+		}
+	}
+	if q.TSIGKey != "" {
+		for _, k := range a.TSIGKeys {
+			if strings.EqualFold(strings.TrimSuffix(k, "."), strings.TrimSuffix(q.TSIGKey, ".")) {
+				return true, fmt.Sprintf("query is signed with TSIG key %s", k)
+			}
+		}
+	}
+	return false, ""
+}
+
+// parsePrefix parses an IP prefix; a bare address is a host prefix.
+func parsePrefix(p string) (*net.IPNet, error) {
+	if !strings.Contains(p, "/") {
+		ip := net.ParseIP(p)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP prefix %q", p)
+		}
+		bits := 128
+		if ip.To4() != nil {
+			bits = 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, n, err := net.ParseCIDR(p)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IP prefix %q", p)
+	}
+	return n, nil
+}
+
+// authoritativeZone returns the longest of zones enclosing name.
+func authoritativeZone(zones []string, name string) string {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	best := ""
+	for _, z := range zones {
+		zone := strings.ToLower(strings.TrimSuffix(z, "."))
+		if (name == zone || strings.HasSuffix(name, "."+zone)) && len(zone) > len(best) {
+			best = zone
+		}
+	}
+	return best
+}
+
+func describeClient(q *ViewQuery) string {
+	parts := []string{}
+	if q.ClientIP != nil {
+		parts = append(parts, q.ClientIP.String())
+	}
+	if q.TSIGKey != "" {
+		parts = append(parts, "TSIG key "+q.TSIGKey)
+	}
+	if q.Network != nil {
+		parts = append(parts, fmt.Sprintf("network %d", *q.Network))
+	}
+	if len(parts) == 0 {
+		return "an anonymous client"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func viewOrder(views []*View, preference func(*View) int) string {
+	parts := make([]string, len(views))
+	for i, v := range views {
+		parts[i] = fmt.Sprintf("%s (%d)", v.Name, preference(v))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func containsInt(l []int, n int) bool {
+	for _, m := range l {
+		if m == n {
+			return true
+		}
+	}
+	return false
+}
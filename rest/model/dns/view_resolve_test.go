@@ -0,0 +1,118 @@
+package dns
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveView(t *testing.T) {
+	views := []*View{
+		{Name: "public", Zones: []string{"example.com"}, Preference: 1},
+		{Name: "internal", ReadACLs: []string{"office", "vpn"}, Zones: []string{"example.com", "corp.example.com"}, Preference: 9},
+		{Name: "partners", ReadACLs: []string{"partners"}, Zones: []string{"partner.net"}, Networks: []int{1}, Preference: 9},
+	}
+	prefs := map[string]int{"internal": 1, "partners": 2, "public": 3}
+	acls := []*ACL{
+		{Name: "office", IPPrefixes: []string{"10.0.0.0/8", "2001:db8::/32"}},
+		{Name: "vpn", TSIGKeys: []string{"vpn-key."}},
+		{Name: "partners", IPPrefixes: []string{"198.51.100.7"}},
+	}
+	network := func(n int) *int { return &n }
+
+	tests := []struct {
+		name  string
+		query *ViewQuery
+		view  string
+		zone  string
+		step  string
+	}{
+		{
+			name:  "ip prefix",
+			query: &ViewQuery{Name: "www.corp.example.com", ClientIP: net.ParseIP("10.1.2.3")},
+			view:  "internal",
+			zone:  "corp.example.com",
+			step:  "client 10.1.2.3 is within 10.0.0.0/8",
+		},
+		{
+			name:  "ipv6 prefix",
+			query: &ViewQuery{Name: "www.example.com.", ClientIP: net.ParseIP("2001:db8::1")},
+			view:  "internal",
+			zone:  "example.com",
+		},
+		{
+			name:  "tsig key",
+			query: &ViewQuery{Name: "WWW.Example.com", TSIGKey: "VPN-key"},
+			view:  "internal",
+			zone:  "example.com",
+			step:  "signed with TSIG key vpn-key.",
+		},
+		{
+			name:  "fall through to public",
+			query: &ViewQuery{Name: "www.example.com", ClientIP: net.ParseIP("192.0.2.1")},
+			view:  "public",
+			zone:  "example.com",
+			step:  "no read ACL matches (acl office does not match; acl vpn does not match)",
+		},
+		{
+			name:  "network mismatch",
+			query: &ViewQuery{Name: "www.partner.net", ClientIP: net.ParseIP("198.51.100.7"), Network: network(0)},
+			view:  "public",
+			zone:  "",
+			step:  "network 0 is not among the view networks [1]",
+		},
+		{
+			name:  "matching view without zone is refused",
+			query: &ViewQuery{Name: "www.example.com", ClientIP: net.ParseIP("198.51.100.7"), Network: network(1)},
+			view:  "partners",
+			zone:  "",
+			step:  "would have answered from zone example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ResolveView(views, prefs, acls, tt.query)
+			require.NoError(t, err)
+			require.NotNil(t, r.View)
+			assert.Equal(t, tt.view, r.View.Name)
+			assert.Equal(t, tt.zone, r.Zone)
+			assert.Contains(t, r.Explain(), tt.step)
+		})
+	}
+
+	t.Run("order", func(t *testing.T) {
+		r, err := ResolveView(views, prefs, acls, &ViewQuery{Name: "example.com"})
+		require.NoError(t, err)
+		assert.Contains(t, r.Steps[0].Reason, "internal (1), partners (2), public (3)")
+
+		// Without global preferences, the views' own are used, and ties
+		// are broken by name.
+		r, err = ResolveView(views, nil, acls, &ViewQuery{Name: "example.com"})
+		require.NoError(t, err)
+		assert.Contains(t, r.Steps[0].Reason, "public (1), internal (9), partners (9)")
+	})
+
+	t.Run("no view matches", func(t *testing.T) {
+		r, err := ResolveView(views[1:], prefs, acls, &ViewQuery{Name: "www.example.com"})
+		require.NoError(t, err)
+		assert.Nil(t, r.View)
+		assert.True(t, strings.HasSuffix(r.Explain(), "no view matches the client; the query is refused\n"))
+		assert.Contains(t, r.Explain(), "from an anonymous client")
+	})
+
+	t.Run("unknown acl", func(t *testing.T) {
+		r, err := ResolveView(views[1:2], prefs, nil, &ViewQuery{Name: "www.example.com", ClientIP: net.ParseIP("10.0.0.1")})
+		require.NoError(t, err)
+		assert.Nil(t, r.View)
+		assert.Contains(t, r.Explain(), "acl office is unknown")
+	})
+
+	t.Run("invalid prefix", func(t *testing.T) {
+		_, err := ResolveView(views, prefs, []*ACL{{Name: "bad", IPPrefixes: []string{"10.0.0.0/33"}}}, &ViewQuery{})
+		assert.Error(t, err)
+	})
+}
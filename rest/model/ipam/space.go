@@ -0,0 +1,301 @@
+package ipam
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/netip"
+	"sort"
+	"strings"
+)
+
+// ErrNoFreeSpace is returned when a block has no room left for an
+// allocation.
+var ErrNoFreeSpace = errors.New("no free space left in block")
+
+// ParsePrefix parses the Prefix of an address. A bare address, as used for
+// hosts, is a single address prefix.
+func (a *Address) ParsePrefix() (netip.Prefix, error) {
+	s := strings.TrimSpace(a.Prefix)
+	if !strings.Contains(s, "/") {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid prefix %q", a.Prefix)
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid prefix %q", a.Prefix)
+	}
+	return p.Masked(), nil
+}
+
+// Usage holds the address counts of a subnet. They are big numbers, as
+// IPv6 subnets easily exceed 64 bits.
+type Usage struct {
+	Total *big.Int
+	Used  *big.Int
+	Free  *big.Int
+}
+
+// Utilization returns the share of used addresses, between 0 and 1.
+func (u *Usage) Utilization() float64 {
+	if u.Total.Sign() == 0 {
+		return 0
+	}
+	f, _ := new(big.Rat).SetFrac(u.Used, u.Total).Float64()
+	return f
+}
+
+// Usage parses the Total, Used and Free counts of the address. A missing
+// total is computed from the prefix, and a missing free count from the
+// total and used ones.
+func (a *Address) Usage() (*Usage, error) {
+	parse := func(field, s string) (*big.Int, error) {
+		if s == "" {
+			return nil, nil
+		}
+		n, ok := new(big.Int).SetString(strings.TrimSpace(s), 10)
+		if !ok || n.Sign() < 0 {
+			return nil, fmt.Errorf("invalid %s count %q", field, s)
+		}
+		return n, nil
+	}
+
+	total, err := parse("total", a.Total)
+	if err != nil {
+		return nil, err
+	}
+	used, err := parse("used", a.Used)
+	if err != nil {
+		return nil, err
+	}
+	free, err := parse("free", a.Free)
+	if err != nil {
+		return nil, err
+	}
+
+	if total == nil {
+		p, err := a.ParsePrefix()
+		if err != nil {
+			return nil, err
+		}
+		total = prefixSize(p)
+	}
+	switch {
+	case used == nil && free == nil:
+		used = new(big.Int)
+		free = new(big.Int).Set(total)
+	case used == nil:
+		used = new(big.Int).Sub(total, free)
+	case free == nil:
+		free = new(big.Int).Sub(total, used)
+	}
+
+	return &Usage{Total: total, Used: used, Free: free}, nil
+}
+
+// Block is an address of an address space, along with its child blocks.
+type Block struct {
+	Address  *Address
+	Prefix   netip.Prefix
+	Children []*Block
+}
+
+// Space is a local model of IPAM address space, e.g. as loaded by
+// IPAMService.GetSpace. Blocks of different networks may overlap, so each
+// network has a tree of its own.
+type Space struct {
+	roots map[int][]*Block
+}
+
+// NewSpace builds an address space from addresses. They may be given in any
+// order; each is placed under the smallest address of the same network
+// containing it.
+func NewSpace(addrs []*Address) (*Space, error) {
+	blocks := make([]*Block, 0, len(addrs))
+	for _, a := range addrs {
+		p, err := a.ParsePrefix()
+		if err != nil {
+			return nil, fmt.Errorf("address %d: %w", a.ID, err)
+		}
+		blocks = append(blocks, &Block{Address: a, Prefix: p})
+	}
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return comparePrefixes(blocks[i].Prefix, blocks[j].Prefix) < 0
+	})
+
+	s := &Space{roots: map[int][]*Block{}}
+	for _, b := range blocks {
+		network := b.Address.Network
+		parent := s.Lookup(network, b.Prefix)
+		switch {
+		case parent == nil:
+			s.roots[network] = append(s.roots[network], b)
+		case parent.Prefix == b.Prefix:
+			return nil, fmt.Errorf("network %d: duplicate prefix %s", network, b.Prefix)
+		default:
+			parent.Children = append(parent.Children, b)
+		}
+	}
+	for _, roots := range s.roots {
+		sortBlocks(roots)
+		for _, r := range roots {
+			r.Walk(func(b *Block) { sortBlocks(b.Children) })
+		}
+	}
+	return s, nil
+}
+
+func sortBlocks(blocks []*Block) {
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Prefix.Addr().Less(blocks[j].Prefix.Addr())
+	})
+}
+
+// Roots returns the top level blocks of a network, in address order.
+func (s *Space) Roots(network int) []*Block {
+	return s.roots[network]
+}
+
+// Find returns the block of a network with exactly the given prefix, or nil.
+func (s *Space) Find(network int, prefix string) *Block {
+	p, err := (&Address{Prefix: prefix}).ParsePrefix()
+	if err != nil {
+		return nil
+	}
+	if b := s.Lookup(network, p); b != nil && b.Prefix == p {
+		return b
+	}
+	return nil
+}
+
+// Lookup returns the smallest block of a network containing p, or nil.
+func (s *Space) Lookup(network int, p netip.Prefix) *Block {
+	var found *Block
+	blocks := s.roots[network]
+	for {
+		var next *Block
+		for _, b := range blocks {
+			if contains(b.Prefix, p) {
+				next = b
+				break
+			}
+		}
+		if next == nil {
+			return found
+		}
+		found, blocks = next, next.Children
+	}
+}
+
+// Free returns the largest blocks of b not covered by any child, in
+// address order.
+func (b *Block) Free() []netip.Prefix {
+	used := make([]netip.Prefix, len(b.Children))
+	for i, c := range b.Children {
+		used[i] = c.Prefix
+	}
+	return freeBlocks(b.Prefix, used)
+}
+
+// NextFree returns the lowest free subnet of b with the given prefix
+// length, which must be longer than that of b.
+func (b *Block) NextFree(bits int) (netip.Prefix, error) {
+	if bits <= b.Prefix.Bits() || bits > b.Prefix.Addr().BitLen() {
+		return netip.Prefix{}, fmt.Errorf("cannot allocate a /%d in %s", bits, b.Prefix)
+	}
+	for _, f := range b.Free() {
+		if f.Bits() <= bits {
+			return netip.PrefixFrom(f.Addr(), bits), nil
+		}
+	}
+	return netip.Prefix{}, ErrNoFreeSpace
+}
+
+// NextFreeHost returns the lowest free host address of b. The network and
+// broadcast addresses of IPv4 subnets are skipped.
+func (b *Block) NextFreeHost() (netip.Addr, error) {
+	first, last := b.Prefix.Addr(), lastAddr(b.Prefix)
+	skip := b.Prefix.Addr().Is4() && b.Prefix.Bits() < 31
+
+	for _, f := range b.Free() {
+		for a := f.Addr(); f.Contains(a); a = a.Next() {
+			if skip && (a == first || a == last) {
+				continue
+			}
+			return a, nil
+		}
+	}
+	return netip.Addr{}, ErrNoFreeSpace
+}
+
+// Walk calls fn for b and each of its descendants, parents first.
+func (b *Block) Walk(fn func(*Block)) {
+	fn(b)
+	for _, c := range b.Children {
+		c.Walk(fn)
+	}
+}
+
+// freeBlocks returns the largest aligned blocks of p overlapping none of
+// used, in address order.
+func freeBlocks(p netip.Prefix, used []netip.Prefix) []netip.Prefix {
+	overlapping := []netip.Prefix{}
+	for _, u := range used {
+		if u.Overlaps(p) {
+			if contains(u, p) {
+				return nil
+			}
+			overlapping = append(overlapping, u)
+		}
+	}
+	if len(overlapping) == 0 {
+		return []netip.Prefix{p}
+	}
+
+	lo, hi := halves(p)
+	return append(freeBlocks(lo, overlapping), freeBlocks(hi, overlapping)...)
+}
+
+// halves splits p in its lower and upper halves. p must not be a single
+// address.
+func halves(p netip.Prefix) (netip.Prefix, netip.Prefix) {
+	bits := p.Bits() + 1
+	lo := netip.PrefixFrom(p.Addr(), bits)
+
+	b := p.Addr().AsSlice()
+	i := p.Bits()
+	b[i/8] |= 0x80 >> (i % 8)
+	addr, _ := netip.AddrFromSlice(b)
+	return lo, netip.PrefixFrom(addr, bits)
+}
+
+// lastAddr returns the highest address of p.
+func lastAddr(p netip.Prefix) netip.Addr {
+	b := p.Addr().AsSlice()
+	for i := p.Bits(); i < len(b)*8; i++ {
+		b[i/8] |= 0x80 >> (i % 8)
+	}
+	addr, _ := netip.AddrFromSlice(b)
+	return addr
+}
+
+// contains reports whether outer contains all of inner.
+func contains(outer, inner netip.Prefix) bool {
+	return outer.Bits() <= inner.Bits() && outer.Contains(inner.Addr())
+}
+
+func prefixSize(p netip.Prefix) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(p.Addr().BitLen()-p.Bits()))
+}
+
+// comparePrefixes orders prefixes by length, then address, so that blocks
+// come after the blocks containing them.
+func comparePrefixes(a, b netip.Prefix) int {
+	if a.Bits() != b.Bits() {
+		return a.Bits() - b.Bits()
+	}
+	return a.Addr().Compare(b.Addr())
+}
@@ -0,0 +1,152 @@
+package ipam
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSpace(t *testing.T) *Space {
+	s, err := NewSpace([]*Address{
+		{ID: 3, Prefix: "10.20.0.64/26", Network: 1},
+		{ID: 1, Prefix: "10.20.0.0/16", Network: 1},
+		{ID: 2, Prefix: "10.20.0.0/26", Network: 1},
+		{ID: 4, Prefix: "10.20.1.0/24", Network: 1},
+		{ID: 5, Prefix: "10.20.1.1", Network: 1},
+		{ID: 6, Prefix: "10.20.0.0/16", Network: 2},
+		{ID: 7, Prefix: "2001:db8::/48", Network: 1},
+		{ID: 8, Prefix: "2001:db8::/64", Network: 1},
+	})
+	require.NoError(t, err)
+	return s
+}
+
+func TestNewSpace(t *testing.T) {
+	s := testSpace(t)
+
+	roots := s.Roots(1)
+	require.Len(t, roots, 2)
+	assert.Equal(t, 1, roots[0].Address.ID)
+	assert.Len(t, roots[0].Children, 3)
+	assert.Len(t, s.Roots(2), 1)
+
+	b := s.Find(1, "10.20.1.0/24")
+	require.NotNil(t, b)
+	require.Len(t, b.Children, 1)
+	assert.Equal(t, netip.MustParsePrefix("10.20.1.1/32"), b.Children[0].Prefix)
+	assert.Nil(t, s.Find(1, "10.20.2.0/24"))
+	assert.Nil(t, s.Find(1, "bogus"))
+
+	assert.Equal(t, 4, s.Lookup(1, netip.MustParsePrefix("10.20.1.128/25")).Address.ID)
+	assert.Equal(t, 6, s.Lookup(2, netip.MustParsePrefix("10.20.1.128/25")).Address.ID)
+	assert.Nil(t, s.Lookup(3, netip.MustParsePrefix("10.20.1.128/25")))
+
+	var ids []int
+	roots[0].Walk(func(b *Block) { ids = append(ids, b.Address.ID) })
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids)
+
+	_, err := NewSpace([]*Address{{Prefix: "10.0.0.0/8"}, {Prefix: "10.0.0.0/8"}})
+	assert.Error(t, err)
+	_, err = NewSpace([]*Address{{Prefix: "10.0.0.0/33"}})
+	assert.Error(t, err)
+}
+
+func TestBlock_Free(t *testing.T) {
+	s := testSpace(t)
+
+	free := s.Find(1, "10.20.0.0/16").Free()
+	want := []string{
+		"10.20.0.128/25", "10.20.2.0/23", "10.20.4.0/22", "10.20.8.0/21",
+		"10.20.16.0/20", "10.20.32.0/19", "10.20.64.0/18", "10.20.128.0/17",
+	}
+	got := []string{}
+	for _, p := range free {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, want, got)
+
+	assert.Len(t, s.Find(1, "10.20.0.0/26").Free(), 1)
+}
+
+func TestBlock_NextFree(t *testing.T) {
+	s := testSpace(t)
+	b := s.Find(1, "10.20.0.0/16")
+
+	p, err := b.NextFree(26)
+	require.NoError(t, err)
+	assert.Equal(t, "10.20.0.128/26", p.String())
+
+	p, err = b.NextFree(24)
+	require.NoError(t, err)
+	assert.Equal(t, "10.20.2.0/24", p.String())
+
+	p, err = b.NextFree(17)
+	require.NoError(t, err)
+	assert.Equal(t, "10.20.128.0/17", p.String())
+
+	_, err = b.NextFree(16)
+	assert.Error(t, err)
+	_, err = s.Find(1, "10.20.1.0/24").NextFree(24)
+	assert.Error(t, err)
+	_, err = s.Find(1, "10.20.1.0/24").NextFree(23)
+	assert.Error(t, err)
+
+	p, err = s.Find(1, "10.20.1.0/24").NextFree(25)
+	require.NoError(t, err)
+	assert.Equal(t, "10.20.1.128/25", p.String())
+
+	full, err := NewSpace([]*Address{{Prefix: "192.0.2.0/24"}, {Prefix: "192.0.2.0/25"}, {Prefix: "192.0.2.128/25"}})
+	require.NoError(t, err)
+	_, err = full.Find(0, "192.0.2.0/24").NextFree(26)
+	assert.Equal(t, ErrNoFreeSpace, err)
+
+	p, err = s.Find(1, "2001:db8::/48").NextFree(64)
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8:0:1::/64", p.String())
+}
+
+func TestBlock_NextFreeHost(t *testing.T) {
+	s := testSpace(t)
+
+	a, err := s.Find(1, "10.20.1.0/24").NextFreeHost()
+	require.NoError(t, err)
+	assert.Equal(t, "10.20.1.2", a.String())
+
+	a, err = s.Find(1, "10.20.0.0/26").NextFreeHost()
+	require.NoError(t, err)
+	assert.Equal(t, "10.20.0.1", a.String())
+
+	a, err = s.Find(1, "2001:db8::/64").NextFreeHost()
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::", a.String())
+
+	full, err := NewSpace([]*Address{
+		{Prefix: "192.0.2.0/30"},
+		{Prefix: "192.0.2.1/32"},
+		{Prefix: "192.0.2.2/32"},
+	})
+	require.NoError(t, err)
+	_, err = full.Find(0, "192.0.2.0/30").NextFreeHost()
+	assert.Equal(t, ErrNoFreeSpace, err)
+}
+
+func TestAddress_Usage(t *testing.T) {
+	u, err := (&Address{Prefix: "10.0.0.0/24", Total: "256", Used: "64", Free: "192"}).Usage()
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, u.Utilization())
+
+	u, err = (&Address{Prefix: "10.0.0.0/24", Used: "128"}).Usage()
+	require.NoError(t, err)
+	assert.Equal(t, "256", u.Total.String())
+	assert.Equal(t, "128", u.Free.String())
+
+	u, err = (&Address{Prefix: "2001:db8::/32", Free: "79228162514264337593543950335"}).Usage()
+	require.NoError(t, err)
+	assert.Equal(t, "79228162514264337593543950336", u.Total.String())
+	assert.Equal(t, "1", u.Used.String())
+
+	_, err = (&Address{Prefix: "10.0.0.0/24", Used: "lots"}).Usage()
+	assert.Error(t, err)
+}
@@ -0,0 +1,305 @@
+package ipam
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported import and export formats.
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// ErrUnsupportedFormat is returned for formats other than FormatCSV and
+// FormatJSON.
+var ErrUnsupportedFormat = errors.New("unsupported format")
+
+// transferColumns are the CSV columns, in export order.
+var transferColumns = []string{"network", "prefix", "name", "desc", "status", "tags", "kvps"}
+
+// transferRecord is an address as imported and exported.
+type transferRecord struct {
+	Network int                    `json:"network,omitempty"`
+	Prefix  string                 `json:"prefix"`
+	Name    string                 `json:"name,omitempty"`
+	Desc    string                 `json:"desc,omitempty"`
+	Status  AddrStatus             `json:"status,omitempty"`
+	Tags    map[string]interface{} `json:"tags,omitempty"`
+	KVPS    map[string]interface{} `json:"kvps,omitempty"`
+}
+
+// WriteAddresses exports addresses in the given format, in the given order.
+// Only the network, prefix, name, desc, status, tags and kvps of the
+// addresses are written.
+//
+// In CSV, tags and kvps are written as "key=value" pairs separated by
+// semicolons, or as a JSON object when a key or value would not survive
+// that form.
+func WriteAddresses(w io.Writer, format string, addrs []*Address) error {
+	switch format {
+	case FormatJSON:
+		records := make([]*transferRecord, len(addrs))
+		for i, a := range addrs {
+			records[i] = &transferRecord{
+				Network: a.Network,
+				Prefix:  a.Prefix,
+				Name:    a.Name,
+				Desc:    a.Desc,
+				Status:  a.Status,
+				Tags:    a.Tags,
+				KVPS:    a.KVPS,
+			}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case FormatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(transferColumns); err != nil {
+			return err
+		}
+		for _, a := range addrs {
+			tags, err := formatPairs(a.Tags)
+			if err != nil {
+				return err
+			}
+			kvps, err := formatPairs(a.KVPS)
+			if err != nil {
+				return err
+			}
+			network := ""
+			if a.Network != 0 {
+				network = strconv.Itoa(a.Network)
+			}
+			row := []string{network, a.Prefix, a.Name, a.Desc, string(a.Status), tags, kvps}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+// ReadAddresses imports addresses written in the given format, e.g. by
+// WriteAddresses. CSV input must start with a header naming its columns,
+// in any order; only the prefix column is required.
+func ReadAddresses(r io.Reader, format string) ([]*Address, error) {
+	switch format {
+	case FormatJSON:
+		records := []*transferRecord{}
+		dec := json.NewDecoder(r)
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&records); err != nil {
+			return nil, err
+		}
+		addrs := make([]*Address, len(records))
+		for i, rec := range records {
+			addrs[i] = &Address{
+				Network: rec.Network,
+				Prefix:  rec.Prefix,
+				Name:    rec.Name,
+				Desc:    rec.Desc,
+				Status:  rec.Status,
+				Tags:    rec.Tags,
+				KVPS:    rec.KVPS,
+			}
+		}
+		return addrs, nil
+	case FormatCSV:
+		return readCSV(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+}
+
+func readCSV(r io.Reader) ([]*Address, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		known := false
+		for _, c := range transferColumns {
+			known = known || c == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown CSV column %q", name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["prefix"]; !ok {
+		return nil, errors.New(`CSV header lacks the "prefix" column`)
+	}
+
+	addrs := []*Address{}
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		cell := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+
+		a := &Address{
+			Prefix: cell("prefix"),
+			Name:   cell("name"),
+			Desc:   cell("desc"),
+			Status: AddrStatus(cell("status")),
+		}
+		if s := cell("network"); s != "" {
+			if a.Network, err = strconv.Atoi(s); err != nil {
+				return nil, fmt.Errorf("line %d: invalid network %q", line, s)
+			}
+		}
+		if a.Tags, err = parsePairs(cell("tags")); err != nil {
+			return nil, fmt.Errorf("line %d: tags: %w", line, err)
+		}
+		if a.KVPS, err = parsePairs(cell("kvps")); err != nil {
+			return nil, fmt.Errorf("line %d: kvps: %w", line, err)
+		}
+		addrs = append(addrs, a)
+	}
+
+	return addrs, nil
+}
+
+// formatPairs renders a map as "key=value" pairs, sorted by key, falling
+// back to JSON for non-string values and keys or values holding
+// separators.
+func formatPairs(m map[string]interface{}) (string, error) {
+	if len(m) == 0 {
+		return "", nil
+	}
+
+	keys := make([]string, 0, len(m))
+	plain := true
+	for k, v := range m {
+		keys = append(keys, k)
+		s, ok := v.(string)
+		plain = plain && ok && k != "" && !strings.ContainsAny(k, "=;{") && !strings.Contains(s, ";")
+	}
+	if !plain {
+		b, err := json.Marshal(m)
+		return string(b), err
+	}
+
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + m[k].(string)
+	}
+	return strings.Join(pairs, ";"), nil
+}
+
+// parsePairs parses the output of formatPairs.
+func parsePairs(s string) (map[string]interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	m := map[string]interface{}{}
+	if strings.HasPrefix(s, "{") {
+		if err := json.Unmarshal([]byte(s), &m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	for _, pair := range strings.Split(s, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("invalid pair %q, expected key=value", pair)
+		}
+		m[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return m, nil
+}
+
+// SortParentsFirst sorts addresses so that every address comes after the
+// addresses of the same network containing it: by network, prefix length
+// and address. Addresses with invalid prefixes come last.
+func SortParentsFirst(addrs []*Address) {
+	sort.SliceStable(addrs, func(i, j int) bool {
+		a, b := addrs[i], addrs[j]
+		pa, errA := a.ParsePrefix()
+		pb, errB := b.ParsePrefix()
+		if errA != nil || errB != nil {
+			return errA == nil && errB != nil
+		}
+		if a.Network != b.Network {
+			return a.Network < b.Network
+		}
+		return comparePrefixes(pa, pb) < 0
+	})
+}
+
+// ValidateImport checks addresses before importing them, returning every
+// problem found: invalid prefixes, unknown statuses, missing networks and
+// duplicates.
+func ValidateImport(addrs []*Address) []error {
+	errs := []error{}
+	seen := map[int]map[netip.Prefix]bool{}
+	for i, a := range addrs {
+		where := fmt.Sprintf("address %d (%s)", i+1, a.Prefix)
+		p, err := a.ParsePrefix()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", where, err))
+			continue
+		}
+		if raw, err := netip.ParsePrefix(strings.TrimSpace(a.Prefix)); err == nil && raw != raw.Masked() {
+			errs = append(errs, fmt.Errorf("%s: host bits are set, the prefix is %s", where, p))
+		}
+		switch a.Status {
+		case "", StatusPlanned, StatusAssigned:
+		default:
+			errs = append(errs, fmt.Errorf("%s: unknown status %q", where, a.Status))
+		}
+		if a.Network == 0 {
+			errs = append(errs, fmt.Errorf("%s: network is required", where))
+		}
+		if seen[a.Network] == nil {
+			seen[a.Network] = map[netip.Prefix]bool{}
+		}
+		if seen[a.Network][p] {
+			errs = append(errs, fmt.Errorf("%s: duplicate prefix in network %d", where, a.Network))
+		}
+		seen[a.Network][p] = true
+	}
+	return errs
+}
+
+// Exists reports whether the space holds an address of the given network
+// with the prefix of a.
+func (s *Space) Exists(a *Address) bool {
+	p, err := a.ParsePrefix()
+	if err != nil {
+		return false
+	}
+	b := s.Lookup(a.Network, p)
+	return b != nil && b.Prefix == p
+}
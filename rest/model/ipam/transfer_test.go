@@ -0,0 +1,118 @@
+package ipam
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteReadAddresses(t *testing.T) {
+	addrs := []*Address{
+		{ID: 1, Network: 1, Prefix: "10.0.0.0/16", Name: "site", Desc: "main, site", Status: StatusPlanned,
+			Tags: map[string]interface{}{"env": "prod", "team": "net"}},
+		{ID: 2, Network: 1, Prefix: "10.0.1.0/24", KVPS: map[string]interface{}{"vlan": 12.0, "note": "a;b"}},
+		{ID: 3, Prefix: "10.0.1.1/32", Status: StatusAssigned},
+	}
+
+	for _, format := range []string{FormatCSV, FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, WriteAddresses(&buf, format, addrs))
+
+			got, err := ReadAddresses(&buf, format)
+			require.NoError(t, err)
+			require.Len(t, got, len(addrs))
+			for i, a := range addrs {
+				assert.Equal(t, 0, got[i].ID)
+				assert.Equal(t, a.Network, got[i].Network)
+				assert.Equal(t, a.Prefix, got[i].Prefix)
+				assert.Equal(t, a.Name, got[i].Name)
+				assert.Equal(t, a.Desc, got[i].Desc)
+				assert.Equal(t, a.Status, got[i].Status)
+				assert.Equal(t, a.Tags, got[i].Tags)
+				assert.Equal(t, a.KVPS, got[i].KVPS)
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, WriteAddresses(&buf, FormatCSV, addrs))
+	assert.Equal(t, `network,prefix,name,desc,status,tags,kvps
+1,10.0.0.0/16,site,"main, site",planned,env=prod;team=net,
+1,10.0.1.0/24,,,,,"{""note"":""a;b"",""vlan"":12}"
+,10.0.1.1/32,,,assigned,,
+`, buf.String())
+
+	assert.True(t, errors.Is(WriteAddresses(&buf, "xml", addrs), ErrUnsupportedFormat))
+	_, err := ReadAddresses(&buf, "xml")
+	assert.True(t, errors.Is(err, ErrUnsupportedFormat))
+}
+
+func TestReadAddresses_CSV(t *testing.T) {
+	addrs, err := ReadAddresses(strings.NewReader("\ufeffPrefix, Tags\n10.0.0.0/8, a=1 ; b = 2\n"), FormatCSV)
+	require.NoError(t, err)
+	require.Len(t, addrs, 1)
+	assert.Equal(t, map[string]interface{}{"a": "1", "b": "2"}, addrs[0].Tags)
+
+	for _, input := range []string{
+		"name\nx\n",
+		"prefix,owner\n10.0.0.0/8,me\n",
+		"prefix,network\n10.0.0.0/8,one\n",
+		"prefix,tags\n10.0.0.0/8,novalue\n",
+		"prefix,kvps\n10.0.0.0/8,{bad\n",
+		"",
+	} {
+		_, err := ReadAddresses(strings.NewReader(input), FormatCSV)
+		assert.Error(t, err, input)
+	}
+
+	_, err = ReadAddresses(strings.NewReader(`[{"prefix": "10.0.0.0/8", "id": 1}]`), FormatJSON)
+	assert.Error(t, err)
+}
+
+func TestSortParentsFirst(t *testing.T) {
+	addrs := []*Address{
+		{Prefix: "bogus"},
+		{Network: 2, Prefix: "10.0.0.0/8"},
+		{Network: 1, Prefix: "10.1.0.0/24"},
+		{Network: 1, Prefix: "10.0.0.5"},
+		{Network: 1, Prefix: "10.0.0.0/16"},
+	}
+	SortParentsFirst(addrs)
+
+	got := []string{}
+	for _, a := range addrs {
+		got = append(got, a.Prefix)
+	}
+	assert.Equal(t, []string{"10.0.0.0/16", "10.1.0.0/24", "10.0.0.5", "10.0.0.0/8", "bogus"}, got)
+}
+
+func TestValidateImport(t *testing.T) {
+	errs := ValidateImport([]*Address{
+		{Network: 1, Prefix: "10.0.0.0/16", Status: StatusPlanned},
+		{Network: 1, Prefix: "10.0.0.1"},
+		{Network: 2, Prefix: "10.0.0.0/16"},
+		{Network: 1, Prefix: "10.0.0.0/16"},
+		{Network: 1, Prefix: "10.0.0.1/16"},
+		{Network: 1, Prefix: "10.2.0.0/16", Status: "reserved"},
+		{Prefix: "10.3.0.0/16"},
+		{Network: 1, Prefix: "10.0.0.0/33"},
+	})
+
+	msgs := []string{}
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	assert.Equal(t, []string{
+		"address 4 (10.0.0.0/16): duplicate prefix in network 1",
+		"address 5 (10.0.0.1/16): host bits are set, the prefix is 10.0.0.0/16",
+		"address 5 (10.0.0.1/16): duplicate prefix in network 1",
+		`address 6 (10.2.0.0/16): unknown status "reserved"`,
+		"address 7 (10.3.0.0/16): network is required",
+		`address 8 (10.0.0.0/33): invalid prefix "10.0.0.0/33"`,
+	}, msgs)
+}
@@ -0,0 +1,278 @@
+package ipam
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"sort"
+)
+
+// Node is an address of a Tree, linked to its parent and children.
+type Node struct {
+	Address *Address
+
+	// Parent is nil for root addresses and orphans.
+	Parent   *Node
+	Children []*Node
+
+	// Depth is 0 for root addresses and orphans.
+	Depth int
+}
+
+// Tree is the parent/child graph of addresses, as given by their Parent
+// field, e.g. as loaded by IPAMService.GetTree.
+type Tree struct {
+	// Roots holds the addresses without parent.
+	Roots []*Node
+	// Orphans holds the addresses whose parent is not in the tree.
+	Orphans []*Node
+
+	nodes map[int]*Node
+}
+
+// NewTree links addresses to their parents. Children are ordered by
+// address. Addresses whose parent is missing, or which are their own
+// ancestor, are kept apart as orphans, and are walked after the roots.
+func NewTree(addrs []*Address) *Tree {
+	t := &Tree{Roots: []*Node{}, Orphans: []*Node{}, nodes: make(map[int]*Node, len(addrs))}
+	for _, a := range addrs {
+		t.nodes[a.ID] = &Node{Address: a}
+	}
+
+	for _, a := range addrs {
+		n := t.nodes[a.ID]
+		switch parent, ok := t.nodes[a.Parent]; {
+		case a.Parent == 0:
+			t.Roots = append(t.Roots, n)
+		case !ok || parent == n:
+			t.Orphans = append(t.Orphans, n)
+		default:
+			n.Parent = parent
+			parent.Children = append(parent.Children, n)
+		}
+	}
+
+	// Addresses caught in a parent cycle are reachable from no root; cut
+	// each cycle at its first address, which becomes an orphan.
+	reached := map[*Node]bool{}
+	mark := func(n *Node) bool {
+		reached[n] = true
+		return true
+	}
+	for _, n := range t.tops() {
+		n.walk(mark)
+	}
+	for _, a := range addrs {
+		n := t.nodes[a.ID]
+		if reached[n] {
+			continue
+		}
+		siblings := n.Parent.Children
+		for i, s := range siblings {
+			if s == n {
+				n.Parent.Children = append(siblings[:i:i], siblings[i+1:]...)
+				break
+			}
+		}
+		n.Parent = nil
+		t.Orphans = append(t.Orphans, n)
+		n.walk(mark)
+	}
+
+	sortNodes(t.Roots)
+	sortNodes(t.Orphans)
+	for _, n := range t.tops() {
+		n.walk(func(n *Node) bool {
+			sortNodes(n.Children)
+			for _, c := range n.Children {
+				c.Depth = n.Depth + 1
+			}
+			return true
+		})
+	}
+
+	return t
+}
+
+func sortNodes(nodes []*Node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, errA := nodes[i].Address.ParsePrefix()
+		b, errB := nodes[j].Address.ParsePrefix()
+		if errA != nil || errB != nil {
+			return errA == nil
+		}
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c < 0
+		}
+		return a.Bits() < b.Bits()
+	})
+}
+
+// Node returns the node of the address with the given ID, or nil.
+func (t *Tree) Node(id int) *Node {
+	return t.nodes[id]
+}
+
+// Len returns the number of addresses in the tree.
+func (t *Tree) Len() int {
+	return len(t.nodes)
+}
+
+// DepthFirst calls fn for each address, parents before their children,
+// until fn returns false.
+func (t *Tree) DepthFirst(fn func(*Node) bool) {
+	for _, n := range t.tops() {
+		if !n.walk(fn) {
+			return
+		}
+	}
+}
+
+// BreadthFirst calls fn for each address, level by level, until fn
+// returns false.
+func (t *Tree) BreadthFirst(fn func(*Node) bool) {
+	queue := t.tops()
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if !fn(n) {
+			return
+		}
+		queue = append(queue, n.Children...)
+	}
+}
+
+func (t *Tree) tops() []*Node {
+	return append(append([]*Node{}, t.Roots...), t.Orphans...)
+}
+
+func (n *Node) walk(fn func(*Node) bool) bool {
+	if !fn(n) {
+		return false
+	}
+	for _, c := range n.Children {
+		if !c.walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Tag is an effective tag of an address.
+type Tag struct {
+	Name  string
+	Value interface{}
+
+	// Source is the ID of the address the tag is set on; it is the node's
+	// own ID unless the tag is inherited. It is 0 for tags an orphan
+	// inherits from its missing ancestors, as reported by InheritedTags.
+	Source    int
+	Inherited bool
+}
+
+// EffectiveTags returns the tags of the address: its own, plus those
+// inherited from its ancestors that it does not override. The tags an
+// orphan inherits are taken from its InheritedTags, when they are a JSON
+// object.
+func (n *Node) EffectiveTags() map[string]*Tag {
+	var tags map[string]*Tag
+	if n.Parent != nil {
+		tags = n.Parent.EffectiveTags()
+		for _, t := range tags {
+			t.Inherited = true
+		}
+	} else {
+		tags = map[string]*Tag{}
+		if n.Address.Parent != 0 && n.Address.InheritedTags != "" {
+			inherited := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(n.Address.InheritedTags), &inherited); err == nil {
+				for name, v := range inherited {
+					tags[name] = &Tag{Name: name, Value: v, Inherited: true}
+				}
+			}
+		}
+	}
+
+	for name, v := range n.Address.Tags {
+		tags[name] = &Tag{Name: name, Value: v, Source: n.Address.ID}
+	}
+	return tags
+}
+
+// HasTag reports whether the address has the effective tag name with the
+// given value. An empty value matches any value.
+func (n *Node) HasTag(name, value string) bool {
+	t, ok := n.EffectiveTags()[name]
+	if !ok {
+		return false
+	}
+	return value == "" || tagValue(t.Value) == value
+}
+
+// Tagged returns the addresses having the effective tag name with the
+// given value, depth first. An empty value matches any value.
+func (t *Tree) Tagged(name, value string) []*Node {
+	nodes := []*Node{}
+	t.DepthFirst(func(n *Node) bool {
+		if n.HasTag(name, value) {
+			nodes = append(nodes, n)
+		}
+		return true
+	})
+	return nodes
+}
+
+func tagValue(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// Overlap is an inconsistency between the prefixes of two addresses.
+type Overlap struct {
+	A, B   *Node
+	Reason string
+}
+
+func (o *Overlap) String() string {
+	return fmt.Sprintf("%s (%d) and %s (%d): %s", o.A.Address.Prefix, o.A.Address.ID, o.B.Address.Prefix, o.B.Address.ID, o.Reason)
+}
+
+// Overlaps finds children lying outside their parent and siblings of the
+// same network overlapping each other.
+func (t *Tree) Overlaps() []*Overlap {
+	overlaps := []*Overlap{}
+	check := func(parent *Node, siblings []*Node) {
+		prefixes := make([]netip.Prefix, len(siblings))
+		valid := make([]bool, len(siblings))
+		for i, s := range siblings {
+			p, err := s.Address.ParsePrefix()
+			prefixes[i], valid[i] = p, err == nil
+		}
+
+		if parent != nil {
+			pp, err := parent.Address.ParsePrefix()
+			for i, s := range siblings {
+				if err == nil && valid[i] && !contains(pp, prefixes[i]) {
+					overlaps = append(overlaps, &Overlap{A: parent, B: s, Reason: "child lies outside its parent"})
+				}
+			}
+		}
+		for i := range siblings {
+			for j := i + 1; j < len(siblings); j++ {
+				a, b := siblings[i].Address, siblings[j].Address
+				if valid[i] && valid[j] && a.Network == b.Network && prefixes[i].Overlaps(prefixes[j]) {
+					overlaps = append(overlaps, &Overlap{A: siblings[i], B: siblings[j], Reason: "siblings overlap"})
+				}
+			}
+		}
+	}
+
+	check(nil, t.Roots)
+	t.DepthFirst(func(n *Node) bool {
+		check(n, n.Children)
+		return true
+	})
+	return overlaps
+}
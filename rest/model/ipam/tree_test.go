@@ -0,0 +1,98 @@
+package ipam
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testTree() *Tree {
+	return NewTree([]*Address{
+		{ID: 4, Prefix: "10.0.1.0/24", Parent: 1, Tags: map[string]interface{}{"team": "web"}},
+		{ID: 1, Prefix: "10.0.0.0/16", Tags: map[string]interface{}{"env": "prod", "team": "infra"}},
+		{ID: 2, Prefix: "10.0.0.0/24", Parent: 1},
+		{ID: 3, Prefix: "10.0.0.10/32", Parent: 2},
+		{ID: 5, Prefix: "10.0.1.128/25", Parent: 1},
+		{ID: 6, Prefix: "192.168.0.0/24", Parent: 1},
+		{ID: 7, Prefix: "172.16.0.0/24", Parent: 99, InheritedTags: `{"env": "dev"}`},
+		{ID: 8, Prefix: "172.17.0.0/24", Parent: 9},
+		{ID: 9, Prefix: "172.17.0.0/25", Parent: 8},
+	})
+}
+
+func TestNewTree(t *testing.T) {
+	tree := testTree()
+
+	require.Len(t, tree.Roots, 1)
+	assert.Equal(t, 9, tree.Len())
+	require.Len(t, tree.Orphans, 2)
+	assert.Equal(t, 7, tree.Orphans[0].Address.ID)
+	assert.Equal(t, 8, tree.Orphans[1].Address.ID)
+	assert.Equal(t, 9, tree.Orphans[1].Children[0].Address.ID)
+	assert.Empty(t, tree.Node(9).Children)
+	assert.Equal(t, 2, tree.Node(3).Depth)
+	assert.Nil(t, tree.Node(100))
+
+	var ids []int
+	tree.DepthFirst(func(n *Node) bool {
+		ids = append(ids, n.Address.ID)
+		return true
+	})
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7, 8, 9}, ids)
+
+	ids = nil
+	tree.BreadthFirst(func(n *Node) bool {
+		ids = append(ids, n.Address.ID)
+		return len(ids) < 6
+	})
+	assert.Equal(t, []int{1, 7, 8, 2, 4, 5}, ids)
+
+	ids = nil
+	tree.DepthFirst(func(n *Node) bool {
+		ids = append(ids, n.Address.ID)
+		return n.Address.ID != 3
+	})
+	assert.Equal(t, []int{1, 2, 3}, ids)
+}
+
+func TestNode_EffectiveTags(t *testing.T) {
+	tree := testTree()
+
+	tags := tree.Node(3).EffectiveTags()
+	require.Len(t, tags, 2)
+	assert.Equal(t, &Tag{Name: "env", Value: "prod", Source: 1, Inherited: true}, tags["env"])
+	assert.Equal(t, "infra", tags["team"].Value)
+
+	tags = tree.Node(5).EffectiveTags()
+	assert.Equal(t, &Tag{Name: "team", Value: "web", Source: 4, Inherited: false}, tree.Node(4).EffectiveTags()["team"])
+	assert.Equal(t, "infra", tags["team"].Value)
+
+	tags = tree.Node(7).EffectiveTags()
+	assert.Equal(t, &Tag{Name: "env", Value: "dev", Inherited: true}, tags["env"])
+
+	assert.True(t, tree.Node(4).HasTag("team", "web"))
+	assert.True(t, tree.Node(4).HasTag("env", ""))
+	assert.False(t, tree.Node(4).HasTag("team", "infra"))
+	assert.False(t, tree.Node(8).HasTag("env", ""))
+
+	var ids []int
+	for _, n := range tree.Tagged("env", "prod") {
+		ids = append(ids, n.Address.ID)
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5, 6}, ids)
+}
+
+func TestTree_Overlaps(t *testing.T) {
+	tree := testTree()
+
+	overlaps := tree.Overlaps()
+	got := []string{}
+	for _, o := range overlaps {
+		got = append(got, o.String())
+	}
+	assert.Equal(t, []string{
+		"10.0.0.0/16 (1) and 192.168.0.0/24 (6): child lies outside its parent",
+		"10.0.1.0/24 (4) and 10.0.1.128/25 (5): siblings overlap",
+	}, got)
+}
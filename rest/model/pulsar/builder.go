@@ -0,0 +1,479 @@
+package pulsar
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Job type ids.
+const (
+	TypeIDLatency = "latency"
+	TypeIDCustom  = "custom"
+)
+
+// Metric names usable in BlendMetricWeights.
+const (
+	BlendMetricLatency      = "latency"
+	BlendMetricAvailability = "availability"
+	BlendMetricThroughput   = "throughput"
+)
+
+var blendMetrics = map[string]struct{}{
+	BlendMetricLatency:      {},
+	BlendMetricAvailability: {},
+	BlendMetricThroughput:   {},
+}
+
+// hostLabel matches a single DNS label of a host name.
+var hostLabel = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?$`)
+
+// SetTime sets the timestamp of the weights.
+func (b *BlendMetricWeights) SetTime(t time.Time) {
+	b.Timestamp = int(t.Unix())
+}
+
+// Time returns the timestamp of the weights.
+func (b *BlendMetricWeights) Time() time.Time {
+	return time.Unix(int64(b.Timestamp), 0)
+}
+
+// Validate checks that every weight names a known, distinct metric, that no
+// weight is negative and that the weights add up to 100.
+func (b *BlendMetricWeights) Validate() error {
+	if len(b.Weights) == 0 {
+		return errors.New("blend metric weights must have at least one weight")
+	}
+
+	seen := map[string]bool{}
+	total := 0
+	for _, w := range b.Weights {
+		if _, ok := blendMetrics[w.Name]; !ok {
+			return fmt.Errorf("unknown blend metric: %q", w.Name)
+		}
+		if seen[w.Name] {
+			return fmt.Errorf("blend metric %q is weighted more than once", w.Name)
+		}
+		seen[w.Name] = true
+
+		if w.Weight < 0 {
+			return fmt.Errorf("blend metric %q has negative weight %d", w.Name, w.Weight)
+		}
+		total += w.Weight
+	}
+
+	if total != 100 {
+		return fmt.Errorf("blend metric weights must add up to 100, got %d", total)
+	}
+	return nil
+}
+
+// Normalize scales the weights so they add up to 100, keeping their
+// proportions as closely as integer weights allow. Weights summing to zero
+// are left untouched.
+func (b *BlendMetricWeights) Normalize() {
+	total := 0
+	for _, w := range b.Weights {
+		total += w.Weight
+	}
+	if total <= 0 || total == 100 {
+		return
+	}
+
+	// Largest remainder method, so the rounded weights still add up to 100.
+	type share struct {
+		w   *Weights
+		rem int
+	}
+	shares := make([]share, len(b.Weights))
+	assigned := 0
+	for i, w := range b.Weights {
+		scaled := w.Weight * 100
+		shares[i] = share{w: w, rem: scaled % total}
+		w.Weight = scaled / total
+		assigned += w.Weight
+	}
+	sort.SliceStable(shares, func(i, j int) bool { return shares[i].rem > shares[j].rem })
+	for i := 0; assigned < 100; i++ {
+		shares[i%len(shares)].w.Weight++
+		assigned++
+	}
+}
+
+// JobBuilder builds a validated Job, or a JobSpec holding only the fields
+// that were set. Setters record the first problem they find, which Build and
+// Spec then report.
+type JobBuilder struct {
+	job       Job
+	cfg       JobConfig
+	activeSet bool
+	sharedSet bool
+	errs      []error
+}
+
+// NewJSJobBuilder starts building a JavaScript (latency) Pulsar job.
+func NewJSJobBuilder(name string, appid string) *JobBuilder {
+	return &JobBuilder{job: Job{
+		Name:   name,
+		TypeID: TypeIDLatency,
+		AppID:  appid,
+	}}
+}
+
+// NewBBJobBuilder starts building a Bulk Beacon (custom) Pulsar job.
+func NewBBJobBuilder(name string, appid string) *JobBuilder {
+	return &JobBuilder{job: Job{
+		Name:   name,
+		TypeID: TypeIDCustom,
+		AppID:  appid,
+	}}
+}
+
+func (b *JobBuilder) fail(format string, args ...interface{}) *JobBuilder {
+	b.errs = append(b.errs, fmt.Errorf(format, args...))
+	return b
+}
+
+// JobID sets the id of an existing job, for building a desired state to
+// pass to Job.Diff.
+func (b *JobBuilder) JobID(id string) *JobBuilder {
+	b.job.JobID = id
+	return b
+}
+
+// Host sets the host name or IP address the job measures.
+func (b *JobBuilder) Host(host string) *JobBuilder {
+	if err := validateHost(host); err != nil {
+		return b.fail("%s", err)
+	}
+	b.cfg.Host = &host
+	return b
+}
+
+// URLPath sets the path of the resource the job fetches.
+func (b *JobBuilder) URLPath(path string) *JobBuilder {
+	if !strings.HasPrefix(path, "/") {
+		return b.fail("url path must start with '/', got %q", path)
+	}
+	u, err := url.Parse(path)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return b.fail("invalid url path: %q", path)
+	}
+	b.cfg.URLPath = &path
+	return b
+}
+
+// Protocols sets whether the job measures over http, https or both.
+func (b *JobBuilder) Protocols(http, https bool) *JobBuilder {
+	if !http && !https {
+		return b.fail("at least one of http and https must be enabled")
+	}
+	b.cfg.HTTP = &http
+	b.cfg.HTTPS = &https
+	return b
+}
+
+// RequestTimeout sets the timeout of a single request, with millisecond
+// precision.
+func (b *JobBuilder) RequestTimeout(d time.Duration) *JobBuilder {
+	ms := int(d / time.Millisecond)
+	if ms <= 0 {
+		return b.fail("request timeout must be at least 1ms, got %s", d)
+	}
+	b.cfg.RequestTimeoutMillis = &ms
+	return b
+}
+
+// JobTimeout sets the timeout of the whole job, with millisecond precision.
+func (b *JobBuilder) JobTimeout(d time.Duration) *JobBuilder {
+	ms := int(d / time.Millisecond)
+	if ms <= 0 {
+		return b.fail("job timeout must be at least 1ms, got %s", d)
+	}
+	b.cfg.JobTimeoutMillis = &ms
+	return b
+}
+
+// UseXHR sets whether the job measures through XMLHttpRequest.
+func (b *JobBuilder) UseXHR(use bool) *JobBuilder {
+	b.cfg.UseXHR = &use
+	return b
+}
+
+// StaticValues sets whether the job reports static values.
+func (b *JobBuilder) StaticValues(static bool) *JobBuilder {
+	b.cfg.StaticValues = &static
+	return b
+}
+
+// Active sets whether the job is active.
+func (b *JobBuilder) Active(active bool) *JobBuilder {
+	b.job.Active = active
+	b.activeSet = true
+	return b
+}
+
+// Shared sets whether the job is shared.
+func (b *JobBuilder) Shared(shared bool) *JobBuilder {
+	b.job.Shared = shared
+	b.sharedSet = true
+	return b
+}
+
+// Weight adds a blend metric weight. Use Weights to set the timestamp.
+func (b *JobBuilder) Weight(name string, weight int, defaultValue float64, maximize bool) *JobBuilder {
+	if b.cfg.BlendMetricWeights == nil {
+		b.cfg.BlendMetricWeights = &BlendMetricWeights{Weights: []*Weights{}}
+	}
+	bmw := b.cfg.BlendMetricWeights
+	bmw.Weights = append(bmw.Weights, &Weights{
+		Name:         name,
+		Weight:       weight,
+		DefaultValue: defaultValue,
+		Maximize:     maximize,
+	})
+	return b
+}
+
+// Weights sets the timestamp of the blend metric weights and, if normalize
+// is true, rescales them to add up to 100 before they are validated.
+func (b *JobBuilder) Weights(t time.Time, normalize bool) *JobBuilder {
+	if b.cfg.BlendMetricWeights == nil {
+		b.cfg.BlendMetricWeights = &BlendMetricWeights{Weights: []*Weights{}}
+	}
+	bmw := b.cfg.BlendMetricWeights
+	bmw.SetTime(t)
+	if normalize {
+		bmw.Normalize()
+	}
+	return b
+}
+
+// Build validates and returns the job. A job without any config, like a
+// Bulk Beacon job with no settings, is returned with a nil Config.
+func (b *JobBuilder) Build() (*Job, error) {
+	if len(b.errs) > 0 {
+		return nil, b.errs[0]
+	}
+
+	j, cfg := b.job, b.cfg
+
+	if j.Name == "" {
+		return nil, errors.New("pulsar job requires a name")
+	}
+	if j.AppID == "" {
+		return nil, errors.New("pulsar job requires an appid")
+	}
+	if j.TypeID == TypeIDLatency {
+		if cfg.Host == nil {
+			return nil, errors.New("latency pulsar job requires a host")
+		}
+		if cfg.URLPath == nil {
+			return nil, errors.New("latency pulsar job requires a url path")
+		}
+	}
+	if cfg.RequestTimeoutMillis != nil && cfg.JobTimeoutMillis != nil &&
+		*cfg.RequestTimeoutMillis > *cfg.JobTimeoutMillis {
+		return nil, fmt.Errorf(
+			"request timeout (%dms) must not exceed job timeout (%dms)",
+			*cfg.RequestTimeoutMillis, *cfg.JobTimeoutMillis,
+		)
+	}
+	if cfg.BlendMetricWeights != nil {
+		if err := cfg.BlendMetricWeights.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if !reflect.DeepEqual(cfg, JobConfig{}) {
+		j.Config = &cfg
+	}
+	return &j, nil
+}
+
+// Spec validates the job like Build and returns it as a desired state for
+// Job.Diff and Job.Merge. Active and shared are only specified if they were
+// set on the builder.
+func (b *JobBuilder) Spec() (*JobSpec, error) {
+	j, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &JobSpec{
+		Name:   j.Name,
+		TypeID: j.TypeID,
+		AppID:  j.AppID,
+		JobID:  j.JobID,
+		Config: j.Config,
+	}
+	if b.activeSet {
+		spec.Active = &j.Active
+	}
+	if b.sharedSet {
+		spec.Shared = &j.Shared
+	}
+	return spec, nil
+}
+
+// JobSpec is the desired state of a job. Empty names and type ids, nil
+// Active and Shared and config fields left nil are not specified, and keep
+// their current values when the spec is merged into a job.
+type JobSpec struct {
+	Name   string
+	TypeID string
+	AppID  string
+	JobID  string
+	Active *bool
+	Shared *bool
+	Config *JobConfig
+}
+
+// Spec returns the job as a desired state specifying all of its fields.
+func (j *Job) Spec() *JobSpec {
+	active, shared := j.Active, j.Shared
+	return &JobSpec{
+		Name:   j.Name,
+		TypeID: j.TypeID,
+		AppID:  j.AppID,
+		JobID:  j.JobID,
+		Active: &active,
+		Shared: &shared,
+		Config: j.Config,
+	}
+}
+
+// Diff returns the json names (e.g. "config.host") of the fields desired
+// specifies that differ from the job. The timestamp of the blend metric
+// weights is not compared. An empty result means updating the job to
+// Merge(desired) would be a no-op.
+func (j *Job) Diff(desired *JobSpec) []string {
+	diff := []string{}
+
+	if desired.Name != "" && desired.Name != j.Name {
+		diff = append(diff, "name")
+	}
+	if desired.TypeID != "" && desired.TypeID != j.TypeID {
+		diff = append(diff, "typeid")
+	}
+	if desired.Active != nil && *desired.Active != j.Active {
+		diff = append(diff, "active")
+	}
+	if desired.Shared != nil && *desired.Shared != j.Shared {
+		diff = append(diff, "shared")
+	}
+
+	if desired.Config == nil {
+		return diff
+	}
+	current := j.Config
+	if current == nil {
+		current = &JobConfig{}
+	}
+
+	dv := reflect.ValueOf(desired.Config).Elem()
+	cv := reflect.ValueOf(current).Elem()
+	t := dv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if dv.Field(i).IsNil() {
+			continue
+		}
+		name := "config." + strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+
+		if t.Field(i).Name == "BlendMetricWeights" {
+			if !sameWeights(desired.Config.BlendMetricWeights, current.BlendMetricWeights) {
+				diff = append(diff, name)
+			}
+			continue
+		}
+		if cv.Field(i).IsNil() || !reflect.DeepEqual(dv.Field(i).Elem().Interface(), cv.Field(i).Elem().Interface()) {
+			diff = append(diff, name)
+		}
+	}
+
+	return diff
+}
+
+// Merge returns a copy of the job overridden by the fields desired
+// specifies. The job itself is left untouched.
+func (j *Job) Merge(desired *JobSpec) *Job {
+	merged := *j
+
+	if desired.Name != "" {
+		merged.Name = desired.Name
+	}
+	if desired.TypeID != "" {
+		merged.TypeID = desired.TypeID
+	}
+	if desired.Active != nil {
+		merged.Active = *desired.Active
+	}
+	if desired.Shared != nil {
+		merged.Shared = *desired.Shared
+	}
+
+	if j.Config == nil && desired.Config == nil {
+		return &merged
+	}
+	cfg := &JobConfig{}
+	if j.Config != nil {
+		*cfg = *j.Config
+	}
+	if desired.Config != nil {
+		dv := reflect.ValueOf(desired.Config).Elem()
+		mv := reflect.ValueOf(cfg).Elem()
+		for i := 0; i < dv.NumField(); i++ {
+			if !dv.Field(i).IsNil() {
+				mv.Field(i).Set(dv.Field(i))
+			}
+		}
+	}
+	merged.Config = cfg
+	return &merged
+}
+
+// sameWeights compares the weights of a and b regardless of order.
+func sameWeights(a, b *BlendMetricWeights) bool {
+	if b == nil || len(a.Weights) != len(b.Weights) {
+		return false
+	}
+	byName := make(map[string]Weights, len(b.Weights))
+	for _, w := range b.Weights {
+		byName[w.Name] = *w
+	}
+	for _, w := range a.Weights {
+		if other, ok := byName[w.Name]; !ok || other != *w {
+			return false
+		}
+	}
+	return true
+}
+
+func validateHost(host string) error {
+	if host == "" {
+		return errors.New("host must not be empty")
+	}
+	if strings.Contains(host, "://") {
+		return fmt.Errorf("host must not include a scheme, got %q", host)
+	}
+	if net.ParseIP(host) != nil {
+		return nil
+	}
+
+	name := strings.TrimSuffix(host, ".")
+	if len(name) > 253 {
+		return fmt.Errorf("host name too long: %q", host)
+	}
+	for _, label := range strings.Split(name, ".") {
+		if !hostLabel.MatchString(label) {
+			return fmt.Errorf("invalid host name: %q", host)
+		}
+	}
+	return nil
+}
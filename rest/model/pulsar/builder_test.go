@@ -0,0 +1,181 @@
+package pulsar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobBuilder(t *testing.T) {
+	ts := time.Unix(1700000000, 0)
+
+	j, err := NewJSJobBuilder("myJob", "myAppId").
+		Host("www.example.com").
+		URLPath("/pulsar/15kb.jpg").
+		Protocols(false, true).
+		RequestTimeout(2*time.Second).
+		JobTimeout(5*time.Second).
+		Weight(BlendMetricLatency, 3, 0, false).
+		Weight(BlendMetricAvailability, 1, 100, true).
+		Weights(ts, true).
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, TypeIDLatency, j.TypeID)
+	assert.Equal(t, "www.example.com", *j.Config.Host)
+	assert.Equal(t, "/pulsar/15kb.jpg", *j.Config.URLPath)
+	assert.False(t, *j.Config.HTTP)
+	assert.True(t, *j.Config.HTTPS)
+	assert.Equal(t, 2000, *j.Config.RequestTimeoutMillis)
+	assert.Equal(t, 5000, *j.Config.JobTimeoutMillis)
+	assert.Equal(t, 1700000000, j.Config.BlendMetricWeights.Timestamp)
+	assert.Equal(t, ts, j.Config.BlendMetricWeights.Time())
+	assert.Equal(t, 75, j.Config.BlendMetricWeights.Weights[0].Weight)
+	assert.Equal(t, 25, j.Config.BlendMetricWeights.Weights[1].Weight)
+
+	j, err = NewBBJobBuilder("myBBJob", "myAppId").Build()
+	require.NoError(t, err)
+	assert.Equal(t, TypeIDCustom, j.TypeID)
+	assert.Nil(t, j.Config)
+}
+
+func TestJobBuilder_Errors(t *testing.T) {
+	cases := map[string]struct {
+		b   *JobBuilder
+		err string
+	}{
+		"scheme in host": {
+			NewJSJobBuilder("j", "a").Host("https://example.com"),
+			`host must not include a scheme, got "https://example.com"`,
+		},
+		"bad host": {
+			NewJSJobBuilder("j", "a").Host("exa_mple.com"),
+			`invalid host name: "exa_mple.com"`,
+		},
+		"relative path": {
+			NewJSJobBuilder("j", "a").Host("example.com").URLPath("img.jpg"),
+			`url path must start with '/', got "img.jpg"`,
+		},
+		"missing host": {
+			NewJSJobBuilder("j", "a").URLPath("/img.jpg"),
+			"latency pulsar job requires a host",
+		},
+		"no protocol": {
+			NewBBJobBuilder("j", "a").Protocols(false, false),
+			"at least one of http and https must be enabled",
+		},
+		"zero timeout": {
+			NewBBJobBuilder("j", "a").RequestTimeout(time.Microsecond),
+			"request timeout must be at least 1ms, got 1µs",
+		},
+		"request exceeds job timeout": {
+			NewBBJobBuilder("j", "a").RequestTimeout(time.Second * 10).JobTimeout(time.Second),
+			"request timeout (10000ms) must not exceed job timeout (1000ms)",
+		},
+		"unknown metric": {
+			NewBBJobBuilder("j", "a").Weight("speed", 100, 0, false),
+			`unknown blend metric: "speed"`,
+		},
+		"duplicate metric": {
+			NewBBJobBuilder("j", "a").Weight(BlendMetricLatency, 50, 0, false).Weight(BlendMetricLatency, 50, 0, false),
+			`blend metric "latency" is weighted more than once`,
+		},
+		"weights not normalized": {
+			NewBBJobBuilder("j", "a").Weight(BlendMetricLatency, 3, 0, false).Weight(BlendMetricAvailability, 1, 0, true),
+			"blend metric weights must add up to 100, got 4",
+		},
+		"negative weight": {
+			NewBBJobBuilder("j", "a").Weight(BlendMetricLatency, 110, 0, false).Weight(BlendMetricAvailability, -10, 0, true),
+			`blend metric "availability" has negative weight -10`,
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := c.b.Build()
+			assert.EqualError(t, err, c.err)
+		})
+	}
+}
+
+func TestBlendMetricWeights_Normalize(t *testing.T) {
+	b := &BlendMetricWeights{Weights: []*Weights{
+		{Name: BlendMetricLatency, Weight: 1},
+		{Name: BlendMetricAvailability, Weight: 1},
+		{Name: BlendMetricThroughput, Weight: 1},
+	}}
+	b.Normalize()
+	assert.Equal(t, 34, b.Weights[0].Weight)
+	assert.Equal(t, 33, b.Weights[1].Weight)
+	assert.Equal(t, 33, b.Weights[2].Weight)
+	assert.NoError(t, b.Validate())
+}
+
+func TestJob_Diff(t *testing.T) {
+	current, err := NewJSJobBuilder("myJob", "myAppId").
+		Host("example.com").
+		URLPath("/img.jpg").
+		Weight(BlendMetricLatency, 60, 0, false).
+		Weight(BlendMetricAvailability, 40, 100, true).
+		Weights(time.Unix(1, 0), false).
+		Build()
+	require.NoError(t, err)
+
+	desired, err := NewJSJobBuilder("myJob", "myAppId").
+		Host("example.com").
+		URLPath("/img.jpg").
+		Weight(BlendMetricAvailability, 40, 100, true).
+		Weight(BlendMetricLatency, 60, 0, false).
+		Weights(time.Unix(2, 0), false).
+		Spec()
+	require.NoError(t, err)
+	assert.Empty(t, current.Diff(desired))
+
+	// Active and shared are only compared once set on the builder.
+	current.Active = true
+	assert.Empty(t, current.Diff(desired))
+
+	desired, err = NewJSJobBuilder("myJob", "myAppId").
+		Host("example.com").
+		URLPath("/img.jpg").
+		Active(false).
+		Protocols(true, true).
+		Weight(BlendMetricAvailability, 50, 100, true).
+		Weight(BlendMetricLatency, 50, 0, false).
+		Spec()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"active", "config.http", "config.https", "config.blend_metric_weights"}, current.Diff(desired))
+
+	// A whole job specifies active and shared, including direct edits.
+	j := &Job{Name: "myJob"}
+	assert.Equal(t, []string{"active"}, current.Diff(j.Spec()))
+	j.Active = true
+	assert.Empty(t, current.Diff(j.Spec()))
+}
+
+func TestJob_Merge(t *testing.T) {
+	current, err := NewJSJobBuilder("myJob", "myAppId").
+		JobID("myJobId").
+		Host("example.com").
+		URLPath("/img.jpg").
+		Active(true).
+		Build()
+	require.NoError(t, err)
+
+	desired, err := NewJSJobBuilder("myJob", "myAppId").
+		JobID("myJobId").
+		Host("www.example.com").
+		URLPath("/img.jpg").
+		Spec()
+	require.NoError(t, err)
+
+	merged := current.Merge(desired)
+	assert.True(t, merged.Active)
+	assert.Equal(t, "www.example.com", *merged.Config.Host)
+	assert.Equal(t, "/img.jpg", *merged.Config.URLPath)
+	assert.Equal(t, "example.com", *current.Config.Host)
+	assert.Empty(t, merged.Diff(desired))
+	assert.Equal(t, current.Config, current.Merge((&Job{Active: true}).Spec()).Config)
+}
@@ -0,0 +1,146 @@
+package pulsar
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Aggregation is the statistic computed over the samples in each period of
+// a Pulsar time series.
+type Aggregation string
+
+// Supported aggregations.
+const (
+	AggregationAvg = Aggregation("avg")
+	AggregationMin = Aggregation("min")
+	AggregationMax = Aggregation("max")
+	AggregationP50 = Aggregation("p50")
+	AggregationP75 = Aggregation("p75")
+	AggregationP90 = Aggregation("p90")
+	AggregationP95 = Aggregation("p95")
+	AggregationP99 = Aggregation("p99")
+)
+
+// Dimension is a field Pulsar data can be grouped by.
+type Dimension string
+
+// Supported dimensions.
+const (
+	DimensionJob      = Dimension("job")
+	DimensionGeo      = Dimension("geo")
+	DimensionASN      = Dimension("asn")
+	DimensionDecision = Dimension("decision")
+)
+
+// DataQuery selects the Pulsar data returned by the PulsarDataService.
+// Zero fields are left out of the query.
+type DataQuery struct {
+	// Start and End bound the queried time range.
+	Start time.Time
+	End   time.Time
+
+	// Period is the width of each time series bucket, e.g. time.Hour.
+	Period time.Duration
+
+	Aggregation Aggregation
+
+	// GroupBy splits the results into one time series per distinct value
+	// of each dimension.
+	GroupBy []Dimension
+
+	// Filters narrowing down the samples.
+	AppID  string
+	JobIDs []string
+	Geo    string
+	ASN    string
+
+	// Record limits decision queries to a record, as "zone/domain/type".
+	Record string
+}
+
+// DataPoint is a single bucket of a Pulsar time series.
+type DataPoint struct {
+	// Timestamp is the unix start time of the bucket.
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+
+	// Count is the number of samples aggregated into the bucket.
+	Count int `json:"count,omitempty"`
+}
+
+// Time returns the start time of the bucket.
+func (p *DataPoint) Time() time.Time {
+	return time.Unix(p.Timestamp, 0)
+}
+
+// TimeSeries is the Pulsar data for one combination of the grouped
+// dimensions. Dimensions not grouped by are left empty.
+type TimeSeries struct {
+	JobID    string       `json:"jobid,omitempty"`
+	Geo      string       `json:"geo,omitempty"`
+	ASN      string       `json:"asn,omitempty"`
+	Decision string       `json:"decision,omitempty"`
+	Points   []*DataPoint `json:"points"`
+}
+
+// Mean returns the sample-weighted mean of the series. Points without a
+// count are weighted as a single sample. It returns NaN for an empty series.
+func (ts *TimeSeries) Mean() float64 {
+	var sum, n float64
+	for _, p := range ts.Points {
+		w := float64(p.Count)
+		if w == 0 {
+			w = 1
+		}
+		sum += p.Value * w
+		n += w
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	return sum / n
+}
+
+// Percentile returns the pth percentile (0-100) of the bucket values of the
+// series, using nearest rank. It returns NaN for an empty series.
+func (ts *TimeSeries) Percentile(p float64) float64 {
+	if len(ts.Points) == 0 {
+		return math.NaN()
+	}
+
+	values := make([]float64, len(ts.Points))
+	for i, pt := range ts.Points {
+		values[i] = pt.Value
+	}
+	sort.Float64s(values)
+
+	rank := int(math.Ceil(p/100*float64(len(values)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(values) {
+		rank = len(values) - 1
+	}
+	return values[rank]
+}
+
+// DataResult wraps the response of a Pulsar data query.
+type DataResult struct {
+	// Metric is "performance", "availability" or "decisions".
+	Metric      string        `json:"metric"`
+	Aggregation Aggregation   `json:"agg,omitempty"`
+	Start       int64         `json:"start"`
+	End         int64         `json:"end"`
+	Series      []*TimeSeries `json:"graphs"`
+}
+
+// ByJob returns the series of the result keyed by job id. Only meaningful
+// when the query was grouped by job alone.
+func (r *DataResult) ByJob() map[string]*TimeSeries {
+	m := make(map[string]*TimeSeries, len(r.Series))
+	for _, ts := range r.Series {
+		m[ts.JobID] = ts
+	}
+	return m
+}
@@ -0,0 +1,30 @@
+package pulsar
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeSeries_Stats(t *testing.T) {
+	ts := &TimeSeries{Points: []*DataPoint{
+		{Value: 30},
+		{Value: 10},
+		{Value: 20},
+		{Value: 40},
+	}}
+
+	assert.Equal(t, 25.0, ts.Mean())
+	assert.Equal(t, 10.0, ts.Percentile(0))
+	assert.Equal(t, 20.0, ts.Percentile(50))
+	assert.Equal(t, 40.0, ts.Percentile(90))
+	assert.Equal(t, 40.0, ts.Percentile(100))
+
+	ts.Points[0].Count = 3
+	assert.Equal(t, 30.0*3/6+(10.0+20+40)/6, ts.Mean())
+
+	empty := &TimeSeries{}
+	assert.True(t, math.IsNaN(empty.Mean()))
+	assert.True(t, math.IsNaN(empty.Percentile(50)))
+}
@@ -0,0 +1,2 @@
+// Package stats contains definitions for NS1 query and usage statistics.
+package stats
@@ -0,0 +1,164 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// QPS wraps an NS1 /stats/qps response.
+type QPS struct {
+	QPS      float32       `json:"qps"`
+	Networks []*NetworkQPS `json:"networks,omitempty"`
+}
+
+// NetworkQPS is the share of the QPS served by a single network.
+type NetworkQPS struct {
+	Network int     `json:"network"`
+	QPS     float32 `json:"qps"`
+}
+
+// Period is the time range covered by a usage query.
+type Period string
+
+// Supported usage periods.
+const (
+	Period1h  = Period("1h")
+	Period24h = Period("24h")
+	Period30d = Period("30d")
+)
+
+// UsageQuery selects the usage statistics returned by the StatsService.
+// Zero fields are left out of the query.
+type UsageQuery struct {
+	Period Period
+
+	// Aggregate sums the usage of every zone (or record) into one series.
+	Aggregate bool
+
+	// Expand breaks down account and zone usage per zone or per record.
+	Expand bool
+
+	// ByTier breaks down usage per record tier.
+	ByTier bool
+
+	// ByNetwork breaks down usage per network, optionally restricted to
+	// the given network ids.
+	ByNetwork bool
+	Networks  []int
+}
+
+// Sample is the number of queries served in the interval starting at
+// Timestamp. It is encoded as a [timestamp, queries] pair.
+type Sample struct {
+	Timestamp int64
+	Queries   int64
+}
+
+// Time returns the start of the sample interval.
+func (s Sample) Time() time.Time {
+	return time.Unix(s.Timestamp, 0)
+}
+
+// MarshalJSON encodes the sample as a [timestamp, queries] pair.
+func (s Sample) MarshalJSON() ([]byte, error) {
+	return json.Marshal([2]int64{s.Timestamp, s.Queries})
+}
+
+// UnmarshalJSON decodes a [timestamp, queries] pair.
+func (s *Sample) UnmarshalJSON(data []byte) error {
+	var pair []float64
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("expected [timestamp, queries] pair, got %s", data)
+	}
+	s.Timestamp = int64(pair[0])
+	s.Queries = int64(pair[1])
+	return nil
+}
+
+// Usage wraps an element of an NS1 /stats/usage response. The fields
+// identifying the usage (zone, domain, type, network, tier) are set
+// depending on the query and the breakdown requested.
+type Usage struct {
+	Zone       string `json:"zone,omitempty"`
+	Domain     string `json:"domain,omitempty"`
+	RecordType string `json:"rectype,omitempty"`
+	Network    *int   `json:"network,omitempty"`
+	Tier       int    `json:"tier,omitempty"`
+
+	Period Period `json:"period,omitempty"`
+
+	// Queries is the total over the period.
+	Queries int64 `json:"queries"`
+
+	// Graph is the usage over time, oldest sample first.
+	Graph []Sample `json:"graph,omitempty"`
+
+	// Breakdowns, when expanded or broken down by network.
+	Records  []*Usage `json:"records,omitempty"`
+	Zones    []*Usage `json:"zones,omitempty"`
+	Networks []*Usage `json:"networks,omitempty"`
+}
+
+// Aggregate sums the graph into buckets of the given width, e.g. time.Hour
+// or 24 * time.Hour. Buckets are aligned on multiples of d since the unix
+// epoch, in UTC, and returned oldest first.
+func (u *Usage) Aggregate(d time.Duration) []Sample {
+	step := int64(d / time.Second)
+	if step <= 0 {
+		return u.Graph
+	}
+
+	buckets := map[int64]int64{}
+	for _, s := range u.Graph {
+		start := s.Timestamp - s.Timestamp%step
+		buckets[start] += s.Queries
+	}
+
+	samples := make([]Sample, 0, len(buckets))
+	for ts, q := range buckets {
+		samples = append(samples, Sample{Timestamp: ts, Queries: q})
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Timestamp < samples[j].Timestamp })
+	return samples
+}
+
+// Hourly sums the graph per hour.
+func (u *Usage) Hourly() []Sample {
+	return u.Aggregate(time.Hour)
+}
+
+// Daily sums the graph per UTC day.
+func (u *Usage) Daily() []Sample {
+	return u.Aggregate(24 * time.Hour)
+}
+
+// BillingUsage wraps an NS1 /billing-usage/queries response: the query
+// totals of the account between two dates.
+type BillingUsage struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+
+	CleanQueries int64 `json:"clean_queries"`
+	DDoSQueries  int64 `json:"ddos_queries"`
+	NXDResponses int64 `json:"nxd_responses"`
+
+	ByNetwork []*NetworkBillingUsage `json:"by_network,omitempty"`
+}
+
+// NetworkBillingUsage is the share of the billing usage of a single network.
+type NetworkBillingUsage struct {
+	Network      int   `json:"network"`
+	CleanQueries int64 `json:"clean_queries"`
+	DDoSQueries  int64 `json:"ddos_queries"`
+	NXDResponses int64 `json:"nxd_responses"`
+}
+
+// Total returns the sum of clean and DDoS queries.
+func (b *BillingUsage) Total() int64 {
+	return b.CleanQueries + b.DDoSQueries
+}
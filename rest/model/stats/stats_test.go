@@ -0,0 +1,45 @@
+package stats
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSample_JSON(t *testing.T) {
+	var s Sample
+	require.NoError(t, json.Unmarshal([]byte(`[1700000000, 42]`), &s))
+	assert.Equal(t, Sample{Timestamp: 1700000000, Queries: 42}, s)
+	assert.Equal(t, time.Unix(1700000000, 0), s.Time())
+
+	b, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.Equal(t, `[1700000000,42]`, string(b))
+
+	assert.Error(t, json.Unmarshal([]byte(`[1700000000]`), &s))
+}
+
+func TestUsage_Aggregate(t *testing.T) {
+	// 2023-11-14 23:00 UTC onwards, every 30 minutes.
+	base := int64(1700002800)
+	u := &Usage{Graph: []Sample{
+		{Timestamp: base, Queries: 1},
+		{Timestamp: base + 1800, Queries: 2},
+		{Timestamp: base + 3600, Queries: 4},
+		{Timestamp: base + 5400, Queries: 8},
+	}}
+
+	hourly := u.Hourly()
+	require.Len(t, hourly, 2)
+	assert.Equal(t, int64(3), hourly[0].Queries)
+	assert.Equal(t, int64(12), hourly[1].Queries)
+
+	daily := u.Daily()
+	require.Len(t, daily, 2)
+	assert.Equal(t, int64(1699920000), daily[0].Timestamp)
+	assert.Equal(t, int64(3), daily[0].Queries)
+	assert.Equal(t, int64(12), daily[1].Queries)
+}
@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/pulsar"
+)
+
+// PulsarDataService handles 'pulsar/query' endpoints.
+type PulsarDataService service
+
+// ErrPulsarDataRange is returned when a Pulsar data query has an empty or
+// inverted time range.
+var ErrPulsarDataRange = errors.New("pulsar data query needs a start before its end")
+
+// ErrPulsarDataQuery is returned when a Pulsar data query is nil.
+var ErrPulsarDataQuery = errors.New("pulsar data query must not be nil")
+
+// Performance returns Pulsar performance (latency) time series matching q.
+func (s *PulsarDataService) Performance(q *pulsar.DataQuery) (*pulsar.DataResult, *http.Response, error) {
+	return s.query("performance", q)
+}
+
+// Availability returns Pulsar availability time series matching q.
+func (s *PulsarDataService) Availability(q *pulsar.DataQuery) (*pulsar.DataResult, *http.Response, error) {
+	return s.query("availability", q)
+}
+
+// Decisions returns time series counting how often Pulsar steered traffic
+// to each answer. Group by pulsar.DimensionDecision to split the counts per
+// answer.
+func (s *PulsarDataService) Decisions(q *pulsar.DataQuery) (*pulsar.DataResult, *http.Response, error) {
+	return s.query("decisions", q)
+}
+
+func (s *PulsarDataService) query(metric string, q *pulsar.DataQuery) (*pulsar.DataResult, *http.Response, error) {
+	if q == nil {
+		return nil, nil, ErrPulsarDataQuery
+	}
+	if !q.Start.IsZero() && !q.End.IsZero() && !q.Start.Before(q.End) {
+		return nil, nil, ErrPulsarDataRange
+	}
+
+	path := fmt.Sprintf("pulsar/query/%s/time-series", metric)
+	if v := pulsarDataValues(q); len(v) > 0 {
+		path = fmt.Sprintf("%s?%s", path, v.Encode())
+	}
+
+	req, err := s.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var r pulsar.DataResult
+	resp, err := s.client.Do(req, &r)
+	if err != nil {
+		switch errorType := err.(type) {
+		case *Error:
+			if errorType.Message == "pulsar app not found" {
+				return nil, resp, ErrAppMissing
+			}
+		}
+		return nil, resp, err
+	}
+
+	return &r, resp, nil
+}
+
+// pulsarDataValues converts a query into url query parameters.
+func pulsarDataValues(q *pulsar.DataQuery) url.Values {
+	v := url.Values{}
+
+	if !q.Start.IsZero() {
+		SetTimeParam("start", q.Start)(&v)
+	}
+	if !q.End.IsZero() {
+		SetTimeParam("end", q.End)(&v)
+	}
+	if q.Period > 0 {
+		SetIntParam("period", int(q.Period.Seconds()))(&v)
+	}
+	if q.Aggregation != "" {
+		SetStringParam("agg", string(q.Aggregation))(&v)
+	}
+	if len(q.GroupBy) > 0 {
+		dims := make([]string, len(q.GroupBy))
+		for i, d := range q.GroupBy {
+			dims[i] = string(d)
+		}
+		SetStringParam("group_by", strings.Join(dims, ","))(&v)
+	}
+	if q.AppID != "" {
+		SetStringParam("appid", q.AppID)(&v)
+	}
+	if len(q.JobIDs) > 0 {
+		SetStringParam("jobs", strings.Join(q.JobIDs, ","))(&v)
+	}
+	if q.Geo != "" {
+		SetStringParam("geo", q.Geo)(&v)
+	}
+	if q.ASN != "" {
+		// Accept both "AS13335" and "13335".
+		SetStringParam("asn", strings.TrimPrefix(strings.ToUpper(q.ASN), "AS"))(&v)
+	}
+	if q.Record != "" {
+		SetStringParam("record", q.Record)(&v)
+	}
+
+	return v
+}
@@ -0,0 +1,118 @@
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/mockns1"
+
+	api "gopkg.in/ns1/ns1-go.v2/rest"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/pulsar"
+)
+
+func TestPulsarData(t *testing.T) {
+	mock, doer, err := mockns1.New(t)
+	require.Nil(t, err)
+	defer mock.Shutdown()
+
+	client := api.NewClient(doer, api.SetEndpoint("https://"+mock.Address+"/v1/"))
+
+	start := time.Unix(1700000000, 0)
+	q := &pulsar.DataQuery{
+		Start:       start,
+		End:         start.Add(24 * time.Hour),
+		Period:      time.Hour,
+		Aggregation: pulsar.AggregationP90,
+		GroupBy:     []pulsar.Dimension{pulsar.DimensionJob, pulsar.DimensionGeo},
+		AppID:       "app-1",
+		JobIDs:      []string{"job-1", "job-2"},
+		ASN:         "AS13335",
+	}
+	query := "agg=p90&appid=app-1&asn=13335&end=1700086400&group_by=job%2Cgeo&jobs=job-1%2Cjob-2&period=3600&start=1700000000"
+
+	result := &pulsar.DataResult{
+		Metric:      "performance",
+		Aggregation: pulsar.AggregationP90,
+		Start:       1700000000,
+		End:         1700086400,
+		Series: []*pulsar.TimeSeries{
+			{JobID: "job-1", Geo: "US", Points: []*pulsar.DataPoint{
+				{Timestamp: 1700000000, Value: 40, Count: 10},
+				{Timestamp: 1700003600, Value: 60, Count: 30},
+			}},
+			{JobID: "job-2", Geo: "US", Points: []*pulsar.DataPoint{}},
+		},
+	}
+
+	t.Run("Performance", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddPulsarDataTestCase("performance", query, nil, nil, result))
+
+		r, _, err := client.PulsarData.Performance(q)
+		require.Nil(t, err)
+		require.Len(t, r.Series, 2)
+		assert.Equal(t, "job-1", r.Series[0].JobID)
+		assert.Equal(t, 55.0, r.Series[0].Mean())
+		assert.Equal(t, start, r.Series[0].Points[0].Time())
+		assert.Contains(t, r.ByJob(), "job-2")
+	})
+
+	t.Run("Availability", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddPulsarDataTestCase("availability", "geo=EU", nil, nil, result))
+
+		r, _, err := client.PulsarData.Availability(&pulsar.DataQuery{Geo: "EU"})
+		require.Nil(t, err)
+		assert.Len(t, r.Series, 2)
+	})
+
+	t.Run("Decisions", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddPulsarDataTestCase("decisions", "", nil, nil, result))
+
+		r, _, err := client.PulsarData.Decisions(&pulsar.DataQuery{})
+		require.Nil(t, err)
+		assert.Len(t, r.Series, 2)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		t.Run("Range", func(t *testing.T) {
+			_, resp, err := client.PulsarData.Performance(&pulsar.DataQuery{Start: start, End: start})
+			require.Nil(t, resp)
+			require.Equal(t, api.ErrPulsarDataRange, err)
+		})
+
+		t.Run("Nil query", func(t *testing.T) {
+			_, resp, err := client.PulsarData.Availability(nil)
+			require.Nil(t, resp)
+			require.Equal(t, api.ErrPulsarDataQuery, err)
+		})
+
+		t.Run("App not found", func(t *testing.T) {
+			defer mock.ClearTestCases()
+
+			require.Nil(t, mock.AddTestCase(
+				http.MethodGet, "/pulsar/query/performance/time-series?appid=missing", http.StatusNotFound,
+				nil, nil, "", `{"message": "pulsar app not found"}`,
+			))
+
+			_, resp, err := client.PulsarData.Performance(&pulsar.DataQuery{AppID: "missing"})
+			require.Equal(t, api.ErrAppMissing, err)
+			require.Equal(t, http.StatusNotFound, resp.StatusCode)
+		})
+
+		t.Run("Other", func(t *testing.T) {
+			c := api.NewClient(errorClient{}, api.SetEndpoint(""))
+			r, resp, err := c.PulsarData.Performance(q)
+			require.Nil(t, resp)
+			require.Error(t, err)
+			require.Nil(t, r)
+		})
+	})
+}
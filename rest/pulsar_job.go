@@ -156,6 +156,30 @@ func (s *PulsarJobsService) Delete(pulsarJob *pulsar.Job) (*http.Response, error
 	return resp, nil
 }
 
+// Apply takes a desired *JobSpec, compares it with the existing job and
+// only updates the job if they differ. Only the fields desired specifies are
+// changed, the others keep their current values; see Job.Diff and Job.Merge.
+// Use Job.Spec to apply a whole job. It returns the names of the fields that
+// differed.
+func (s *PulsarJobsService) Apply(desired *pulsar.JobSpec) ([]string, *http.Response, error) {
+	current, resp, err := s.Get(desired.AppID, desired.JobID)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	diff := current.Diff(desired)
+	if len(diff) == 0 {
+		return diff, resp, nil
+	}
+
+	resp, err = s.Update(current.Merge(desired))
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return diff, resp, nil
+}
+
 var (
 	// ErrAppMissing bundles GET/PUT/POST/DELETE
 	ErrAppMissing = errors.New("pulsar application does not exist")
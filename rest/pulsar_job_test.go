@@ -320,6 +320,84 @@ func TestPulsarJob(t *testing.T) {
 		})
 	})
 
+	t.Run("Apply", func(t *testing.T) {
+		current := &pulsar.Job{
+			Name:   "myPulsarJob",
+			TypeID: "custom",
+			AppID:  myAppID,
+			JobID:  myJobID,
+		}
+
+		t.Run("Unchanged", func(t *testing.T) {
+			defer mock.ClearTestCases()
+
+			require.Nil(t, mock.AddPulsarJobGetTestCase(myAppID, myJobID, nil, nil, current))
+
+			diff, _, err := client.PulsarJobs.Apply(current.Spec())
+			require.Nil(t, err)
+			require.Empty(t, diff)
+		})
+
+		t.Run("Changed", func(t *testing.T) {
+			defer mock.ClearTestCases()
+
+			desired := *current
+			desired.Active = true
+
+			require.Nil(t, mock.AddPulsarJobGetTestCase(myAppID, myJobID, nil, nil, current))
+			require.Nil(t, mock.AddPulsarJobUpdateTestCase(nil, nil, &desired, &desired))
+
+			diff, _, err := client.PulsarJobs.Apply(desired.Spec())
+			require.Nil(t, err)
+			require.Equal(t, []string{"active"}, diff)
+		})
+
+		t.Run("Built job keeps untouched fields", func(t *testing.T) {
+			defer mock.ClearTestCases()
+
+			host, path, https := "example.com", "/img.jpg", true
+			live := &pulsar.Job{
+				Name:   "myPulsarJob",
+				TypeID: pulsar.TypeIDLatency,
+				AppID:  myAppID,
+				JobID:  myJobID,
+				Active: true,
+				Shared: true,
+				Config: &pulsar.JobConfig{Host: &host, URLPath: &path, HTTPS: &https},
+			}
+			desired, err := pulsar.NewJSJobBuilder("myPulsarJob", myAppID).
+				JobID(myJobID).
+				Host("www.example.com").
+				URLPath(path).
+				Spec()
+			require.Nil(t, err)
+
+			newHost := "www.example.com"
+			expected := *live
+			expected.Config = &pulsar.JobConfig{Host: &newHost, URLPath: &path, HTTPS: &https}
+
+			require.Nil(t, mock.AddPulsarJobGetTestCase(myAppID, myJobID, nil, nil, live))
+			require.Nil(t, mock.AddPulsarJobUpdateTestCase(nil, nil, &expected, &expected))
+
+			diff, _, err := client.PulsarJobs.Apply(desired)
+			require.Nil(t, err)
+			require.Equal(t, []string{"config.host"}, diff)
+		})
+
+		t.Run("Job not found", func(t *testing.T) {
+			defer mock.ClearTestCases()
+
+			require.Nil(t, mock.AddTestCase(
+				http.MethodGet, fmt.Sprintf("/pulsar/apps/%s/jobs/%s", myAppID, myJobID), http.StatusNotFound,
+				nil, nil, "", fmt.Sprintf(`{"message": "pulsar job %s not found for appid %s"}`, myJobID, myAppID),
+			))
+
+			diff, _, err := client.PulsarJobs.Apply(current.Spec())
+			require.Nil(t, diff)
+			require.Equal(t, api.ErrJobMissing, err)
+		})
+	})
+
 	t.Run("Delete", func(t *testing.T) {
 		pulsarJob := &pulsar.Job{
 			Name:   "myPulsarJob",
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"net/http"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dhcp"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/ipam"
+)
+
+// Check loads all scopes and reservations, and the IPAM addresses they
+// are attached to, and checks them with dhcp.CheckReservations. The
+// subnet served by a scope is the parent of its address in IPAM.
+func (s *ReservationService) Check() ([]*dhcp.Problem, *http.Response, error) {
+	scopes, resp, err := (*ScopeService)(s).List()
+	if err != nil {
+		return nil, resp, err
+	}
+	reservations, resp, err := s.List()
+	if err != nil {
+		return nil, resp, err
+	}
+	tree, resp, err := (*IPAMService)(s).GetTree()
+	if err != nil {
+		return nil, resp, err
+	}
+
+	placements := map[int]dhcp.Placement{}
+	tree.DepthFirst(func(n *ipam.Node) bool {
+		p, err := n.Address.ParsePrefix()
+		if err != nil {
+			return true
+		}
+		placement := dhcp.Placement{Network: n.Address.Network, Prefix: p}
+		if n.Parent != nil {
+			placement.Subnet, _ = n.Parent.Address.ParsePrefix()
+		}
+		placements[n.Address.ID] = placement
+		return true
+	})
+
+	return dhcp.CheckReservations(scopes, reservations, placements), resp, nil
+}
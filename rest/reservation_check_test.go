@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dhcp"
+)
+
+func TestReservationService_Check(t *testing.T) {
+	id := func(i int) *int { return &i }
+	srv := &dhcpServer{ipamServer: newIPAMServer()}
+	srv.add("10.20.1.128/25")
+	srv.add("10.20.1.130/32")
+	srv.add("10.20.2.1/32")
+	srv.scopes = []dhcp.Scope{{ID: 1, IDAddress: id(5), IDScopeGroup: id(1)}}
+	srv.reservations = []dhcp.Reservation{
+		{ID: id(1), IDAddress: id(4), IDScopeGroup: id(1), Mac: "00:1a:2b:3c:4d:5e", Options: dhcp.OptionSet{}},
+		{ID: id(2), IDAddress: id(6), IDScopeGroup: id(1), Mac: "00-1A-2B-3C-4D-5E", Options: dhcp.OptionSet{}},
+		{ID: id(3), IDAddress: id(7), IDScopeGroup: id(1), Options: dhcp.OptionSet{}},
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+	c.FollowPagination = false
+
+	problems, _, err := c.Reservation.Check()
+	require.NoError(t, err)
+	got := []string{}
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	assert.Equal(t, []string{
+		"duplicate-mac 00:1a:2b:3c:4d:5e: reservation 1, reservation 2",
+		"in-dynamic-pool 10.20.1.130: scope 1, reservation 2",
+		"outside-scopes 10.20.2.1: reservation 3",
+	}, got)
+}
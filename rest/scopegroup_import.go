@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dhcp"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/ipam"
+)
+
+// DHCPImportResult describes the objects created by
+// ScopeGroupService.Import, in creation order.
+type DHCPImportResult struct {
+	ScopeGroup   *dhcp.ScopeGroup
+	Addresses    []*ipam.Address
+	Scopes       []*dhcp.Scope
+	Reservations []*dhcp.Reservation
+}
+
+// Import creates the scope group, scopes and reservations of a migration,
+// e.g. as returned by dhcp.ParseDhcpd or dhcp.ParseKea, in the given IPAM
+// network. The subnets, pools and reserved addresses are created in IPAM
+// unless they already exist.
+//
+// The migration is not modified. On failure, the result tells what was
+// created before the failing object.
+func (s *ScopeGroupService) Import(m *dhcp.Migration, network int) (*DHCPImportResult, *http.Response, error) {
+	result := &DHCPImportResult{
+		Addresses:    []*ipam.Address{},
+		Scopes:       []*dhcp.Scope{},
+		Reservations: []*dhcp.Reservation{},
+	}
+
+	ipamService := (*IPAMService)(s)
+	space, resp, err := ipamService.GetSpace()
+	if err != nil {
+		return result, resp, err
+	}
+
+	created := map[string]int{}
+	address := func(prefix, name string) (int, error) {
+		a := &ipam.Address{Prefix: prefix, Name: name, Network: network}
+		p, err := a.ParsePrefix()
+		if err != nil {
+			return 0, err
+		}
+		if b := space.Lookup(network, p); b != nil && b.Prefix == p {
+			return b.Address.ID, nil
+		}
+		if id, ok := created[p.String()]; ok {
+			return id, nil
+		}
+
+		a.Prefix = p.String()
+		var r *http.Response
+		a, r, err = ipamService.CreateSubnet(a)
+		resp = r
+		if err != nil {
+			return 0, fmt.Errorf("creating address %s in network %d: %w", p, network, err)
+		}
+		created[p.String()] = a.ID
+		result.Addresses = append(result.Addresses, a)
+		return a.ID, nil
+	}
+
+	for _, prefix := range m.Subnets {
+		if _, err := address(prefix, ""); err != nil {
+			return result, resp, err
+		}
+	}
+
+	sg := *m.ScopeGroup
+	result.ScopeGroup, resp, err = s.Create(&sg)
+	if err != nil {
+		return result, resp, fmt.Errorf("creating scope group %s: %w", sg.Name, err)
+	}
+	sgID := result.ScopeGroup.ID
+
+	for _, sc := range m.Scopes {
+		scope := *sc
+		id, err := address(sc.AddressDetails.Prefix, sc.AddressDetails.Name)
+		if err != nil {
+			return result, resp, err
+		}
+		scope.IDAddress, scope.IDScopeGroup, scope.AddressDetails = &id, sgID, nil
+
+		var created *dhcp.Scope
+		created, resp, err = (*ScopeService)(s).Create(&scope)
+		if err != nil {
+			return result, resp, fmt.Errorf("creating scope %s: %w", sc.AddressDetails.Prefix, err)
+		}
+		result.Scopes = append(result.Scopes, created)
+	}
+
+	for _, res := range m.Reservations {
+		reservation := *res
+		id, err := address(res.AddressDetails.Prefix, res.AddressDetails.Name)
+		if err != nil {
+			return result, resp, err
+		}
+		reservation.IDAddress, reservation.IDScopeGroup, reservation.AddressDetails = &id, sgID, nil
+		if reservation.Options == nil {
+			reservation.Options = dhcp.OptionSet{}
+		}
+
+		var created *dhcp.Reservation
+		created, resp, err = (*ReservationService)(s).Create(&reservation)
+		if err != nil {
+			return result, resp, fmt.Errorf("creating reservation %s: %w", res.AddressDetails.Prefix, err)
+		}
+		result.Reservations = append(result.Reservations, created)
+	}
+
+	return result, resp, nil
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dhcp"
+)
+
+// dhcpServer records the DHCP objects created, lists the scopes and
+// reservations it is given, and serves IPAM requests from an ipamServer.
+type dhcpServer struct {
+	*ipamServer
+
+	created      []map[string]interface{}
+	scopes       []dhcp.Scope
+	reservations []dhcp.Reservation
+	// failPath makes creations on that path fail.
+	failPath string
+}
+
+func (s *dhcpServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case !strings.HasPrefix(req.URL.Path, "/dhcp/"):
+		s.ipamServer.ServeHTTP(w, req)
+		return
+	case req.Method == "GET" && req.URL.Path == "/dhcp/scope":
+		json.NewEncoder(w).Encode(s.scopes) // nolint: errcheck
+		return
+	case req.Method == "GET" && req.URL.Path == "/dhcp/reservation":
+		json.NewEncoder(w).Encode(s.reservations) // nolint: errcheck
+		return
+	}
+	if req.URL.Path == s.failPath {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"message": "invalid"}`)) // nolint: errcheck
+		return
+	}
+
+	var obj map[string]interface{}
+	json.NewDecoder(req.Body).Decode(&obj) // nolint: errcheck
+	obj["path"] = req.URL.Path
+	s.created = append(s.created, obj)
+	obj["id"] = len(s.created)
+	json.NewEncoder(w).Encode(obj) // nolint: errcheck
+}
+
+func TestScopeGroupService_Import(t *testing.T) {
+	m, err := dhcp.ParseKea(strings.NewReader(`{"Dhcp4": {
+		"option-data": [{"name": "routers", "data": "10.20.1.1"}],
+		"subnet4": [{
+			"subnet": "10.20.1.0/24",
+			"pools": [{"pool": "10.20.1.128/25"}],
+			"reservations": [
+				{"hw-address": "00:1a:2b:3c:4d:5e", "ip-address": "10.20.1.1"},
+				{"hw-address": "00:1a:2b:3c:4d:5f", "ip-address": "10.20.1.2"}
+			]
+		}, {
+			"subnet": "10.30.0.0/24"
+		}]
+	}}`), "office", nil)
+	require.NoError(t, err)
+
+	t.Run("creates", func(t *testing.T) {
+		srv := &dhcpServer{ipamServer: newIPAMServer()}
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		result, _, err := c.ScopeGroup.Import(m, 1)
+		require.NoError(t, err)
+
+		prefixes := []string{}
+		for _, a := range result.Addresses {
+			prefixes = append(prefixes, a.Prefix)
+		}
+		assert.Equal(t, []string{"10.30.0.0/24", "10.20.1.128/25", "10.20.1.2/32"}, prefixes)
+
+		require.Len(t, srv.created, 4)
+		assert.Equal(t, "/dhcp/scopegroup", srv.created[0]["path"])
+		assert.Equal(t, "office", srv.created[0]["name"])
+		assert.Equal(t, "/dhcp/scope", srv.created[1]["path"])
+		assert.Equal(t, float64(6), srv.created[1]["address_id"])
+		assert.Equal(t, float64(1), srv.created[1]["scope_group_id"])
+		assert.Nil(t, srv.created[1]["address_details"])
+		assert.Equal(t, "/dhcp/reservation", srv.created[2]["path"])
+		assert.Equal(t, float64(4), srv.created[2]["address_id"])
+		assert.Equal(t, float64(7), srv.created[3]["address_id"])
+
+		require.NotNil(t, result.ScopeGroup)
+		assert.Len(t, result.Scopes, 1)
+		assert.Len(t, result.Reservations, 2)
+		assert.Equal(t, "10.20.1.1", m.Reservations[0].AddressDetails.Prefix)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		srv := &dhcpServer{ipamServer: newIPAMServer(), failPath: "/dhcp/reservation"}
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		result, _, err := c.ScopeGroup.Import(m, 1)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "creating reservation 10.20.1.1")
+		assert.Len(t, result.Scopes, 1)
+		assert.Empty(t, result.Reservations)
+	})
+}
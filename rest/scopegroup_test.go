@@ -103,6 +103,49 @@ func TestDHCPScopeGroup(t *testing.T) {
 		}
 	})
 
+	t.Run("CreateValidated", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		space, items := "vendor", string(dhcp.ItemTypeUint8)
+		defs := []dhcp.OptionDef{{Space: &space, FriendlyName: "modes", Code: 1,
+			Schema: dhcp.OptionDefSchema{Type: dhcp.SchemaTypeArray, Items: &items}}}
+		err := mock.AddTestCase(http.MethodGet, "/dhcp/optiondef", http.StatusOK, nil, nil, "", defs)
+		if err != nil {
+			t.Fatalf("error adding test case: %v", err)
+		}
+
+		sg := &dhcp.ScopeGroup{Name: "a"}
+		sg.DHCP6.Options = dhcp.OptionSet{{Name: "dhcpv6/dns-servers", Value: []string{"2001:DB8::1"}}}
+		sg.DHCP4.Options = dhcp.OptionSet{
+			{Name: "vendor/modes", Value: []int{1, 300}},
+			{Name: "dhcpv4/unknown", Value: "x"},
+		}
+		_, _, err = client.ScopeGroup.CreateValidated(sg, nil)
+		errs, ok := err.(dhcp.OptionErrors)
+		if !ok || len(errs) != 2 {
+			t.Fatalf("expected 2 option errors, got %v", err)
+		}
+		if errs[0].Name != "vendor/modes" || errs[0].Item != "item 1" {
+			t.Errorf("wrong option error: %v", errs[0])
+		}
+
+		sg.DHCP4.Options = dhcp.OptionSet{{Name: "vendor/modes", Value: []int{1, 3}}}
+		want := &dhcp.ScopeGroup{Name: "a"}
+		want.DHCP4.Options = dhcp.OptionSet{{Name: "vendor/modes", Value: []interface{}{1, 3}}}
+		want.DHCP6.Options = dhcp.OptionSet{{Name: "dhcpv6/dns-servers", Value: []interface{}{"2001:db8::1"}}}
+		err = mock.AddTestCase(http.MethodPut, "/dhcp/scopegroup", http.StatusCreated, nil, nil, want, want)
+		if err != nil {
+			t.Fatalf("error adding test case: %v", err)
+		}
+		respSG, _, err := client.ScopeGroup.CreateValidated(sg, dhcp.NewOptionCatalog(defs...))
+		if err != nil {
+			t.Fatalf("error creating scope group: %v", err)
+		}
+		if respSG.Name != sg.Name {
+			t.Errorf("wrong scope group returned: want=%+v, got=%+v", want, respSG)
+		}
+	})
+
 	t.Run("Edit", func(t *testing.T) {
 		t.Run("RequiredParams", func(t *testing.T) {
 			sg := &dhcp.ScopeGroup{Name: "a"}
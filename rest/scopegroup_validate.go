@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"net/http"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dhcp"
+)
+
+// Catalog returns a catalog of the standard option definitions and the
+// custom ones of the account, to validate options with.
+func (s *OptionDefService) Catalog() (*dhcp.OptionCatalog, *http.Response, error) {
+	defs, resp, err := s.List()
+	if err != nil {
+		return nil, resp, err
+	}
+	return dhcp.NewOptionCatalog(defs...), resp, nil
+}
+
+// CreateValidated validates the options of a scope group against their
+// definitions before creating it with normalized values. The catalog may
+// be nil to fetch it with OptionDefService.Catalog.
+//
+// If any option is invalid, nothing is created and the error is a
+// dhcp.OptionErrors listing the problem of each option.
+func (s *ScopeGroupService) CreateValidated(sg *dhcp.ScopeGroup, catalog *dhcp.OptionCatalog) (*dhcp.ScopeGroup, *http.Response, error) {
+	var resp *http.Response
+	if catalog == nil {
+		var err error
+		catalog, resp, err = (*OptionDefService)(s).Catalog()
+		if err != nil {
+			return nil, resp, err
+		}
+	}
+
+	normalized, errs := catalog.ValidateScopeGroup(sg)
+	if len(errs) > 0 {
+		return nil, resp, errs
+	}
+	return s.Create(normalized)
+}
@@ -1,26 +1,155 @@
 package rest
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strings"
 
 	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
 )
 
+// Domain match types for SearchQuery.Domain.
+const (
+	SearchMatchExact    = "exact"
+	SearchMatchPrefix   = "prefix"
+	SearchMatchSuffix   = "suffix"
+	SearchMatchContains = "contains"
+)
+
+// ErrSearchCursorRepeated is returned by a SearchIterator when the API hands
+// back a cursor that was already followed, which would otherwise loop
+// forever.
+var ErrSearchCursorRepeated = errors.New("search cursor repeated")
+
+// SearchQuery builds the query parameters of a record or zone search, as an
+// alternative to hand written query strings.
+type SearchQuery struct {
+	v url.Values
+}
+
+// NewSearchQuery returns an empty search query.
+func NewSearchQuery() *SearchQuery {
+	return &SearchQuery{v: url.Values{}}
+}
+
+// Domain matches names against a pattern. A leading and/or trailing '*'
+// selects a suffix, prefix or contains match, e.g. "*.example.com" matches
+// every name ending in ".example.com". Patterns without '*' match exactly.
+func (q *SearchQuery) Domain(pattern string) *SearchQuery {
+	match := SearchMatchExact
+	leading, trailing := strings.HasPrefix(pattern, "*"), strings.HasSuffix(pattern, "*")
+	switch {
+	case leading && trailing && len(pattern) > 1:
+		match = SearchMatchContains
+	case leading:
+		match = SearchMatchSuffix
+	case trailing:
+		match = SearchMatchPrefix
+	}
+
+	q.v.Set("domain", strings.Trim(pattern, "*"))
+	q.v.Set("domainType", match)
+	return q
+}
+
+// Type limits the results to records of the given type, e.g. "A".
+func (q *SearchQuery) Type(t string) *SearchQuery {
+	q.v.Set("type", strings.ToUpper(t))
+	return q
+}
+
+// Zone limits the results to the given zone.
+func (q *SearchQuery) Zone(zone string) *SearchQuery {
+	q.v.Set("zone", zone)
+	return q
+}
+
+// Tag limits the results to those tagged with key and value. It can be
+// called multiple times to require several tags.
+func (q *SearchQuery) Tag(key, value string) *SearchQuery {
+	q.v.Add("tag", fmt.Sprintf("%s:%s", key, value))
+	return q
+}
+
+// Answer limits the results to records with an answer containing the given
+// content, e.g. an IP address.
+func (q *SearchQuery) Answer(content string) *SearchQuery {
+	q.v.Set("answer", content)
+	return q
+}
+
+// Limit sets the maximum number of results per page.
+func (q *SearchQuery) Limit(n int) *SearchQuery {
+	SetIntParam("limit", n)(&q.v)
+	return q
+}
+
+// OrderBy sorts the results by the given field, e.g. "domain".
+func (q *SearchQuery) OrderBy(field string, descending bool) *SearchQuery {
+	q.v.Set("orderBy", field)
+	SetBoolParam("orderDescending", descending)(&q.v)
+	return q
+}
+
+// Encode returns the query in url encoded form, as expected by Search.
+func (q *SearchQuery) Encode() string {
+	return q.v.Encode()
+}
+
+// values returns a copy of the query parameters.
+func (q *SearchQuery) values() url.Values {
+	v := url.Values{}
+	for key, vals := range q.v {
+		v[key] = append([]string{}, vals...)
+	}
+	return v
+}
+
 // RecordSearchService handles 'dns/record/search' endpoint.
 type RecordSearchService service
 
-// Find takes query parameters and returns matching DNS records.
+// Search takes query parameters and returns matching DNS records.
 func (s *RecordSearchService) Search(params string) (*dns.SearchResult, *http.Response, error) {
-	path := fmt.Sprintf("dns/record/search?%s", params)
+	return search(s.client, "dns/record/search", params)
+}
+
+// Iterate returns a SearchIterator over every record matching q, following
+// the Next cursor of each page.
+func (s *RecordSearchService) Iterate(q *SearchQuery) *SearchIterator {
+	return newSearchIterator(s.Search, q)
+}
+
+// SearchAll returns every record matching q, across all pages.
+func (s *RecordSearchService) SearchAll(q *SearchQuery) ([]*dns.Record, *http.Response, error) {
+	return s.Iterate(q).All()
+}
 
-	req, err := s.client.NewRequest("GET", path, nil)
+// ZoneSearchService handles 'dns/zone/search' endpoint.
+type ZoneSearchService service
+
+// Search takes query parameters and returns matching DNS zones.
+func (s *ZoneSearchService) Search(params string) (*dns.SearchResult, *http.Response, error) {
+	return search(s.client, "dns/zone/search", params)
+}
+
+// Iterate returns a SearchIterator over every zone matching q, following
+// the Next cursor of each page.
+func (s *ZoneSearchService) Iterate(q *SearchQuery) *SearchIterator {
+	return newSearchIterator(s.Search, q)
+}
+
+func search(c *Client, endpoint, params string) (*dns.SearchResult, *http.Response, error) {
+	path := fmt.Sprintf("%s?%s", endpoint, params)
+
+	req, err := c.NewRequest("GET", path, nil)
 	if err != nil {
 		return nil, nil, err
 	}
 
 	var r dns.SearchResult
-	resp, err := s.client.Do(req, &r)
+	resp, err := c.Do(req, &r)
 	if err != nil {
 		return nil, resp, err
 	}
@@ -28,23 +157,108 @@ func (s *RecordSearchService) Search(params string) (*dns.SearchResult, *http.Re
 	return &r, resp, nil
 }
 
-// ZoneSearchService handles 'dns/zone/search' endpoint.
-type ZoneSearchService service
+// SearchIterator pages through search results:
+//
+//	it := client.RecordSearch.Iterate(rest.NewSearchQuery().Answer("10.1.2.3"))
+//	for it.Next() {
+//		for _, r := range it.Results() {
+//			...
+//		}
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type SearchIterator struct {
+	search func(params string) (*dns.SearchResult, *http.Response, error)
+	params url.Values
 
-// Find takes query parameters and returns matching DNS zones.
-func (s *ZoneSearchService) Search(params string) (*dns.SearchResult, *http.Response, error) {
-	path := fmt.Sprintf("dns/zone/search?%s", params)
+	// seen holds the encoded params of every request made.
+	seen map[string]bool
 
-	req, err := s.client.NewRequest("GET", path, nil)
-	if err != nil {
-		return nil, nil, err
+	page *dns.SearchResult
+	resp *http.Response
+	err  error
+	done bool
+}
+
+func newSearchIterator(search func(string) (*dns.SearchResult, *http.Response, error), q *SearchQuery) *SearchIterator {
+	return &SearchIterator{search: search, params: q.values(), seen: map[string]bool{}}
+}
+
+// Next fetches the next page of results, and reports whether there was one.
+func (it *SearchIterator) Next() bool {
+	if it.done {
+		return false
 	}
 
-	var r dns.SearchResult
-	resp, err := s.client.Do(req, &r)
+	params := it.params.Encode()
+	if it.seen[params] {
+		it.err = fmt.Errorf("%w: %s", ErrSearchCursorRepeated, it.params.Get("next"))
+		it.done = true
+		return false
+	}
+	it.seen[params] = true
+
+	page, resp, err := it.search(params)
+	it.resp = resp
 	if err != nil {
-		return nil, resp, err
+		it.err = err
+		it.done = true
+		return false
 	}
+	it.page = page
 
-	return &r, resp, nil
+	if page.Next == "" {
+		it.done = true
+	} else {
+		it.setCursor(page.Next)
+	}
+	return true
+}
+
+// setCursor points the next request at the given cursor, which may be a
+// bare token or a uri carrying the full query.
+func (it *SearchIterator) setCursor(next string) {
+	if i := strings.Index(next, "?"); i >= 0 {
+		if v, err := url.ParseQuery(next[i+1:]); err == nil {
+			it.params = v
+			return
+		}
+	}
+	it.params.Set("next", next)
+}
+
+// Results returns the results of the current page.
+func (it *SearchIterator) Results() []*dns.Record {
+	if it.page == nil {
+		return nil
+	}
+	return it.page.Results
+}
+
+// Page returns the current page.
+func (it *SearchIterator) Page() *dns.SearchResult {
+	return it.page
+}
+
+// Response returns the http response of the last request.
+func (it *SearchIterator) Response() *http.Response {
+	return it.resp
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *SearchIterator) Err() error {
+	return it.err
+}
+
+// All drains the iterator and returns the results of every page.
+func (it *SearchIterator) All() ([]*dns.Record, *http.Response, error) {
+	results := []*dns.Record{}
+	for it.Next() {
+		results = append(results, it.Results()...)
+	}
+	if it.err != nil {
+		return nil, it.resp, it.err
+	}
+	return results, it.resp, nil
 }
@@ -0,0 +1,126 @@
+package rest_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/mockns1"
+
+	api "gopkg.in/ns1/ns1-go.v2/rest"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+)
+
+func TestSearchQuery(t *testing.T) {
+	cases := map[string]string{
+		"www.example.com": "domain=www.example.com&domainType=exact",
+		"*.example.com":   "domain=.example.com&domainType=suffix",
+		"www*":            "domain=www&domainType=prefix",
+		"*cdn*":           "domain=cdn&domainType=contains",
+	}
+	for pattern, expected := range cases {
+		assert.Equal(t, expected, api.NewSearchQuery().Domain(pattern).Encode(), pattern)
+	}
+
+	q := api.NewSearchQuery().
+		Type("a").
+		Zone("example.com").
+		Tag("env", "prod").
+		Tag("team", "edge").
+		Answer("10.1.2.3").
+		Limit(50).
+		OrderBy("domain", true)
+	assert.Equal(t,
+		"answer=10.1.2.3&limit=50&orderBy=domain&orderDescending=true&tag=env%3Aprod&tag=team%3Aedge&type=A&zone=example.com",
+		q.Encode(),
+	)
+}
+
+func TestRecordSearch(t *testing.T) {
+	mock, doer, err := mockns1.New(t)
+	require.Nil(t, err)
+	defer mock.Shutdown()
+
+	client := api.NewClient(doer, api.SetEndpoint("https://"+mock.Address+"/v1/"))
+	q := api.NewSearchQuery().Answer("10.1.2.3").Limit(2)
+
+	t.Run("SearchAll", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/dns/record/search?answer=10.1.2.3&limit=2", http.StatusOK,
+			nil, nil, "", &dns.SearchResult{
+				Next:         "cursor-1",
+				Limit:        2,
+				TotalResults: 3,
+				Results:      []*dns.Record{{Domain: "a.example.com"}, {Domain: "b.example.com"}},
+			},
+		))
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/dns/record/search?answer=10.1.2.3&limit=2&next=cursor-1", http.StatusOK,
+			nil, nil, "", &dns.SearchResult{
+				Next:         "/v1/dns/record/search?answer=10.1.2.3&limit=2&next=cursor-2",
+				Limit:        2,
+				TotalResults: 3,
+				Results:      []*dns.Record{{Domain: "c.example.com"}},
+			},
+		))
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/dns/record/search?answer=10.1.2.3&limit=2&next=cursor-2", http.StatusOK,
+			nil, nil, "", &dns.SearchResult{Limit: 2, TotalResults: 3, Results: []*dns.Record{}},
+		))
+
+		records, _, err := client.RecordSearch.SearchAll(q)
+		require.Nil(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, "a.example.com", records[0].Domain)
+		assert.Equal(t, "c.example.com", records[2].Domain)
+	})
+
+	t.Run("Iterate", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/dns/record/search?answer=10.1.2.3&limit=2", http.StatusOK,
+			nil, nil, "", &dns.SearchResult{Next: "cursor-1", Results: []*dns.Record{{Domain: "a.example.com"}}},
+		))
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/dns/record/search?answer=10.1.2.3&limit=2&next=cursor-1", http.StatusInternalServerError,
+			nil, nil, "", `{"message": "test error"}`,
+		))
+
+		it := client.RecordSearch.Iterate(q)
+		require.True(t, it.Next())
+		assert.Len(t, it.Results(), 1)
+		require.False(t, it.Next())
+		require.Error(t, it.Err())
+		assert.Contains(t, it.Err().Error(), "test error")
+		assert.Equal(t, http.StatusInternalServerError, it.Response().StatusCode)
+		assert.False(t, it.Next())
+
+		_, _, err := client.RecordSearch.Iterate(q).All()
+		require.Error(t, err)
+	})
+
+	t.Run("Repeated cursor", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/dns/record/search?answer=10.1.2.3&limit=2", http.StatusOK,
+			nil, nil, "", &dns.SearchResult{Next: "cursor-1", Results: []*dns.Record{{Domain: "a.example.com"}}},
+		))
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/dns/record/search?answer=10.1.2.3&limit=2&next=cursor-1", http.StatusOK,
+			nil, nil, "", &dns.SearchResult{Next: "cursor-1", Results: []*dns.Record{{Domain: "b.example.com"}}},
+		))
+
+		it := client.RecordSearch.Iterate(q)
+		require.True(t, it.Next())
+		require.True(t, it.Next())
+		require.False(t, it.Next())
+		assert.True(t, errors.Is(it.Err(), api.ErrSearchCursorRepeated))
+		assert.EqualError(t, it.Err(), "search cursor repeated: cursor-1")
+	})
+}
@@ -3,18 +3,28 @@ package rest
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/stats"
 )
 
-const statsQPSEndpoint = "stats/qps"
+const (
+	statsQPSEndpoint     = "stats/qps"
+	statsUsageEndpoint   = "stats/usage"
+	billingUsageEndpoint = "billing-usage/queries"
+)
 
-// StatsService handles 'stats/qps' endpoint.
+// StatsService handles 'stats/qps' and 'stats/usage' endpoints.
 type StatsService service
 
 // GetQPS returns current queries per second (QPS) for the account.
 // The QPS number is lagged by approximately 30 seconds for statistics collection;
 // and the rate is computed over the preceding minute.
 func (s *StatsService) GetQPS() (float32, *http.Response, error) {
-	return s.getQPS(statsQPSEndpoint)
+	return s.getQPSValue(statsQPSEndpoint)
 }
 
 // GetZoneQPS returns current queries per second (QPS) for a specific zone.
@@ -22,7 +32,7 @@ func (s *StatsService) GetQPS() (float32, *http.Response, error) {
 // and the rate is computed over the preceding minute.
 func (s *StatsService) GetZoneQPS(zone string) (float32, *http.Response, error) {
 	path := fmt.Sprintf("%s/%s", statsQPSEndpoint, zone)
-	return s.getQPS(path)
+	return s.getQPSValue(path)
 }
 
 // GetRecordQPS returns current queries per second (QPS) for a specific record.
@@ -30,37 +40,152 @@ func (s *StatsService) GetZoneQPS(zone string) (float32, *http.Response, error)
 // and the rate is computed over the preceding minute.
 func (s *StatsService) GetRecordQPS(zone, record, t string) (float32, *http.Response, error) {
 	path := fmt.Sprintf("%s/%s/%s/%s", statsQPSEndpoint, zone, record, t)
+	return s.getQPSValue(path)
+}
+
+// GetQPSByNetwork returns current queries per second (QPS) for the account,
+// along with its breakdown per network.
+func (s *StatsService) GetQPSByNetwork() (*stats.QPS, *http.Response, error) {
+	return s.getQPS(statsQPSEndpoint)
+}
+
+// GetZoneQPSByNetwork returns current queries per second (QPS) for a
+// specific zone, along with its breakdown per network.
+func (s *StatsService) GetZoneQPSByNetwork(zone string) (*stats.QPS, *http.Response, error) {
+	path := fmt.Sprintf("%s/%s", statsQPSEndpoint, zone)
 	return s.getQPS(path)
 }
 
-func (s *StatsService) getQPS(path string) (float32, *http.Response, error) {
-	req, err := s.client.NewRequest("GET", path, nil)
+func (s *StatsService) getQPSValue(path string) (float32, *http.Response, error) {
+	value, resp, err := s.getQPS(path)
 	if err != nil {
-		return 0, nil, err
+		return 0, resp, err
 	}
+	return value.QPS, resp, nil
+}
 
-	var value struct {
-		/* by default unmartial will ignore any extra fields so we don't need these
-		Networks []struct {
-			Network int
-			Qps float32
-		}
-		*/
-		QPS float32
+func (s *StatsService) getQPS(path string) (*stats.QPS, *http.Response, error) {
+	req, err := s.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, err
 	}
+
+	var value stats.QPS
 	resp, err := s.client.Do(req, &value)
 
 	if err != nil {
-		switch err.(type) {
-		case *Error:
-			switch err.(*Error).Message {
-			case "zone not found":
-				return 0, nil, ErrZoneMissing
-			case "record not found":
-				return 0, nil, ErrRecordMissing
-			}
+		return nil, resp, statsError(err)
+	}
+	return &value, resp, nil
+}
+
+// GetUsage returns the query usage of the account over time.
+//
+// NS1 API docs: https://ns1.com/api/#usage-get
+func (s *StatsService) GetUsage(q *stats.UsageQuery) ([]*stats.Usage, *http.Response, error) {
+	return s.getUsage(statsUsageEndpoint, q)
+}
+
+// GetZoneUsage returns the query usage of a specific zone over time.
+//
+// NS1 API docs: https://ns1.com/api/#usage-get
+func (s *StatsService) GetZoneUsage(zone string, q *stats.UsageQuery) ([]*stats.Usage, *http.Response, error) {
+	path := fmt.Sprintf("%s/%s", statsUsageEndpoint, zone)
+	return s.getUsage(path, q)
+}
+
+// GetRecordUsage returns the query usage of a specific record over time.
+//
+// NS1 API docs: https://ns1.com/api/#usage-get
+func (s *StatsService) GetRecordUsage(zone, record, t string, q *stats.UsageQuery) ([]*stats.Usage, *http.Response, error) {
+	path := fmt.Sprintf("%s/%s/%s/%s", statsUsageEndpoint, zone, record, t)
+	return s.getUsage(path, q)
+}
+
+func (s *StatsService) getUsage(path string, q *stats.UsageQuery) ([]*stats.Usage, *http.Response, error) {
+	if v := usageValues(q); len(v) > 0 {
+		path = fmt.Sprintf("%s?%s", path, v.Encode())
+	}
+
+	req, err := s.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ul := []*stats.Usage{}
+	resp, err := s.client.Do(req, &ul)
+	if err != nil {
+		return nil, resp, statsError(err)
+	}
+
+	return ul, resp, nil
+}
+
+// GetBillingUsage returns the query totals of the account between from
+// and to, typically the bounds of a billing period.
+func (s *StatsService) GetBillingUsage(from, to time.Time) (*stats.BillingUsage, *http.Response, error) {
+	v := url.Values{}
+	SetTimeParam("from", from)(&v)
+	SetTimeParam("to", to)(&v)
+	path := fmt.Sprintf("%s?%s", billingUsageEndpoint, v.Encode())
+
+	req, err := s.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var bu stats.BillingUsage
+	resp, err := s.client.Do(req, &bu)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return &bu, resp, nil
+}
+
+// usageValues converts a usage query into url query parameters.
+func usageValues(q *stats.UsageQuery) url.Values {
+	v := url.Values{}
+	if q == nil {
+		return v
+	}
+
+	if q.Period != "" {
+		SetStringParam("period", string(q.Period))(&v)
+	}
+	if q.Aggregate {
+		SetBoolParam("aggregate", true)(&v)
+	}
+	if q.Expand {
+		SetBoolParam("expand", true)(&v)
+	}
+	if q.ByTier {
+		SetBoolParam("by_tier", true)(&v)
+	}
+	if q.ByNetwork {
+		SetBoolParam("by_network", true)(&v)
+	}
+	if len(q.Networks) > 0 {
+		ids := make([]string, len(q.Networks))
+		for i, n := range q.Networks {
+			ids[i] = strconv.Itoa(n)
 		}
-		return 0, resp, err
+		SetStringParam("networks", strings.Join(ids, ","))(&v)
 	}
-	return value.QPS, resp, nil
+
+	return v
+}
+
+// statsError maps missing zone and record errors to their sentinel errors.
+func statsError(err error) error {
+	switch err.(type) {
+	case *Error:
+		switch err.(*Error).Message {
+		case "zone not found":
+			return ErrZoneMissing
+		case "record not found":
+			return ErrRecordMissing
+		}
+	}
+	return err
 }
@@ -0,0 +1,105 @@
+package rest_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/mockns1"
+
+	api "gopkg.in/ns1/ns1-go.v2/rest"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/stats"
+)
+
+func TestStats(t *testing.T) {
+	mock, doer, err := mockns1.New(t)
+	require.Nil(t, err)
+	defer mock.Shutdown()
+
+	client := api.NewClient(doer, api.SetEndpoint("https://"+mock.Address+"/v1/"))
+
+	t.Run("GetQPS", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/stats/qps", http.StatusOK, nil, nil, "",
+			`{"qps": 12.5, "networks": [{"network": 0, "qps": 10}, {"network": 1, "qps": 2.5}]}`,
+		))
+
+		qps, _, err := client.Stats.GetQPS()
+		require.Nil(t, err)
+		assert.Equal(t, float32(12.5), qps)
+
+		detail, _, err := client.Stats.GetQPSByNetwork()
+		require.Nil(t, err)
+		assert.Equal(t, float32(12.5), detail.QPS)
+		require.Len(t, detail.Networks, 2)
+		assert.Equal(t, 1, detail.Networks[1].Network)
+		assert.Equal(t, float32(2.5), detail.Networks[1].QPS)
+	})
+
+	t.Run("GetZoneQPS", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/stats/qps/missing.com", http.StatusNotFound, nil, nil, "",
+			`{"message": "zone not found"}`,
+		))
+
+		qps, resp, err := client.Stats.GetZoneQPS("missing.com")
+		assert.Equal(t, float32(0), qps)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, api.ErrZoneMissing, err)
+	})
+
+	t.Run("GetUsage", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/stats/usage?by_network=true&networks=0%2C1&period=24h", http.StatusOK, nil, nil, "",
+			`[{"period": "24h", "queries": 300, "graph": [[1700000000, 100], [1700001800, 50], [1700003600, 150]],
+			  "networks": [{"network": 0, "queries": 200}, {"network": 1, "queries": 100}]}]`,
+		))
+
+		ul, _, err := client.Stats.GetUsage(&stats.UsageQuery{
+			Period:    stats.Period24h,
+			ByNetwork: true,
+			Networks:  []int{0, 1},
+		})
+		require.Nil(t, err)
+		require.Len(t, ul, 1)
+		assert.Equal(t, int64(300), ul[0].Queries)
+		require.Len(t, ul[0].Graph, 3)
+		require.Len(t, ul[0].Networks, 2)
+		assert.Equal(t, 1, *ul[0].Networks[1].Network)
+	})
+
+	t.Run("GetRecordUsage", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/stats/usage/example.com/www.example.com/A", http.StatusNotFound, nil, nil, "",
+			`{"message": "record not found"}`,
+		))
+
+		_, resp, err := client.Stats.GetRecordUsage("example.com", "www.example.com", "A", nil)
+		assert.Equal(t, api.ErrRecordMissing, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("GetBillingUsage", func(t *testing.T) {
+		defer mock.ClearTestCases()
+
+		require.Nil(t, mock.AddTestCase(
+			http.MethodGet, "/billing-usage/queries?from=1698796800&to=1701388800", http.StatusOK, nil, nil, "",
+			`{"from": 1698796800, "to": 1701388800, "clean_queries": 1000, "ddos_queries": 20, "nxd_responses": 5}`,
+		))
+
+		bu, _, err := client.Stats.GetBillingUsage(time.Unix(1698796800, 0), time.Unix(1701388800, 0))
+		require.Nil(t, err)
+		assert.Equal(t, int64(1020), bu.Total())
+		assert.Equal(t, int64(5), bu.NXDResponses)
+	})
+}
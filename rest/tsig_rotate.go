@@ -0,0 +1,152 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+)
+
+// TsigRotation describes a TSIG key rotation.
+type TsigRotation struct {
+	Old *dns.TSIGKey
+	New *dns.TSIGKey
+
+	// Zones holds the secondary zones repointed from the old key to the new.
+	Zones []string
+
+	// RolledBack reports whether a failed rotation repointed the zones back
+	// to the old key and removed the new one.
+	RolledBack bool
+}
+
+// Rotate replaces the TSIG key named oldName with newKey. It creates the
+// new key, repoints the TSIG of every secondary zone using the old key to
+// it, verifies each zone, and finally deletes the old key. Zones are fetched
+// one by one, unless the zone list shows them using another key.
+//
+// An empty newKey.Algorithm reuses the old key's algorithm, and an empty
+// newKey.Secret is generated. If a zone fails to update or verify, the
+// zones updated so far are pointed back to the old key, the new key is
+// deleted, and the error is returned along with the rotation.
+func (s *TsigService) Rotate(oldName string, newKey *dns.TSIGKey) (*TsigRotation, *http.Response, error) {
+	old, resp, err := s.Get(oldName)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	if newKey.Algorithm == "" {
+		newKey.Algorithm = old.Algorithm
+	}
+	if newKey.Secret == "" {
+		if newKey.Secret, err = dns.GenerateTsigSecret(newKey.Algorithm); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := newKey.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if newKey.Name == old.Name {
+		return nil, nil, fmt.Errorf("new TSIG key must not be named %q like the old one", old.Name)
+	}
+
+	rot := &TsigRotation{Old: old, New: newKey, Zones: []string{}}
+
+	resp, err = s.Create(newKey)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	zones := (*ZonesService)(s)
+	zl, resp, err := zones.List()
+	if err != nil {
+		return rot, resp, s.rollbackRotation(rot, err)
+	}
+
+	for _, z := range zl {
+		if usesOtherTsigKey(z, old.Name) {
+			continue
+		}
+
+		// The zone list may omit secondary settings, so decide on the
+		// current settings of the zone. They are also the ones to repoint,
+		// in case they changed since listing.
+		full, resp, err := zones.Get(z.Zone, false)
+		if err != nil {
+			return rot, resp, s.rollbackRotation(rot, err)
+		}
+		if !usesTsigKey(full, old.Name) {
+			continue
+		}
+
+		resp, err = repointTsig(zones, full, newKey)
+		if err != nil {
+			return rot, resp, s.rollbackRotation(rot, fmt.Errorf("zone %s: %w", z.Zone, err))
+		}
+		rot.Zones = append(rot.Zones, z.Zone)
+
+		updated, resp, err := zones.Get(z.Zone, false)
+		if err != nil {
+			return rot, resp, s.rollbackRotation(rot, err)
+		}
+		if !usesTsigKey(updated, newKey.Name) {
+			return rot, resp, s.rollbackRotation(rot,
+				fmt.Errorf("zone %s still does not use TSIG key %s after update", z.Zone, newKey.Name))
+		}
+	}
+
+	resp, err = s.Delete(old.Name)
+	if err != nil {
+		return rot, resp, fmt.Errorf("deleting old TSIG key %s: %w", old.Name, err)
+	}
+
+	return rot, resp, nil
+}
+
+// rollbackRotation points the zones of a failed rotation back to the old
+// key and deletes the new key. It returns cause, annotated with any failure
+// to roll back.
+func (s *TsigService) rollbackRotation(rot *TsigRotation, cause error) error {
+	zones := (*ZonesService)(s)
+
+	for _, name := range rot.Zones {
+		z, _, err := zones.Get(name, false)
+		if err == nil {
+			_, err = repointTsig(zones, z, rot.Old)
+		}
+		if err != nil {
+			return fmt.Errorf("%w (and failed to restore TSIG key %s on zone %s: %v)", cause, rot.Old.Name, name, err)
+		}
+	}
+
+	if _, err := s.Delete(rot.New.Name); err != nil {
+		return fmt.Errorf("%w (and failed to delete new TSIG key %s: %v)", cause, rot.New.Name, err)
+	}
+
+	rot.RolledBack = true
+	return cause
+}
+
+func usesTsigKey(z *dns.Zone, name string) bool {
+	return z.Secondary != nil && z.Secondary.TSIG != nil && z.Secondary.TSIG.Name == name
+}
+
+// usesOtherTsigKey reports whether the zone is known to use a TSIG key other
+// than name. Zones without secondary TSIG settings are not, as the settings
+// may have been left out of a zone list.
+func usesOtherTsigKey(z *dns.Zone, name string) bool {
+	return z.Secondary != nil && z.Secondary.TSIG != nil && z.Secondary.TSIG.Name != name
+}
+
+// repointTsig updates only the secondary settings of a zone, so the rest of
+// its configuration is left untouched.
+func repointTsig(zones *ZonesService, z *dns.Zone, key *dns.TSIGKey) (*http.Response, error) {
+	sec := *z.Secondary
+	tsig := *sec.TSIG
+	tsig.Name = key.Name
+	tsig.Hash = key.Algorithm
+	tsig.Key = ""
+	sec.TSIG = &tsig
+
+	return zones.Update(&dns.Zone{Zone: z.Zone, Secondary: &sec})
+}
@@ -0,0 +1,194 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+)
+
+// tsigServer simulates TSIG keys and secondary zones.
+type tsigServer struct {
+	mu    sync.Mutex
+	keys  map[string]*dns.TSIGKey
+	zones map[string]*dns.Zone
+	order []string
+
+	// ignoreUpdates lists zones whose updates are accepted but not applied.
+	ignoreUpdates map[string]bool
+
+	// listNames leaves everything but zone names out of the zone list.
+	listNames bool
+}
+
+func (s *tsigServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notFound := func() {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "not found"}`)) // nolint: errcheck
+	}
+
+	switch {
+	case strings.HasPrefix(req.URL.Path, "/tsig/"):
+		name := strings.TrimPrefix(req.URL.Path, "/tsig/")
+		switch req.Method {
+		case "GET":
+			if k, ok := s.keys[name]; ok {
+				json.NewEncoder(w).Encode(k) // nolint: errcheck
+				return
+			}
+			notFound()
+		case "PUT":
+			var k dns.TSIGKey
+			json.NewDecoder(req.Body).Decode(&k) // nolint: errcheck
+			s.keys[name] = &k
+			json.NewEncoder(w).Encode(k) // nolint: errcheck
+		case "DELETE":
+			if _, ok := s.keys[name]; !ok {
+				notFound()
+				return
+			}
+			delete(s.keys, name)
+			w.Write([]byte(`{}`)) // nolint: errcheck
+		}
+	case req.URL.Path == "/zones":
+		zl := []*dns.Zone{}
+		for _, name := range s.order {
+			if s.listNames {
+				zl = append(zl, &dns.Zone{Zone: name})
+				continue
+			}
+			zl = append(zl, s.zones[name])
+		}
+		json.NewEncoder(w).Encode(zl) // nolint: errcheck
+	case strings.HasPrefix(req.URL.Path, "/zones/"):
+		name := strings.TrimPrefix(req.URL.Path, "/zones/")
+		z, ok := s.zones[name]
+		if !ok {
+			notFound()
+			return
+		}
+		if req.Method == "POST" {
+			var update dns.Zone
+			json.NewDecoder(req.Body).Decode(&update) // nolint: errcheck
+			if !s.ignoreUpdates[name] && update.Secondary != nil {
+				sec := *update.Secondary
+				z.Secondary = &sec
+			}
+		}
+		json.NewEncoder(w).Encode(z) // nolint: errcheck
+	default:
+		notFound()
+	}
+}
+
+func newTsigServer() *tsigServer {
+	secondary := func(key string) *dns.ZoneSecondary {
+		return &dns.ZoneSecondary{
+			Enabled:   true,
+			PrimaryIP: "192.0.2.1",
+			TSIG:      &dns.TSIG{Enabled: true, Name: key, Hash: "hmac-sha256"},
+		}
+	}
+	return &tsigServer{
+		keys: map[string]*dns.TSIGKey{
+			"old-key":   dns.NewTsigKey("old-key", "hmac-sha256", "Ok1qR5IW1ajVka5cHPEJQIXfLyx5V3PSkFBROAzOn21JumDq6nIpoj6H8rfj5Uo+Ok55ZWQ0Wgrf302fDscHLA=="),
+			"other-key": dns.NewTsigKey("other-key", "hmac-sha256", "Ok1qR5IW1ajVka5cHPEJQIXfLyx5V3PSkFBROAzOn21JumDq6nIpoj6H8rfj5Uo+Ok55ZWQ0Wgrf302fDscHLA=="),
+		},
+		zones: map[string]*dns.Zone{
+			"a.com":       {Zone: "a.com", Secondary: secondary("old-key")},
+			"b.com":       {Zone: "b.com", Secondary: secondary("old-key")},
+			"other.com":   {Zone: "other.com", Secondary: secondary("other-key")},
+			"primary.com": {Zone: "primary.com"},
+		},
+		order:         []string{"a.com", "b.com", "other.com", "primary.com"},
+		ignoreUpdates: map[string]bool{},
+	}
+}
+
+func TestTsigService_Rotate(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		srv := newTsigServer()
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		rot, _, err := c.TSIG.Rotate("old-key", &dns.TSIGKey{Name: "new-key"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.com", "b.com"}, rot.Zones)
+		assert.False(t, rot.RolledBack)
+		assert.Equal(t, "hmac-sha256", rot.New.Algorithm)
+		assert.NoError(t, rot.New.Validate())
+
+		assert.NotContains(t, srv.keys, "old-key")
+		assert.Contains(t, srv.keys, "new-key")
+		assert.Equal(t, "new-key", srv.zones["a.com"].Secondary.TSIG.Name)
+		assert.Equal(t, "192.0.2.1", srv.zones["a.com"].Secondary.PrimaryIP)
+		assert.Equal(t, "other-key", srv.zones["other.com"].Secondary.TSIG.Name)
+	})
+
+	t.Run("zone list without secondary settings", func(t *testing.T) {
+		srv := newTsigServer()
+		srv.listNames = true
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		rot, _, err := c.TSIG.Rotate("old-key", &dns.TSIGKey{Name: "new-key"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"a.com", "b.com"}, rot.Zones)
+		assert.Equal(t, "new-key", srv.zones["b.com"].Secondary.TSIG.Name)
+		assert.Equal(t, "other-key", srv.zones["other.com"].Secondary.TSIG.Name)
+	})
+
+	t.Run("verification failure rolls back", func(t *testing.T) {
+		srv := newTsigServer()
+		srv.ignoreUpdates["b.com"] = true
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		rot, _, err := c.TSIG.Rotate("old-key", &dns.TSIGKey{Name: "new-key", Algorithm: "hmac-sha512"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "b.com")
+		assert.True(t, rot.RolledBack)
+
+		assert.Contains(t, srv.keys, "old-key")
+		assert.NotContains(t, srv.keys, "new-key")
+		assert.Equal(t, "old-key", srv.zones["a.com"].Secondary.TSIG.Name)
+		assert.Equal(t, "old-key", srv.zones["b.com"].Secondary.TSIG.Name)
+	})
+
+	t.Run("invalid new key", func(t *testing.T) {
+		srv := newTsigServer()
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+		_, _, err := c.TSIG.Rotate("old-key", &dns.TSIGKey{Name: "new-key", Secret: "short"})
+		require.Error(t, err)
+		_, _, err = c.TSIG.Rotate("old-key", &dns.TSIGKey{Name: "old-key"})
+		require.Error(t, err)
+		assert.NotContains(t, srv.keys, "new-key")
+	})
+
+	t.Run("missing old key", func(t *testing.T) {
+		ts := httptest.NewServer(newTsigServer())
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+		_, _, err := c.TSIG.Rotate("missing-key", &dns.TSIGKey{Name: "new-key"})
+		assert.Equal(t, ErrTsigKeyMissing, err)
+	})
+}
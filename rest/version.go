@@ -89,3 +89,58 @@ func (s *VersionsService) Activate(zone string, versionID int) (*http.Response,
 
 	return resp, nil
 }
+
+// Export returns the full contents of a zone version, including every
+// record, as a point in time snapshot of the zone.
+//
+// NS1 API docs: https://ns1.com/api/#zones-get
+func (s *VersionsService) Export(zone string, versionID int) (*dns.Zone, *http.Response, error) {
+	path := fmt.Sprintf("zones/%s/versions/%d", zone, versionID)
+	req, err := s.client.NewRequest("GET", path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var z dns.Zone
+	var resp *http.Response
+	if s.client.FollowPagination {
+		resp, err = s.client.DoWithPagination(req, &z, (*ZonesService)(s).nextRecords)
+	} else {
+		resp, err = s.client.Do(req, &z)
+	}
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return &z, resp, nil
+}
+
+// Diff returns the changes between two versions of a zone, going from
+// version fromID to version toID.
+func (s *VersionsService) Diff(zone string, fromID, toID int) (*dns.ZoneDiff, *http.Response, error) {
+	from, resp, err := s.Export(zone, fromID)
+	if err != nil {
+		return nil, resp, err
+	}
+	to, resp, err := s.Export(zone, toID)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return dns.DiffZones(from, to), resp, nil
+}
+
+// DiffLive returns the changes that activating a version would make to the
+// live zone, for review before calling Activate.
+func (s *VersionsService) DiffLive(zone string, versionID int) (*dns.ZoneDiff, *http.Response, error) {
+	live, resp, err := (*ZonesService)(s).Get(zone, true)
+	if err != nil {
+		return nil, resp, err
+	}
+	version, resp, err := s.Export(zone, versionID)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	return dns.DiffZones(live, version), resp, nil
+}
@@ -0,0 +1,205 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+)
+
+const (
+	defaultRollbackVerifyAttempts = 3
+	defaultRollbackVerifyDelay    = time.Second * 5
+)
+
+var (
+	// ErrRollbackVerify is returned when the zone does not match the
+	// activated version after a rollback, and the snapshot taken beforehand
+	// has been re-activated.
+	ErrRollbackVerify = errors.New("zone does not match the rolled back version")
+)
+
+// Rollback configures VersionsService.Rollback.
+type Rollback struct {
+	force       bool
+	attempts    int
+	delay       time.Duration
+	checkSerial bool
+	sleep       func(time.Duration)
+}
+
+// SetRollbackForce sets the force flag of the snapshot version created
+// before rolling back.
+func SetRollbackForce(force bool) func(*Rollback) {
+	return func(r *Rollback) { r.force = force }
+}
+
+// SetRollbackVerify sets how many times the zone is checked against the
+// activated version, and the delay before each check, before giving up and
+// re-activating the snapshot. The zone is always checked at least once.
+func SetRollbackVerify(attempts int, delay time.Duration) func(*Rollback) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	return func(r *Rollback) {
+		r.attempts = attempts
+		r.delay = delay
+	}
+}
+
+// SetRollbackCheckSerial sets whether verification requires the zone serial
+// to have advanced past its value before the rollback. It is on by default.
+func SetRollbackCheckSerial(check bool) func(*Rollback) {
+	return func(r *Rollback) { r.checkSerial = check }
+}
+
+// RollbackResult describes a rollback.
+type RollbackResult struct {
+	Zone string
+
+	// Snapshot is the version created from the zone before rolling back.
+	Snapshot *dns.Version
+
+	// Target is the id of the version that was activated.
+	Target int
+
+	// SerialBefore and SerialAfter are the zone serials before the rollback
+	// and at the last verification.
+	SerialBefore int
+	SerialAfter  int
+
+	// Diff holds the differences between the zone and the target version at
+	// the last verification; it is empty on success.
+	Diff *dns.ZoneDiff
+
+	// Reverted reports whether the snapshot was re-activated after a
+	// failed verification.
+	Reverted bool
+}
+
+// Rollback snapshots the current state of a zone as a new version,
+// activates the version with the given id, and verifies that the zone
+// serial advanced and its records match the version. If verification fails,
+// the snapshot is re-activated and ErrRollbackVerify is returned along with
+// the result.
+func (s *VersionsService) Rollback(zone string, versionID int, options ...func(*Rollback)) (*RollbackResult, *http.Response, error) {
+	rb := &Rollback{
+		attempts:    defaultRollbackVerifyAttempts,
+		delay:       defaultRollbackVerifyDelay,
+		checkSerial: true,
+		sleep:       time.Sleep,
+	}
+	for _, option := range options {
+		option(rb)
+	}
+	zones := (*ZonesService)(s)
+
+	target, resp, err := s.Export(zone, versionID)
+	if err != nil {
+		return nil, resp, err
+	}
+	before, resp, err := zones.Get(zone, false)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	snapshot, resp, err := s.Create(zone, rb.force)
+	if err != nil {
+		return nil, resp, fmt.Errorf("creating snapshot of zone %s: %w", zone, err)
+	}
+	result := &RollbackResult{
+		Zone:         zone,
+		Snapshot:     snapshot,
+		Target:       versionID,
+		SerialBefore: before.Serial,
+	}
+
+	resp, err = s.Activate(zone, versionID)
+	if err != nil {
+		return result, resp, err
+	}
+
+	for i := 0; i < rb.attempts; i++ {
+		rb.sleep(rb.delay)
+
+		var live *dns.Zone
+		live, resp, err = zones.Get(zone, true)
+		if err != nil {
+			continue
+		}
+		result.SerialAfter = live.Serial
+		result.Diff = dns.DiffZones(target, live)
+
+		serialOK := !rb.checkSerial || live.Serial > before.Serial
+		if serialOK && len(result.Diff.Records) == 0 {
+			return result, resp, nil
+		}
+	}
+
+	resp, rerr := s.Activate(zone, snapshot.Id)
+	if rerr != nil {
+		return result, resp, fmt.Errorf("re-activating snapshot version %d of zone %s: %w", snapshot.Id, zone, rerr)
+	}
+	result.Reverted = true
+
+	if err != nil {
+		return result, resp, fmt.Errorf("%w: %v", ErrRollbackVerify, err)
+	}
+	return result, resp, ErrRollbackVerify
+}
+
+// VersionRetention is a policy for pruning old zone versions. The active
+// version is always kept.
+type VersionRetention struct {
+	// Keep is the number of most recent versions to keep; zero keeps any
+	// number of them.
+	Keep int
+
+	// MaxAge is the age beyond which versions are pruned; zero keeps
+	// versions of any age.
+	MaxAge time.Duration
+}
+
+// Select returns the versions the policy prunes at the given time, newest
+// first.
+func (p VersionRetention) Select(versions []*dns.Version, now time.Time) []*dns.Version {
+	sorted := append([]*dns.Version{}, versions...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt > sorted[j].CreatedAt })
+
+	prune := []*dns.Version{}
+	for i, v := range sorted {
+		if v.Active {
+			continue
+		}
+		tooMany := p.Keep > 0 && i >= p.Keep
+		tooOld := p.MaxAge > 0 && now.Sub(time.Unix(int64(v.CreatedAt), 0)) > p.MaxAge
+		if tooMany || tooOld {
+			prune = append(prune, v)
+		}
+	}
+	return prune
+}
+
+// Prune deletes the versions of a zone selected by the retention policy,
+// and returns the deleted versions. If a deletion fails, the versions
+// deleted so far are returned along with the error.
+func (s *VersionsService) Prune(zone string, policy VersionRetention) ([]*dns.Version, *http.Response, error) {
+	versions, resp, err := s.List(zone)
+	if err != nil {
+		return nil, resp, err
+	}
+
+	deleted := []*dns.Version{}
+	for _, v := range policy.Select(versions, time.Now()) {
+		resp, err = s.Delete(zone, v.Id)
+		if err != nil {
+			return deleted, resp, fmt.Errorf("deleting version %d of zone %s: %w", v.Id, zone, err)
+		}
+		deleted = append(deleted, v)
+	}
+
+	return deleted, resp, nil
+}
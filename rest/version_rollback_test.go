@@ -0,0 +1,198 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/ns1/ns1-go.v2/rest/model/dns"
+)
+
+// versionServer simulates the versions of a single zone.
+type versionServer struct {
+	mu        sync.Mutex
+	live      []*dns.ZoneRecord
+	serial    int
+	versions  map[int][]*dns.ZoneRecord
+	list      []*dns.Version
+	nextID    int
+	activated []int
+	deleted   []int
+
+	// ignoreActivate makes activations of other versions than ignoreExcept
+	// no-ops, to fail verification.
+	ignoreActivate bool
+	ignoreExcept   int
+}
+
+func (s *versionServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var id int
+	switch {
+	case req.Method == "GET" && req.URL.Path == "/zones/rb.zone":
+		s.write(w, &dns.Zone{Zone: "rb.zone", Serial: s.serial, Records: s.live})
+	case req.Method == "GET" && req.URL.Path == "/zones/rb.zone/versions":
+		s.write(w, s.list)
+	case req.Method == "PUT" && req.URL.Path == "/zones/rb.zone/versions":
+		s.nextID++
+		s.versions[s.nextID] = s.live
+		s.write(w, &dns.Version{Id: s.nextID})
+	case scan(req.URL.Path, "/v1/zones/rb.zone/versions/%d/activate", &id) && req.Method == "POST":
+		s.activated = append(s.activated, id)
+		if !s.ignoreActivate || id == s.ignoreExcept {
+			s.live = s.versions[id]
+			s.serial++
+		}
+		s.write(w, &dns.Version{Id: id})
+	case scan(req.URL.Path, "/zones/rb.zone/versions/%d", &id) && req.Method == "GET":
+		s.write(w, &dns.Zone{Zone: "rb.zone", Records: s.versions[id]})
+	case scan(req.URL.Path, "/zones/rb.zone/versions/%d", &id) && req.Method == "DELETE":
+		s.deleted = append(s.deleted, id)
+		s.write(w, &dns.Version{})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "zone not found"}`)) // nolint: errcheck
+	}
+}
+
+func (s *versionServer) write(w http.ResponseWriter, v interface{}) {
+	json.NewEncoder(w).Encode(v) // nolint: errcheck
+}
+
+func scan(path, format string, id *int) bool {
+	var rest string
+	n, _ := fmt.Sscanf(path+" end", format+" %s", id, &rest)
+	return n == 2 && rest == "end"
+}
+
+func newVersionServer() *versionServer {
+	current := []*dns.ZoneRecord{{Domain: "www.rb.zone", Type: "A", TTL: 300, ShortAns: []string{"2.2.2.2"}}}
+	good := []*dns.ZoneRecord{{Domain: "www.rb.zone", Type: "A", TTL: 300, ShortAns: []string{"1.1.1.1"}}}
+	return &versionServer{
+		live:     current,
+		serial:   100,
+		versions: map[int][]*dns.ZoneRecord{1: good},
+		nextID:   1,
+	}
+}
+
+func TestVersionsService_Rollback(t *testing.T) {
+	noWait := func(r *Rollback) { r.sleep = func(time.Duration) {} }
+
+	t.Run("verified", func(t *testing.T) {
+		srv := newVersionServer()
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		result, _, err := c.Versions.Rollback("rb.zone", 1, noWait)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Snapshot.Id)
+		assert.Equal(t, 100, result.SerialBefore)
+		assert.Equal(t, 101, result.SerialAfter)
+		assert.True(t, result.Diff.Empty())
+		assert.False(t, result.Reverted)
+		assert.Equal(t, []int{1}, srv.activated)
+		assert.Equal(t, []string{"1.1.1.1"}, srv.live[0].ShortAns)
+	})
+
+	t.Run("reverted", func(t *testing.T) {
+		srv := newVersionServer()
+		srv.ignoreActivate = true
+		srv.ignoreExcept = 2
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		var sleeps int
+		result, _, err := c.Versions.Rollback("rb.zone", 1,
+			SetRollbackVerify(2, time.Second), func(r *Rollback) { r.sleep = func(time.Duration) { sleeps++ } })
+		assert.Equal(t, ErrRollbackVerify, err)
+		require.NotNil(t, result)
+		assert.True(t, result.Reverted)
+		assert.Equal(t, 2, sleeps)
+		require.Len(t, result.Diff.Records, 1)
+		assert.Equal(t, []int{1, 2}, srv.activated)
+		assert.Equal(t, []string{"2.2.2.2"}, srv.live[0].ShortAns)
+	})
+
+	t.Run("zero attempts", func(t *testing.T) {
+		srv := newVersionServer()
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		result, _, err := c.Versions.Rollback("rb.zone", 1, SetRollbackVerify(0, 0), noWait)
+		require.NoError(t, err)
+		assert.False(t, result.Reverted)
+		assert.Equal(t, []int{1}, srv.activated)
+	})
+
+	t.Run("missing version", func(t *testing.T) {
+		srv := newVersionServer()
+		ts := httptest.NewServer(srv)
+		defer ts.Close()
+		c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+		c.FollowPagination = false
+
+		_, _, err := c.Versions.Rollback("other.zone", 1, noWait)
+		require.Error(t, err)
+		assert.Empty(t, srv.activated)
+	})
+}
+
+func TestVersionRetention_Select(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	day := 24 * time.Hour
+	created := func(age time.Duration) int { return int(now.Add(-age).Unix()) }
+
+	versions := []*dns.Version{
+		{Id: 1, CreatedAt: created(40 * day), Active: true},
+		{Id: 2, CreatedAt: created(31 * day)},
+		{Id: 3, CreatedAt: created(10 * day)},
+		{Id: 4, CreatedAt: created(2 * day)},
+		{Id: 5, CreatedAt: created(time.Hour)},
+	}
+	ids := func(vs []*dns.Version) []int {
+		out := []int{}
+		for _, v := range vs {
+			out = append(out, v.Id)
+		}
+		return out
+	}
+
+	assert.Equal(t, []int{3, 2}, ids(VersionRetention{Keep: 2}.Select(versions, now)))
+	assert.Equal(t, []int{2}, ids(VersionRetention{MaxAge: 30 * day}.Select(versions, now)))
+	assert.Equal(t, []int{4, 3, 2}, ids(VersionRetention{Keep: 1, MaxAge: 30 * day}.Select(versions, now)))
+	assert.Empty(t, VersionRetention{}.Select(versions, now))
+}
+
+func TestVersionsService_Prune(t *testing.T) {
+	srv := newVersionServer()
+	now := time.Now()
+	srv.list = []*dns.Version{
+		{Id: 1, CreatedAt: int(now.Add(-time.Hour).Unix()), Active: true},
+		{Id: 2, CreatedAt: int(now.Add(-2 * time.Hour).Unix())},
+		{Id: 3, CreatedAt: int(now.Add(-3 * time.Hour).Unix())},
+	}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	c := NewClient(nil, SetEndpoint(ts.URL+"/"))
+
+	deleted, _, err := c.Versions.Prune("rb.zone", VersionRetention{Keep: 2})
+	require.NoError(t, err)
+	require.Len(t, deleted, 1)
+	assert.Equal(t, 3, deleted[0].Id)
+	assert.Equal(t, []int{3}, srv.deleted)
+}
@@ -163,4 +163,86 @@ func TestVersion(t *testing.T) {
 		})
 	})
 
+	t.Run("Export", func(t *testing.T) {
+		t.Run("export version", func(t *testing.T) {
+			defer mock.ClearTestCases()
+
+			client.FollowPagination = false
+			snapshot := &dns.Zone{
+				Zone: "versioned.zone",
+				TTL:  3600,
+				Records: []*dns.ZoneRecord{
+					{Domain: "www.versioned.zone", Type: "A", TTL: 300, ShortAns: []string{"1.2.3.4"}},
+				},
+			}
+			require.Nil(t, mock.AddVersionExportTestCase("versioned.zone", 15, nil, nil, snapshot))
+
+			z, _, err := client.Versions.Export("versioned.zone", 15)
+			require.Nil(t, err)
+			require.Equal(t, "versioned.zone", z.Zone)
+			require.Len(t, z.Records, 1)
+			require.Equal(t, []string{"1.2.3.4"}, z.Records[0].ShortAns)
+		})
+
+		t.Run("error", func(t *testing.T) {
+			t.Run("HTTP", func(t *testing.T) {
+				defer mock.ClearTestCases()
+
+				require.Nil(t, mock.AddTestCase(
+					http.MethodGet, "/zones/versioned.zone/versions/15", http.StatusNotFound,
+					nil, nil, "", `{"message": "version not found"}`,
+				))
+				_, _, err := client.Versions.Export("versioned.zone", 15)
+				require.NotNil(t, err)
+			})
+		})
+	})
+
+	t.Run("Diff", func(t *testing.T) {
+		client.FollowPagination = false
+		v15 := &dns.Zone{
+			Zone: "versioned.zone",
+			TTL:  3600,
+			Records: []*dns.ZoneRecord{
+				{Domain: "www.versioned.zone", Type: "A", TTL: 300, ShortAns: []string{"1.2.3.4", "5.6.7.8"}},
+				{Domain: "old.versioned.zone", Type: "CNAME", TTL: 300, ShortAns: []string{"www.versioned.zone"}},
+			},
+		}
+		v16 := &dns.Zone{
+			Zone: "versioned.zone",
+			TTL:  7200,
+			Records: []*dns.ZoneRecord{
+				{Domain: "www.versioned.zone", Type: "A", TTL: 300, ShortAns: []string{"5.6.7.8", "1.2.3.4"}},
+				{Domain: "new.versioned.zone", Type: "A", TTL: 60, ShortAns: []string{"9.9.9.9"}},
+			},
+		}
+
+		t.Run("between versions", func(t *testing.T) {
+			defer mock.ClearTestCases()
+
+			require.Nil(t, mock.AddVersionExportTestCase("versioned.zone", 15, nil, nil, v15))
+			require.Nil(t, mock.AddVersionExportTestCase("versioned.zone", 16, nil, nil, v16))
+
+			diff, _, err := client.Versions.Diff("versioned.zone", 15, 16)
+			require.Nil(t, err)
+			require.Equal(t, []string{"ttl"}, diff.Fields)
+			require.Len(t, diff.Records, 2)
+			require.Equal(t, dns.ChangeAdded, diff.Records[0].Change)
+			require.Equal(t, "new.versioned.zone", diff.Records[0].Domain)
+			require.Equal(t, dns.ChangeRemoved, diff.Records[1].Change)
+			require.Equal(t, "old.versioned.zone", diff.Records[1].Domain)
+		})
+
+		t.Run("against live zone", func(t *testing.T) {
+			defer mock.ClearTestCases()
+
+			require.Nil(t, mock.AddZoneGetTestCase("versioned.zone", nil, nil, v16, true))
+			require.Nil(t, mock.AddVersionExportTestCase("versioned.zone", 16, nil, nil, v16))
+
+			diff, _, err := client.Versions.DiffLive("versioned.zone", 16)
+			require.Nil(t, err)
+			require.True(t, diff.Empty())
+		})
+	})
+
 }